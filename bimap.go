@@ -0,0 +1,228 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BiMapPolicy is a type which specifies the behavior of BiMap when a value
+// to be stored is already mapped to another key.
+type BiMapPolicy int
+
+const (
+	// RejectOnCollision is a BiMapPolicy which makes Store return a
+	// ValueCollisionError.
+	RejectOnCollision BiMapPolicy = iota
+
+	// EvictOnCollision is a BiMapPolicy which makes Store delete the entry of
+	// the other key before storing.
+	EvictOnCollision
+)
+
+// ValueCollisionError is an error type which is returned by BiMap when
+// attempting to store a value which is already mapped to another key.
+type ValueCollisionError struct {
+	Value any
+}
+
+func (err ValueCollisionError) Error() string {
+	return fmt.Sprintf("value is already mapped to another key: %v", err.Value)
+}
+
+// BiMap is a struct which represents a bidirectional map of which values are
+// unique, so that a key can be looked up by a value as well as a value by a
+// key. This map preserves the order in which keys were inserted.
+//
+// A BiMap has to be created by NewBiMap, because a BiMap and its inverse view
+// returned by Inverse share the same entries.
+type BiMap[K, V comparable] struct {
+	fwd    *Map[K, V]
+	bwd    *Map[V, K]
+	policy BiMapPolicy
+}
+
+// NewBiMap is a function which creates a new BiMap, which is empty.
+// The policy specifies the behavior on a value collision.
+func NewBiMap[K, V comparable](policy BiMapPolicy) BiMap[K, V] {
+	fwd := New[K, V]()
+	bwd := New[V, K]()
+	return BiMap[K, V]{fwd: &fwd, bwd: &bwd, policy: policy}
+}
+
+// Len is a method which returns the number of entries in this map.
+func (bm *BiMap[K, V]) Len() int {
+	if bm.fwd == nil {
+		return 0
+	}
+	return bm.fwd.Len()
+}
+
+// Store is a method which sets a value for a key.
+// If the value is already mapped to another key, this method returns a
+// ValueCollisionError or deletes the entry of the other key according to the
+// policy of this map.
+// If the key was present, its position is not changed.
+func (bm *BiMap[K, V]) Store(key K, value V) error {
+	if bm.fwd == nil {
+		*bm = NewBiMap[K, V](bm.policy)
+	}
+
+	other, exists := bm.bwd.Load(value)
+	if exists {
+		if other == key {
+			return nil
+		}
+		if bm.policy != EvictOnCollision {
+			return ValueCollisionError{Value: value}
+		}
+		bm.fwd.Delete(other)
+		bm.bwd.Delete(value)
+	}
+
+	old, exists := bm.fwd.Load(key)
+	if exists {
+		bm.fwd.Store(key, value)
+		bm.bwd.rekey(old, value)
+		return nil
+	}
+
+	bm.fwd.Store(key, value)
+	bm.bwd.Store(value, key)
+	return nil
+}
+
+// rekey is a method which replaces the key of an entry with a new key without
+// changing the position of the entry.
+func (om *Map[K, V]) rekey(oldKey, newKey K) {
+	om.beforeWrite()
+
+	ent := om.m[oldKey]
+	delete(om.m, oldKey)
+	ent.key = newKey
+	om.m[newKey] = ent
+}
+
+// LoadByKey is a method which returns a value mapped to a key.
+// If no value was found, the ok result is false.
+func (bm *BiMap[K, V]) LoadByKey(key K) (value V, ok bool) {
+	if bm.fwd == nil {
+		return
+	}
+	return bm.fwd.Load(key)
+}
+
+// LoadByValue is a method which returns a key mapped to a value.
+// If no key was found, the ok result is false.
+func (bm *BiMap[K, V]) LoadByValue(value V) (key K, ok bool) {
+	if bm.bwd == nil {
+		return
+	}
+	return bm.bwd.Load(value)
+}
+
+// DeleteByKey is a method which deletes an entry for a key.
+func (bm *BiMap[K, V]) DeleteByKey(key K) {
+	if bm.fwd == nil {
+		return
+	}
+	value, loaded := bm.fwd.LoadAndDelete(key)
+	if loaded {
+		bm.bwd.Delete(value)
+	}
+}
+
+// DeleteByValue is a method which deletes an entry for a value.
+func (bm *BiMap[K, V]) DeleteByValue(value V) {
+	if bm.bwd == nil {
+		return
+	}
+	key, loaded := bm.bwd.LoadAndDelete(value)
+	if loaded {
+		bm.fwd.Delete(key)
+	}
+}
+
+// Inverse is a method which returns a BiMap of which keys and values are
+// swapped. The returned BiMap is not a copy but a view which shares the
+// entries with this map, so modifications through either of them are
+// reflected to both.
+func (bm *BiMap[K, V]) Inverse() BiMap[V, K] {
+	if bm.fwd == nil {
+		*bm = NewBiMap[K, V](bm.policy)
+	}
+	return BiMap[V, K]{fwd: bm.bwd, bwd: bm.fwd, policy: bm.policy}
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in this map in the order of key insertions.
+// If fn returns false, this method stops the iteration.
+func (bm *BiMap[K, V]) Range(fn func(key K, value V) bool) {
+	if bm.fwd == nil {
+		return
+	}
+	bm.fwd.Range(fn)
+}
+
+// Front is a method which returns the head entry of this map.
+func (bm *BiMap[K, V]) Front() *Entry[K, V] {
+	if bm.fwd == nil {
+		return nil
+	}
+	return bm.fwd.Front()
+}
+
+// Back is a method which returns the last entry of this map.
+func (bm *BiMap[K, V]) Back() *Entry[K, V] {
+	if bm.fwd == nil {
+		return nil
+	}
+	return bm.fwd.Back()
+}
+
+// String is a method which returns a string of the content of this map.
+func (bm BiMap[K, V]) String() string {
+	var buf strings.Builder
+	buf.WriteString("BiMap[")
+	ent := bm.Front()
+	if ent != nil {
+		buf.WriteString(fmt.Sprintf("%v:%v", ent.Key(), ent.Value()))
+		for ent = ent.Next(); ent != nil; ent = ent.Next() {
+			buf.WriteString(fmt.Sprintf(" %v:%v", ent.Key(), ent.Value()))
+		}
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
+
+// MarshalJSON is a method which returns a JSON string of the content of this
+// map like Map.MarshalJSON.
+// This method is an implementation of json.Marshaler interface.
+func (bm BiMap[K, V]) MarshalJSON() ([]byte, error) {
+	if bm.fwd == nil {
+		return []byte("{}"), nil
+	}
+	return bm.fwd.MarshalJSON()
+}
+
+// UnmarshalJSON is a method which stores the entries in a JSON string into
+// this map in the order in the JSON string, like Map.UnmarshalJSON.
+// Value collisions are handled according to the policy of this map.
+// This method is an implementation of json.Unmarshaler interface.
+func (bm *BiMap[K, V]) UnmarshalJSON(data []byte) error {
+	om := New[K, V]()
+	err := om.UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	for ent := om.Front(); ent != nil; ent = ent.Next() {
+		err = bm.Store(ent.key, ent.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package orderedmap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func TestNewBiMap(t *testing.T) {
+	bm := orderedmap.NewBiMap[string, int](orderedmap.RejectOnCollision)
+	assert.Equal(t, bm.Len(), 0)
+	assert.Nil(t, bm.Front())
+	assert.Nil(t, bm.Back())
+	assert.Equal(t, bm.String(), "BiMap[]")
+}
+
+func TestBiMap_Store(t *testing.T) {
+	bm := orderedmap.NewBiMap[string, int](orderedmap.RejectOnCollision)
+	assert.Nil(t, bm.Store("ext-c", 3))
+	assert.Nil(t, bm.Store("ext-a", 1))
+	assert.Nil(t, bm.Store("ext-b", 2))
+	assert.Equal(t, bm.Len(), 3)
+	assert.Equal(t, bm.String(), "BiMap[ext-c:3 ext-a:1 ext-b:2]")
+
+	v, ok := bm.LoadByKey("ext-a")
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	_, ok = bm.LoadByKey("ext-z")
+	assert.False(t, ok)
+
+	k, ok := bm.LoadByValue(2)
+	assert.True(t, ok)
+	assert.Equal(t, k, "ext-b")
+	_, ok = bm.LoadByValue(9)
+	assert.False(t, ok)
+}
+
+func TestBiMap_Store_updateValue(t *testing.T) {
+	bm := orderedmap.NewBiMap[string, int](orderedmap.RejectOnCollision)
+	bm.Store("a", 1)
+	bm.Store("b", 2)
+	bm.Store("c", 3)
+
+	assert.Nil(t, bm.Store("a", 10))
+	assert.Equal(t, bm.String(), "BiMap[a:10 b:2 c:3]")
+	_, ok := bm.LoadByValue(1)
+	assert.False(t, ok)
+	k, _ := bm.LoadByValue(10)
+	assert.Equal(t, k, "a")
+
+	inv := bm.Inverse()
+	assert.Equal(t, inv.String(), "BiMap[10:a 2:b 3:c]")
+
+	assert.Nil(t, bm.Store("b", 2))
+	assert.Equal(t, bm.Len(), 3)
+}
+
+func TestBiMap_Store_rejectOnCollision(t *testing.T) {
+	bm := orderedmap.NewBiMap[string, int](orderedmap.RejectOnCollision)
+	bm.Store("a", 1)
+	bm.Store("b", 2)
+
+	err := bm.Store("c", 1)
+	assert.Equal(t, err.Error(), "value is already mapped to another key: 1")
+	var e orderedmap.ValueCollisionError
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, e.Value, 1)
+
+	err = bm.Store("b", 1)
+	assert.NotNil(t, err)
+	assert.Equal(t, bm.String(), "BiMap[a:1 b:2]")
+}
+
+func TestBiMap_Store_evictOnCollision(t *testing.T) {
+	bm := orderedmap.NewBiMap[string, int](orderedmap.EvictOnCollision)
+	bm.Store("a", 1)
+	bm.Store("b", 2)
+	bm.Store("c", 3)
+
+	assert.Nil(t, bm.Store("d", 1))
+	assert.Equal(t, bm.String(), "BiMap[b:2 c:3 d:1]")
+	_, ok := bm.LoadByKey("a")
+	assert.False(t, ok)
+
+	assert.Nil(t, bm.Store("b", 3))
+	assert.Equal(t, bm.String(), "BiMap[b:3 d:1]")
+	k, _ := bm.LoadByValue(3)
+	assert.Equal(t, k, "b")
+	_, ok = bm.LoadByValue(2)
+	assert.False(t, ok)
+
+	inv := bm.Inverse()
+	assert.Equal(t, inv.String(), "BiMap[3:b 1:d]")
+}
+
+func TestBiMap_Delete(t *testing.T) {
+	bm := orderedmap.NewBiMap[string, int](orderedmap.RejectOnCollision)
+	bm.Store("a", 1)
+	bm.Store("b", 2)
+	bm.Store("c", 3)
+
+	bm.DeleteByKey("b")
+	assert.Equal(t, bm.String(), "BiMap[a:1 c:3]")
+	_, ok := bm.LoadByValue(2)
+	assert.False(t, ok)
+
+	bm.DeleteByValue(1)
+	assert.Equal(t, bm.String(), "BiMap[c:3]")
+	_, ok = bm.LoadByKey("a")
+	assert.False(t, ok)
+
+	bm.DeleteByKey("x")
+	bm.DeleteByValue(9)
+	assert.Equal(t, bm.Len(), 1)
+
+	assert.Nil(t, bm.Store("b", 2))
+	assert.Equal(t, bm.String(), "BiMap[c:3 b:2]")
+}
+
+func TestBiMap_Inverse_isView(t *testing.T) {
+	bm := orderedmap.NewBiMap[string, int](orderedmap.RejectOnCollision)
+	bm.Store("a", 1)
+	bm.Store("b", 2)
+
+	inv := bm.Inverse()
+	assert.Equal(t, inv.Len(), 2)
+	k, ok := inv.LoadByKey(2)
+	assert.True(t, ok)
+	assert.Equal(t, k, "b")
+
+	assert.Nil(t, inv.Store(3, "c"))
+	v, ok := bm.LoadByKey("c")
+	assert.True(t, ok)
+	assert.Equal(t, v, 3)
+	assert.Equal(t, bm.String(), "BiMap[a:1 b:2 c:3]")
+
+	err := inv.Store(4, "a")
+	assert.NotNil(t, err)
+
+	inv.DeleteByKey(1)
+	assert.Equal(t, bm.String(), "BiMap[b:2 c:3]")
+
+	bm.Store("d", 4)
+	assert.Equal(t, inv.String(), "BiMap[2:b 3:c 4:d]")
+
+	back := inv.Inverse()
+	back.Store("e", 5)
+	assert.Equal(t, bm.String(), "BiMap[b:2 c:3 d:4 e:5]")
+}
+
+func TestBiMap_Range(t *testing.T) {
+	bm := orderedmap.NewBiMap[string, int](orderedmap.RejectOnCollision)
+	bm.Store("c", 3)
+	bm.Store("a", 1)
+
+	var keys []string
+	bm.Range(func(k string, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	assert.Equal(t, keys, []string{"c", "a"})
+
+	var values []int
+	for ent := bm.Back(); ent != nil; ent = ent.Prev() {
+		values = append(values, ent.Value())
+	}
+	assert.Equal(t, values, []int{1, 3})
+}
+
+func TestBiMap_zeroValue(t *testing.T) {
+	var bm orderedmap.BiMap[string, int]
+	assert.Equal(t, bm.Len(), 0)
+	_, ok := bm.LoadByKey("a")
+	assert.False(t, ok)
+	_, ok = bm.LoadByValue(1)
+	assert.False(t, ok)
+	bm.DeleteByKey("a")
+	bm.DeleteByValue(1)
+	bm.Range(func(k string, v int) bool { return true })
+
+	b, err := json.Marshal(bm)
+	assert.Nil(t, err)
+	assert.Equal(t, string(b), "{}")
+
+	assert.Nil(t, bm.Store("a", 1))
+	assert.NotNil(t, bm.Store("b", 1))
+	assert.Equal(t, bm.String(), "BiMap[a:1]")
+}
+
+func TestBiMap_JSON(t *testing.T) {
+	bm := orderedmap.NewBiMap[string, int](orderedmap.RejectOnCollision)
+	bm.Store("z", 26)
+	bm.Store("a", 1)
+
+	b, err := json.Marshal(bm)
+	assert.Nil(t, err)
+	assert.Equal(t, string(b), `{"z":26,"a":1}`)
+
+	bm2 := orderedmap.NewBiMap[string, int](orderedmap.RejectOnCollision)
+	assert.Nil(t, json.Unmarshal(b, &bm2))
+	assert.Equal(t, bm2.String(), "BiMap[z:26 a:1]")
+	k, _ := bm2.LoadByValue(1)
+	assert.Equal(t, k, "a")
+
+	bm3 := orderedmap.NewBiMap[string, int](orderedmap.RejectOnCollision)
+	err = json.Unmarshal([]byte(`{"a":1,"b":1}`), &bm3)
+	assert.NotNil(t, err)
+
+	bm4 := orderedmap.NewBiMap[string, int](orderedmap.EvictOnCollision)
+	assert.Nil(t, json.Unmarshal([]byte(`{"a":1,"b":1}`), &bm4))
+	assert.Equal(t, bm4.String(), "BiMap[b:1]")
+}
@@ -0,0 +1,725 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"bytes"
+	"encoding"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// This file provides the common parts of the encoders and decoders of binary
+// formats: MessagePack and CBOR, which can express maps with non-string keys.
+
+const maxBinaryDepth = 1000
+
+// UnsupportedValueTypeError is an error type which is returned by binary
+// encoders when attempting to encode a value of an unsupported type.
+type UnsupportedValueTypeError struct {
+	Format string
+	Type   reflect.Type
+}
+
+func (err UnsupportedValueTypeError) Error() string {
+	if err.Type == nil {
+		return err.Format + ": unsupported value type: any"
+	} else {
+		return err.Format + ": unsupported value type: " + err.Type.String()
+	}
+}
+
+// DecodeError is an error type which is returned by binary decoders when an
+// input data is malformed.
+type DecodeError struct {
+	Format string
+	Offset int64
+	msg    string
+}
+
+func (err DecodeError) Error() string {
+	return err.Format + ": " + err.msg + " (offset:" +
+		strconv.FormatInt(err.Offset, 10) + ")"
+}
+
+// DecodeTypeError is an error type which is returned by binary decoders when
+// a decoded value cannot be stored into a Go value of a specific type.
+type DecodeTypeError struct {
+	Format string
+	Value  string
+	Type   reflect.Type
+}
+
+func (err DecodeTypeError) Error() string {
+	return err.Format + ": cannot decode " + err.Value +
+		" into Go value of type " + err.Type.String()
+}
+
+// anyMap is an interface which is implemented by ordered maps to make
+// binary encoders be able to iterate their entries without type parameters.
+type anyMap interface {
+	lenAny() int
+	rangeAny(fn func(key, value any) error) error
+}
+
+// anyMapStorer is an interface which is implemented by pointers of ordered
+// maps to make binary decoders be able to store entries without type
+// parameters.
+type anyMapStorer interface {
+	storeAny(format string, key, value any) error
+}
+
+func (om Map[K, V]) lenAny() int {
+	return om.len
+}
+
+func (om Map[K, V]) rangeAny(fn func(key, value any) error) error {
+	for ent := om.Front(); ent != nil; ent = ent.Next() {
+		err := fn(ent.Key(), ent.Value())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (hm HashMap[K, V]) lenAny() int {
+	return hm.om.len
+}
+
+func (hm HashMap[K, V]) rangeAny(fn func(key, value any) error) error {
+	for ent := hm.om.head; ent != nil; ent = ent.next {
+		err := fn(ent.key.key, ent.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (om *Map[K, V]) storeAny(format string, key, value any) error {
+	if om.m == nil {
+		om.m = make(map[K](*Entry[K, V]))
+	}
+	k, err := convertBinary[K](format, key)
+	if err != nil {
+		return err
+	}
+	v, err := convertBinary[V](format, value)
+	if err != nil {
+		return err
+	}
+	om.Store(k, v)
+	return nil
+}
+
+func (hm *HashMap[K, V]) storeAny(format string, key, value any) error {
+	if hm.keys == nil {
+		return DecodeTypeError{
+			Format: format,
+			Value:  "map",
+			Type:   reflect.TypeOf(hm).Elem(),
+		}
+	}
+	k, err := convertBinary[K](format, key)
+	if err != nil {
+		return err
+	}
+	v, err := convertBinary[V](format, value)
+	if err != nil {
+		return err
+	}
+	hm.Store(k, v)
+	return nil
+}
+
+type binaryWriter interface {
+	format() string
+	writeNil()
+	writeBool(b bool)
+	writeInt(n int64)
+	writeUint(n uint64)
+	writeFloat32(f float32)
+	writeFloat64(f float64)
+	writeString(s string)
+	writeBytes(b []byte)
+	writeArrayHeader(n int)
+	writeMapHeader(n int)
+	writeRaw(b []byte)
+	newWriter() binaryWriter
+	bytes() []byte
+}
+
+func encodeBinary(w binaryWriter, v any, depth int) error {
+	if depth > maxBinaryDepth {
+		return UnsupportedValueTypeError{Format: w.format(), Type: reflect.TypeOf(v)}
+	}
+
+	switch x := v.(type) {
+	case nil:
+		w.writeNil()
+		return nil
+	case bool:
+		w.writeBool(x)
+		return nil
+	case string:
+		w.writeString(x)
+		return nil
+	case []byte:
+		if x == nil {
+			w.writeNil()
+		} else {
+			w.writeBytes(x)
+		}
+		return nil
+	case int:
+		w.writeInt(int64(x))
+		return nil
+	case int64:
+		w.writeInt(x)
+		return nil
+	case uint64:
+		w.writeUint(x)
+		return nil
+	case float32:
+		w.writeFloat32(x)
+		return nil
+	case float64:
+		w.writeFloat64(x)
+		return nil
+	case anyMap:
+		if isNilPointer(v) {
+			w.writeNil()
+			return nil
+		}
+		w.writeMapHeader(x.lenAny())
+		return x.rangeAny(func(key, value any) error {
+			err := encodeBinary(w, key, depth+1)
+			if err != nil {
+				return err
+			}
+			return encodeBinary(w, value, depth+1)
+		})
+	}
+
+	layout := w.format() == binaryLayoutFormat
+	if m, ok := v.(encoding.BinaryMarshaler); ok && layout {
+		if isNilPointer(v) {
+			w.writeNil()
+			return nil
+		}
+		b, err := m.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		w.writeBytes(b)
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		w.writeBool(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.writeInt(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		w.writeUint(rv.Uint())
+	case reflect.Float32:
+		w.writeFloat32(float32(rv.Float()))
+	case reflect.Float64:
+		w.writeFloat64(rv.Float())
+	case reflect.String:
+		w.writeString(rv.String())
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		return encodeBinary(w, rv.Elem().Interface(), depth+1)
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			bs := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(bs), rv)
+			w.writeBytes(bs)
+			return nil
+		}
+		w.writeArrayHeader(rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			err := encodeBinary(w, rv.Index(i).Interface(), depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if rv.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		return encodeBinaryGoMap(w, rv, depth)
+	case reflect.Struct:
+		if !layout {
+			return UnsupportedValueTypeError{Format: w.format(), Type: rv.Type()}
+		}
+		return encodeBinaryStruct(w, rv, depth)
+	default:
+		return UnsupportedValueTypeError{Format: w.format(), Type: rv.Type()}
+	}
+	return nil
+}
+
+// encodeBinaryGoMap encodes a Go map. Because the iteration order of a Go
+// map is not stable, its entries are sorted by the encoded bytes of keys.
+func encodeBinaryGoMap(w binaryWriter, rv reflect.Value, depth int) error {
+	type pair struct {
+		key   []byte
+		value reflect.Value
+	}
+	pairs := make([]pair, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		kw := w.newWriter()
+		err := encodeBinary(kw, iter.Key().Interface(), depth+1)
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, pair{key: kw.bytes(), value: iter.Value()})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return bytes.Compare(pairs[i].key, pairs[j].key) < 0
+	})
+
+	w.writeMapHeader(len(pairs))
+	for _, p := range pairs {
+		w.writeRaw(p.key)
+		err := encodeBinary(w, p.value.Interface(), depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type binaryKind int
+
+const (
+	binaryNil binaryKind = iota
+	binaryBool
+	binaryInt
+	binaryUint
+	binaryFloat32
+	binaryFloat64
+	binaryString
+	binaryBytes
+	binaryArray
+	binaryMap
+	binaryBreak
+)
+
+func (k binaryKind) String() string {
+	switch k {
+	case binaryNil:
+		return "nil"
+	case binaryBool:
+		return "bool"
+	case binaryInt, binaryUint:
+		return "integer"
+	case binaryFloat32, binaryFloat64:
+		return "float"
+	case binaryString:
+		return "string"
+	case binaryBytes:
+		return "binary"
+	case binaryArray:
+		return "array"
+	case binaryMap:
+		return "map"
+	}
+	return "break"
+}
+
+// binaryToken is a decoded item header. If its kind is binaryArray or
+// binaryMap, n is the number of elements or entries, or -1 if it is of an
+// indefinite length.
+type binaryToken struct {
+	kind binaryKind
+	b    bool
+	i    int64
+	u    uint64
+	f    float64
+	s    string
+	bs   []byte
+	n    int
+}
+
+type binaryReader interface {
+	format() string
+	offset() int64
+	remaining() int
+	readToken() (binaryToken, error)
+}
+
+func binaryErrorf(r binaryReader, msg string) error {
+	return DecodeError{Format: r.format(), Offset: r.offset(), msg: msg}
+}
+
+func decodeBinary(r binaryReader, depth int) (any, error) {
+	tok, err := r.readToken()
+	if err != nil {
+		return nil, err
+	}
+	return decodeBinaryToken(r, tok, depth)
+}
+
+func decodeBinaryToken(r binaryReader, tok binaryToken, depth int) (any, error) {
+	if depth > maxBinaryDepth {
+		return nil, binaryErrorf(r, "too deeply nested")
+	}
+
+	switch tok.kind {
+	case binaryNil:
+		return nil, nil
+	case binaryBool:
+		return tok.b, nil
+	case binaryInt:
+		return tok.i, nil
+	case binaryUint:
+		if tok.u <= math.MaxInt64 {
+			return int64(tok.u), nil
+		}
+		return tok.u, nil
+	case binaryFloat32:
+		return float32(tok.f), nil
+	case binaryFloat64:
+		return tok.f, nil
+	case binaryString:
+		return tok.s, nil
+	case binaryBytes:
+		return tok.bs, nil
+	case binaryArray:
+		if tok.n > r.remaining() {
+			return nil, binaryErrorf(r, "unexpected end of data")
+		}
+		arr := make([]any, 0, maxInt(tok.n, 0))
+		for i := 0; tok.n < 0 || i < tok.n; i++ {
+			t, err := r.readToken()
+			if err != nil {
+				return nil, err
+			}
+			if t.kind == binaryBreak && tok.n < 0 {
+				break
+			}
+			elem, err := decodeBinaryToken(r, t, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, elem)
+		}
+		return arr, nil
+	case binaryMap:
+		return decodeBinaryMap(r, tok.n, depth)
+	}
+	return nil, binaryErrorf(r, "unexpected break")
+}
+
+// decodeBinaryMap decodes a nested map into an ordered map. If all keys are
+// strings, the map is decoded into *Map[string, any], and if all keys are
+// integers, the map is decoded into *Map[int64, any]. Otherwise, such as
+// keys of mixed types, booleans, floats or integers larger than
+// math.MaxInt64, the map is decoded into *HashMap[any, any], because any does
+// not satisfy comparable before Go 1.20.
+// Keys which are byte strings or arrays cannot be keys of a Go map, so a map
+// which has such keys causes a DecodeTypeError.
+func decodeBinaryMap(r binaryReader, n int, depth int) (any, error) {
+	keys, values, err := decodeBinaryEntries(r, n, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	allInts, allStrings := true, true
+	for _, k := range keys {
+		switch k.(type) {
+		case int64:
+			allStrings = false
+		case string:
+			allInts = false
+		default:
+			allInts, allStrings = false, false
+		}
+	}
+
+	if allInts && len(keys) > 0 {
+		om := New[int64, any]()
+		for i, k := range keys {
+			om.Store(k.(int64), values[i])
+		}
+		return &om, nil
+	}
+
+	if allStrings {
+		om := New[string, any]()
+		for i, k := range keys {
+			om.Store(k.(string), values[i])
+		}
+		return &om, nil
+	}
+
+	hm := NewWithHasher[any, any](hashAny, equalAny)
+	for i, k := range keys {
+		if t := reflect.TypeOf(k); t != nil && !t.Comparable() {
+			return nil, DecodeTypeError{
+				Format: r.format(),
+				Value:  "map key of " + binaryTypeName(k),
+				Type:   reflect.TypeOf((*any)(nil)).Elem(),
+			}
+		}
+		hm.Store(k, values[i])
+	}
+	return &hm, nil
+}
+
+func equalAny(a, b any) bool {
+	return a == b
+}
+
+func decodeBinaryEntries(r binaryReader, n int, depth int) ([]any, []any, error) {
+	if n > r.remaining()/2 {
+		return nil, nil, binaryErrorf(r, "unexpected end of data")
+	}
+	keys := make([]any, 0, maxInt(n, 0))
+	values := make([]any, 0, maxInt(n, 0))
+	for i := 0; n < 0 || i < n; i++ {
+		t, err := r.readToken()
+		if err != nil {
+			return nil, nil, err
+		}
+		if t.kind == binaryBreak && n < 0 {
+			break
+		}
+		k, err := decodeBinaryToken(r, t, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		v, err := decodeBinary(r, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	return keys, values, nil
+}
+
+// unmarshalBinary decodes a map at the top level of a data into an ordered
+// map.
+func unmarshalBinary(r binaryReader, om anyMapStorer) error {
+	tok, err := r.readToken()
+	if err != nil {
+		return err
+	}
+	if tok.kind != binaryMap {
+		return binaryErrorf(r, "the input data is not a map but "+tok.kind.String())
+	}
+
+	keys, values, err := decodeBinaryEntries(r, tok.n, 0)
+	if err != nil {
+		return err
+	}
+	for i, k := range keys {
+		err = om.storeAny(r.format(), k, values[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.remaining() > 0 {
+		return binaryErrorf(r, "invalid data after the top-level map")
+	}
+	return nil
+}
+
+// convertBinary converts a decoded value to a value of a type parameter.
+func convertBinary[T any](format string, v any) (T, error) {
+	var t T
+	if p, ok := any(&t).(*any); ok {
+		*p = v
+		return t, nil
+	}
+	if x, ok := v.(T); ok {
+		return x, nil
+	}
+	err := assignBinary(format, reflect.ValueOf(&t).Elem(), v)
+	return t, err
+}
+
+func assignBinary(format string, dst reflect.Value, v any) error {
+	typeError := func() error {
+		return DecodeTypeError{
+			Format: format,
+			Value:  binaryTypeName(v),
+			Type:   dst.Type(),
+		}
+	}
+
+	if v == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	sv := reflect.ValueOf(v)
+
+	if bs, ok := v.([]byte); ok && format == binaryLayoutFormat {
+		if u, ok := dst.Addr().Interface().(encoding.BinaryUnmarshaler); ok {
+			return u.UnmarshalBinary(bs)
+		}
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if sv.Type().AssignableTo(dst.Type()) {
+			dst.Set(sv)
+			return nil
+		}
+	case reflect.Ptr:
+		elem := reflect.New(dst.Type().Elem())
+		err := assignBinary(format, elem.Elem(), v)
+		if err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	case reflect.Bool:
+		if b, ok := v.(bool); ok {
+			dst.SetBool(b)
+			return nil
+		}
+	case reflect.String:
+		if s, ok := v.(string); ok {
+			dst.SetString(s)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := v.(int64); ok && !dst.OverflowInt(n) {
+			dst.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		switch n := v.(type) {
+		case int64:
+			if n >= 0 && !dst.OverflowUint(uint64(n)) {
+				dst.SetUint(uint64(n))
+				return nil
+			}
+		case uint64:
+			if !dst.OverflowUint(n) {
+				dst.SetUint(n)
+				return nil
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		switch f := v.(type) {
+		case float32:
+			dst.SetFloat(float64(f))
+			return nil
+		case float64:
+			dst.SetFloat(f)
+			return nil
+		}
+	case reflect.Slice:
+		if bs, ok := v.([]byte); ok && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes(append([]byte{}, bs...))
+			return nil
+		}
+		if arr, ok := v.([]any); ok {
+			s := reflect.MakeSlice(dst.Type(), len(arr), len(arr))
+			for i, elem := range arr {
+				err := assignBinary(format, s.Index(i), elem)
+				if err != nil {
+					return err
+				}
+			}
+			dst.Set(s)
+			return nil
+		}
+	case reflect.Array:
+		if bs, ok := v.([]byte); ok && len(bs) == dst.Len() &&
+			dst.Type().Elem().Kind() == reflect.Uint8 {
+			reflect.Copy(dst, reflect.ValueOf(bs))
+			return nil
+		}
+		if arr, ok := v.([]any); ok && len(arr) == dst.Len() {
+			for i, elem := range arr {
+				err := assignBinary(format, dst.Index(i), elem)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Map:
+		if src, ok := v.(anyMap); ok {
+			m := reflect.MakeMapWithSize(dst.Type(), src.lenAny())
+			err := src.rangeAny(func(key, value any) error {
+				k := reflect.New(dst.Type().Key()).Elem()
+				err := assignBinary(format, k, key)
+				if err != nil {
+					return err
+				}
+				e := reflect.New(dst.Type().Elem()).Elem()
+				err = assignBinary(format, e, value)
+				if err != nil {
+					return err
+				}
+				m.SetMapIndex(k, e)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			dst.Set(m)
+			return nil
+		}
+	case reflect.Struct:
+		if arr, ok := v.([]any); ok && format == binaryLayoutFormat {
+			if assignBinaryStruct(dst, arr) {
+				return nil
+			}
+			break
+		}
+		src, ok := v.(anyMap)
+		if !ok {
+			break
+		}
+		storer, ok := dst.Addr().Interface().(anyMapStorer)
+		if !ok {
+			break
+		}
+		return src.rangeAny(func(key, value any) error {
+			return storer.storeAny(format, key, value)
+		})
+	}
+	return typeError()
+}
+
+func binaryTypeName(v any) string {
+	if v == nil {
+		return "nil"
+	}
+	return reflect.TypeOf(v).String()
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
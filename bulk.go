@@ -0,0 +1,387 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+// bulk is a method which calls fn in a Batch only if this map is observed, so
+// that the changes by a bulk operation are notified together.
+func (om *Map[K, V]) bulk(fn func()) {
+	if om.observed() {
+		om.Batch(fn)
+	} else {
+		fn()
+	}
+}
+
+// StoreAll is a method which stores each key and value yielded by seq in the
+// order of yielding. The seq can be the Range method of another map.
+func (om *Map[K, V]) StoreAll(seq func(yield func(key K, value V) bool)) {
+	om.bulk(func() {
+		seq(func(k K, v V) bool {
+			om.Store(k, v)
+			return true
+		})
+	})
+}
+
+// StorePairs is a method which stores the keys and values of pairs in order.
+// The First field of a Pair is a key and the Second field is a value.
+func (om *Map[K, V]) StorePairs(pairs ...Pair[K, V]) {
+	om.bulk(func() {
+		for _, p := range pairs {
+			om.Store(p.First, p.Second)
+		}
+	})
+}
+
+// DeleteAll is a method which deletes values for keys, and returns the number
+// of deleted entries.
+func (om *Map[K, V]) DeleteAll(keys ...K) int {
+	n := 0
+	om.bulk(func() {
+		for _, k := range keys {
+			if _, loaded := om.LoadAndDelete(k); loaded {
+				n++
+			}
+		}
+	})
+	return n
+}
+
+// DeleteIf is a method which deletes the entries for which pred returns true,
+// and returns the number of deleted entries.
+// The pred must not modify this map.
+func (om *Map[K, V]) DeleteIf(pred func(key K, value V) bool) int {
+	return om.removeIf(pred, false)
+}
+
+// LdeleteIf is a method which logically deletes the entries for which pred
+// returns true, and returns the number of deleted entries.
+// The pred must not modify this map.
+func (om *Map[K, V]) LdeleteIf(pred func(key K, value V) bool) int {
+	return om.removeIf(pred, true)
+}
+
+// RetainIf is a method which deletes the entries for which pred returns
+// false, and returns the number of deleted entries.
+// The pred must not modify this map.
+func (om *Map[K, V]) RetainIf(pred func(key K, value V) bool) int {
+	return om.removeIf(func(k K, v V) bool { return !pred(k, v) }, false)
+}
+
+func (om *Map[K, V]) removeIf(pred func(key K, value V) bool, logical bool) int {
+	if om.len == 0 {
+		return 0
+	}
+
+	n := 0
+	om.bulk(func() {
+		pos := 0
+		for ent := om.head; ent != nil; {
+			next := ent.next
+			if !pred(ent.key, ent.value) {
+				pos++
+				ent = next
+				continue
+			}
+			if n == 0 && om.beforeWrite() {
+				ent = om.m[ent.key]
+				next = ent.next
+			}
+			om.unlink(ent)
+			om.len--
+			n++
+			if logical {
+				ent.deleted = true
+				om.emitDelete(EventLdelete, ent.key, ent.value, pos)
+			} else {
+				delete(om.m, ent.key)
+				om.emitDelete(EventDelete, ent.key, ent.value, pos)
+			}
+			ent = next
+		}
+	})
+	return n
+}
+
+// Compute is a method which calls fn with the current value for a key and
+// the flag which is true if the key is present, and stores the value returned
+// by fn. If fn returns false as the keep flag, the entry for the key is
+// deleted, or nothing is stored if the key is absent.
+// This method returns the value for the key after the call and the flag which
+// is true if the key is present after the call.
+// The key is looked up only once, unlike calling Load and Store.
+// The fn must not modify this map.
+func (om *Map[K, V]) Compute(
+	key K,
+	fn func(value V, loaded bool) (newValue V, keep bool),
+) (actual V, ok bool) {
+	ent, exists := om.m[key]
+	if exists && !ent.deleted {
+		nv, keep := fn(ent.value, true)
+		if om.beforeWrite() {
+			ent = om.m[key]
+		}
+		return om.remap(ent, nv, keep)
+	}
+
+	var zero V
+	nv, keep := fn(zero, false)
+	if !keep {
+		return
+	}
+	if om.beforeWrite() {
+		ent = om.m[key]
+	}
+	om.insert(key, nv, ent)
+	return nv, true
+}
+
+// ComputeIfAbsent is a method which returns a value for a key if present,
+// otherwise calls fn and stores the value returned by fn. If fn returns false
+// as the store flag, nothing is stored.
+// The loaded flag is true if the value was loaded, and the ok flag is true if
+// the key is present after the call.
+// Unlike LoadOrStoreFunc, fn can decline to store a value without an error.
+// The fn must not modify this map.
+func (om *Map[K, V]) ComputeIfAbsent(
+	key K,
+	fn func(key K) (value V, store bool),
+) (actual V, loaded, ok bool) {
+	ent, exists := om.m[key]
+	if exists && !ent.deleted {
+		return ent.value, true, true
+	}
+
+	v, store := fn(key)
+	if !store {
+		return
+	}
+	if om.beforeWrite() {
+		ent = om.m[key]
+	}
+	om.insert(key, v, ent)
+	return v, false, true
+}
+
+// ComputeIfPresent is a method which calls fn with the current value for a
+// key if present, and stores the value returned by fn. If fn returns false as
+// the keep flag, the entry for the key is deleted.
+// This method returns the value for the key after the call and the flag which
+// is true if the key is present after the call.
+// The fn must not modify this map.
+func (om *Map[K, V]) ComputeIfPresent(
+	key K,
+	fn func(value V) (newValue V, keep bool),
+) (actual V, ok bool) {
+	ent, exists := om.m[key]
+	if !exists || ent.deleted {
+		return
+	}
+	nv, keep := fn(ent.value)
+	if om.beforeWrite() {
+		ent = om.m[key]
+	}
+	return om.remap(ent, nv, keep)
+}
+
+// Merge is a method which stores a value for a key if absent, otherwise calls
+// fn with the current value and the given value, and stores the value returned
+// by fn. If fn returns false as the keep flag, the entry for the key is
+// deleted.
+// This method returns the value for the key after the call and the flag which
+// is true if the key is present after the call.
+// The fn must not modify this map.
+func (om *Map[K, V]) Merge(
+	key K,
+	value V,
+	fn func(old, value V) (newValue V, keep bool),
+) (actual V, ok bool) {
+	ent, exists := om.m[key]
+	if exists && !ent.deleted {
+		nv, keep := fn(ent.value, value)
+		if om.beforeWrite() {
+			ent = om.m[key]
+		}
+		return om.remap(ent, nv, keep)
+	}
+	if om.beforeWrite() {
+		ent = om.m[key]
+	}
+	om.insert(key, value, ent)
+	return value, true
+}
+
+// insert is a method which adds an entry for a key which is absent to the end
+// of this map. If ent is a logically deleted entry for the key, it is reused.
+func (om *Map[K, V]) insert(key K, value V, ent *Entry[K, V]) {
+	if ent == nil {
+		ent = &Entry[K, V]{key: key}
+		om.m[key] = ent
+	}
+	ent.value = value
+	ent.deleted = false
+
+	if om.len == 0 {
+		om.head = ent
+	} else {
+		ent.prev = om.last
+		om.last.next = ent
+	}
+	om.last = ent
+	om.len++
+	om.emitInsert(key, value)
+}
+
+// remap is a method which updates the value of an entry which is present, or
+// deletes the entry if keep is false.
+func (om *Map[K, V]) remap(ent *Entry[K, V], value V, keep bool) (actual V, ok bool) {
+	if !keep {
+		pos := om.indexOf(ent)
+		delete(om.m, ent.key)
+		om.unlink(ent)
+		om.len--
+		om.emitDelete(EventDelete, ent.key, ent.value, pos)
+		return
+	}
+	old := ent.value
+	ent.value = value
+	om.emitUpdate(ent, old)
+	return value, true
+}
+
+// StoreAll is a method which stores each key and value yielded by seq in the
+// order of yielding, like Map.StoreAll.
+func (hm *HashMap[K, V]) StoreAll(seq func(yield func(key K, value V) bool)) {
+	hm.om.bulk(func() {
+		seq(func(k K, v V) bool {
+			hm.Store(k, v)
+			return true
+		})
+	})
+}
+
+// StorePairs is a method which stores the keys and values of pairs in order.
+// The First field of a Pair is a key and the Second field is a value.
+func (hm *HashMap[K, V]) StorePairs(pairs ...Pair[K, V]) {
+	hm.om.bulk(func() {
+		for _, p := range pairs {
+			hm.Store(p.First, p.Second)
+		}
+	})
+}
+
+// DeleteAll is a method which deletes values for keys, and returns the number
+// of deleted entries.
+func (hm *HashMap[K, V]) DeleteAll(keys ...K) int {
+	n := 0
+	hm.om.bulk(func() {
+		for _, k := range keys {
+			if _, loaded := hm.LoadAndDelete(k); loaded {
+				n++
+			}
+		}
+	})
+	return n
+}
+
+// DeleteIf is a method which deletes the entries for which pred returns true,
+// and returns the number of deleted entries.
+// The pred must not modify this map.
+func (hm *HashMap[K, V]) DeleteIf(pred func(key K, value V) bool) int {
+	return hm.removeIf(pred, false)
+}
+
+// LdeleteIf is a method which logically deletes the entries for which pred
+// returns true, and returns the number of deleted entries.
+// The pred must not modify this map.
+func (hm *HashMap[K, V]) LdeleteIf(pred func(key K, value V) bool) int {
+	return hm.removeIf(pred, true)
+}
+
+// RetainIf is a method which deletes the entries for which pred returns
+// false, and returns the number of deleted entries.
+// The pred must not modify this map.
+func (hm *HashMap[K, V]) RetainIf(pred func(key K, value V) bool) int {
+	return hm.removeIf(func(k K, v V) bool { return !pred(k, v) }, false)
+}
+
+func (hm *HashMap[K, V]) removeIf(pred func(key K, value V) bool, logical bool) int {
+	var removed []*hashKey[K]
+	n := hm.om.removeIf(func(box *hashKey[K], value V) bool {
+		if !pred(box.key, value) {
+			return false
+		}
+		removed = append(removed, box)
+		return true
+	}, logical)
+	if !logical {
+		for _, box := range removed {
+			hm.keys = hm.keys.remove(box)
+		}
+	}
+	return n
+}
+
+// Compute is a method which calls fn with the current value for a key and
+// the flag which is true if the key is present, and stores the value returned
+// by fn, like Map.Compute.
+// The fn must not modify this map.
+func (hm *HashMap[K, V]) Compute(
+	key K,
+	fn func(value V, loaded bool) (newValue V, keep bool),
+) (actual V, ok bool) {
+	box := hm.intern(key)
+	actual, ok = hm.om.Compute(box, fn)
+	hm.release(box)
+	return
+}
+
+// ComputeIfAbsent is a method which returns a value for a key if present,
+// otherwise calls fn and stores the value returned by fn, like
+// Map.ComputeIfAbsent.
+// The fn must not modify this map.
+func (hm *HashMap[K, V]) ComputeIfAbsent(
+	key K,
+	fn func(key K) (value V, store bool),
+) (actual V, loaded, ok bool) {
+	box := hm.intern(key)
+	actual, loaded, ok = hm.om.ComputeIfAbsent(box, func(*hashKey[K]) (V, bool) {
+		return fn(key)
+	})
+	hm.release(box)
+	return
+}
+
+// ComputeIfPresent is a method which calls fn with the current value for a
+// key if present, and stores the value returned by fn, like
+// Map.ComputeIfPresent.
+// The fn must not modify this map.
+func (hm *HashMap[K, V]) ComputeIfPresent(
+	key K,
+	fn func(value V) (newValue V, keep bool),
+) (actual V, ok bool) {
+	box := hm.keys.lookup(key)
+	if box == nil {
+		return
+	}
+	actual, ok = hm.om.ComputeIfPresent(box, fn)
+	hm.release(box)
+	return
+}
+
+// Merge is a method which stores a value for a key if absent, otherwise calls
+// fn with the current value and the given value, and stores the value returned
+// by fn, like Map.Merge.
+// The fn must not modify this map.
+func (hm *HashMap[K, V]) Merge(
+	key K,
+	value V,
+	fn func(old, value V) (newValue V, keep bool),
+) (actual V, ok bool) {
+	box := hm.intern(key)
+	actual, ok = hm.om.Merge(box, value, fn)
+	hm.release(box)
+	return
+}
@@ -0,0 +1,287 @@
+package orderedmap_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func newABCDE() orderedmap.Map[string, int] {
+	om := orderedmap.New[string, int]()
+	for i, k := range []string{"a", "b", "c", "d", "e"} {
+		om.Store(k, i+1)
+	}
+	return om
+}
+
+func TestMap_StoreAll(t *testing.T) {
+	src := orderedmap.New[string, int]()
+	src.Store("c", 30)
+	src.Store("d", 4)
+
+	om := newABC()
+	om.StoreAll(src.Range)
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:30 d:4]")
+	assert.Equal(t, keysOf(&om), "abcd/dcba")
+
+	om.StoreAll(func(yield func(string, int) bool) {
+		if !yield("e", 5) {
+			return
+		}
+		yield("a", 10)
+	})
+	assert.Equal(t, om.String(), "Map[a:10 b:2 c:30 d:4 e:5]")
+}
+
+func TestMap_StorePairs(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.StorePairs(
+		orderedmap.Pair[string, int]{First: "b", Second: 2},
+		orderedmap.Pair[string, int]{First: "a", Second: 1},
+		orderedmap.Pair[string, int]{First: "b", Second: 20},
+	)
+	assert.Equal(t, om.String(), "Map[b:20 a:1]")
+	om.StorePairs()
+	assert.Equal(t, om.Len(), 2)
+}
+
+func TestMap_DeleteAll(t *testing.T) {
+	om := newABCDE()
+	om.Ldelete("e")
+	assert.Equal(t, om.DeleteAll("b", "x", "d", "b", "e"), 2)
+	assert.Equal(t, keysOf(&om), "ac/ca")
+	assert.Equal(t, om.DeleteAll(), 0)
+}
+
+func TestMap_DeleteIf(t *testing.T) {
+	om := newABCDE()
+	n := om.DeleteIf(func(k string, v int) bool { return v%2 == 1 })
+	assert.Equal(t, n, 3)
+	assert.Equal(t, keysOf(&om), "bd/db")
+	assert.Equal(t, om.Len(), 2)
+
+	n = om.DeleteIf(func(k string, v int) bool { return false })
+	assert.Equal(t, n, 0)
+	n = om.DeleteIf(func(k string, v int) bool { return true })
+	assert.Equal(t, n, 2)
+	assert.Equal(t, keysOf(&om), "/")
+	assert.Equal(t, om.DeleteIf(func(k string, v int) bool { return true }), 0)
+
+	om.Store("a", 1)
+	assert.Equal(t, keysOf(&om), "a/a")
+}
+
+func TestMap_LdeleteIf(t *testing.T) {
+	om := newABCDE()
+	n := om.LdeleteIf(func(k string, v int) bool { return k == "a" || k == "c" })
+	assert.Equal(t, n, 2)
+	assert.Equal(t, keysOf(&om), "bde/edb")
+	_, ok := om.Load("a")
+	assert.False(t, ok)
+
+	om.Store("a", 10)
+	assert.Equal(t, om.String(), "Map[b:2 d:4 e:5 a:10]")
+}
+
+func TestMap_RetainIf(t *testing.T) {
+	om := newABCDE()
+	n := om.RetainIf(func(k string, v int) bool { return v > 3 })
+	assert.Equal(t, n, 3)
+	assert.Equal(t, om.String(), "Map[d:4 e:5]")
+	assert.Equal(t, keysOf(&om), "de/ed")
+}
+
+func TestMap_Compute(t *testing.T) {
+	om := newABC()
+	incr := func(v int, loaded bool) (int, bool) {
+		return v + 1, true
+	}
+
+	v, ok := om.Compute("b", incr)
+	assert.True(t, ok)
+	assert.Equal(t, v, 3)
+	v, ok = om.Compute("d", incr)
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	assert.Equal(t, om.String(), "Map[a:1 b:3 c:3 d:1]")
+
+	v, ok = om.Compute("a", func(v int, loaded bool) (int, bool) {
+		assert.True(t, loaded)
+		return 0, false
+	})
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+	v, ok = om.Compute("x", func(v int, loaded bool) (int, bool) {
+		assert.False(t, loaded)
+		return 0, false
+	})
+	assert.False(t, ok)
+	assert.Equal(t, keysOf(&om), "bcd/dcb")
+
+	om.Ldelete("c")
+	v, ok = om.Compute("c", func(v int, loaded bool) (int, bool) {
+		assert.False(t, loaded)
+		assert.Equal(t, v, 0)
+		return 7, true
+	})
+	assert.True(t, ok)
+	assert.Equal(t, v, 7)
+	assert.Equal(t, om.String(), "Map[b:3 d:1 c:7]")
+	assert.Equal(t, keysOf(&om), "bdc/cdb")
+}
+
+func TestMap_ComputeIfAbsent(t *testing.T) {
+	om := newABC()
+	called := 0
+	fn := func(k string) (int, bool) {
+		called++
+		return len(k) * 10, k != "skip"
+	}
+
+	v, loaded, ok := om.ComputeIfAbsent("a", fn)
+	assert.Equal(t, v, 1)
+	assert.True(t, loaded)
+	assert.True(t, ok)
+	assert.Equal(t, called, 0)
+
+	v, loaded, ok = om.ComputeIfAbsent("dd", fn)
+	assert.Equal(t, v, 20)
+	assert.False(t, loaded)
+	assert.True(t, ok)
+
+	v, loaded, ok = om.ComputeIfAbsent("skip", fn)
+	assert.Equal(t, v, 0)
+	assert.False(t, loaded)
+	assert.False(t, ok)
+	assert.Equal(t, called, 2)
+
+	om.Ldelete("b")
+	v, loaded, ok = om.ComputeIfAbsent("b", fn)
+	assert.Equal(t, v, 10)
+	assert.False(t, loaded)
+	assert.True(t, ok)
+	assert.Equal(t, om.String(), "Map[a:1 c:3 dd:20 b:10]")
+	assert.Equal(t, keysOf(&om), "acddb/bddca")
+}
+
+func TestMap_ComputeIfPresent(t *testing.T) {
+	om := newABC()
+	double := func(v int) (int, bool) { return v * 2, v != 3 }
+
+	v, ok := om.ComputeIfPresent("b", double)
+	assert.True(t, ok)
+	assert.Equal(t, v, 4)
+	v, ok = om.ComputeIfPresent("x", double)
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+	v, ok = om.ComputeIfPresent("c", double)
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+	assert.Equal(t, om.String(), "Map[a:1 b:4]")
+
+	om.Ldelete("a")
+	v, ok = om.ComputeIfPresent("a", double)
+	assert.False(t, ok)
+	assert.Equal(t, keysOf(&om), "b/b")
+}
+
+func TestMap_Merge(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	sum := func(old, v int) (int, bool) { return old + v, old+v != 0 }
+
+	for _, w := range []string{"x", "y", "x", "z", "x"} {
+		om.Merge(w, 1, sum)
+	}
+	assert.Equal(t, om.String(), "Map[x:3 y:1 z:1]")
+
+	v, ok := om.Merge("y", -1, sum)
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+	assert.Equal(t, keysOf(&om), "xz/zx")
+
+	v, ok = om.Merge("y", 5, sum)
+	assert.True(t, ok)
+	assert.Equal(t, v, 5)
+	assert.Equal(t, om.String(), "Map[x:3 z:1 y:5]")
+}
+
+func TestMap_bulk_observe(t *testing.T) {
+	om := newABCDE()
+	var batches [][]string
+	om.ObserveBatch(func(evs []orderedmap.Event[string, int]) {
+		var b []string
+		for _, ev := range evs {
+			b = append(b, ev.Type.String()+":"+ev.Key)
+		}
+		batches = append(batches, b)
+	})
+	var events []orderedmap.Event[string, int]
+	om.Observe(func(ev orderedmap.Event[string, int]) {
+		events = append(events, ev)
+	})
+
+	om.DeleteIf(func(k string, v int) bool { return v%2 == 0 })
+	assert.Equal(t, len(batches), 1)
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[0].Key, "b")
+	assert.Equal(t, events[0].Position, 1)
+	assert.Equal(t, events[1].Key, "d")
+	assert.Equal(t, events[1].Position, 2)
+
+	om.StorePairs(
+		orderedmap.Pair[string, int]{First: "f", Second: 6},
+		orderedmap.Pair[string, int]{First: "a", Second: 10},
+	)
+	assert.Equal(t, len(batches), 2)
+	assert.Equal(t, len(batches[1]), 2)
+
+	events = nil
+	om.Compute("c", func(v int, loaded bool) (int, bool) { return 0, false })
+	om.Merge("g", 7, func(old, v int) (int, bool) { return v, true })
+	om.ComputeIfPresent("a", func(v int) (int, bool) { return v + 1, true })
+	assert.Equal(t, len(events), 3)
+	assert.Equal(t, events[0].Type, orderedmap.EventDelete)
+	assert.Equal(t, events[0].Position, 1)
+	assert.Equal(t, events[1].Type, orderedmap.EventInsert)
+	assert.Equal(t, events[1].Position, 3)
+	assert.Equal(t, events[2].Type, orderedmap.EventUpdate)
+	assert.Equal(t, events[2].NewValue, 11)
+	assert.Equal(t, om.String(), "Map[a:11 e:5 f:6 g:7]")
+}
+
+func TestMap_bulk_snapshot(t *testing.T) {
+	om := newABCDE()
+	snap := om.Snapshot()
+
+	om.DeleteIf(func(k string, v int) bool { return v < 3 })
+	om.Compute("c", func(v int, loaded bool) (int, bool) { return 30, true })
+	om.Merge("f", 6, nil)
+
+	assert.Equal(t, om.String(), "Map[c:30 d:4 e:5 f:6]")
+	assert.Equal(t, snap.String(), "Snapshot[a:1 b:2 c:3 d:4 e:5]")
+}
+
+func TestMap_bulk_snapshotEachCompute(t *testing.T) {
+	om := newABCDE()
+	front := om.Front()
+	om.Snapshot()
+	om.ComputeIfAbsent("a", func(k string) (int, bool) { return 0, true })
+	om.ComputeIfAbsent("x", func(k string) (int, bool) { return 0, false })
+	om.ComputeIfPresent("x", func(v int) (int, bool) { return 0, true })
+	assert.Same(t, om.Front(), front)
+
+	snap := om.Snapshot()
+	om.ComputeIfPresent("a", func(v int) (int, bool) { return v * 10, true })
+	assert.Equal(t, snap.String(), "Snapshot[a:1 b:2 c:3 d:4 e:5]")
+
+	snap = om.Snapshot()
+	om.ComputeIfAbsent("f", func(k string) (int, bool) { return 6, true })
+	assert.Equal(t, snap.String(), "Snapshot[a:10 b:2 c:3 d:4 e:5]")
+
+	snap = om.Snapshot()
+	om.Merge("b", 1, func(old, v int) (int, bool) { return old + v, true })
+	assert.Equal(t, snap.String(), "Snapshot[a:10 b:2 c:3 d:4 e:5 f:6]")
+
+	assert.Equal(t, om.String(), "Map[a:10 b:3 c:3 d:4 e:5 f:6]")
+}
@@ -0,0 +1,364 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
+const cborFormat = "cbor"
+
+const (
+	cborMajorUint   byte = 0
+	cborMajorNegInt byte = 1
+	cborMajorBytes  byte = 2
+	cborMajorString byte = 3
+	cborMajorArray  byte = 4
+	cborMajorMap    byte = 5
+	cborMajorTag    byte = 6
+	cborMajorSimple byte = 7
+
+	cborIndefinite byte = 31
+)
+
+// MarshalCBOR is a method which returns a byte array of CBOR data which
+// expresses the content of this map.
+// The entries are written in the order of key insertions, and keys of any
+// types which can be expressed in CBOR are written as they are.
+func (om Map[K, V]) MarshalCBOR() ([]byte, error) {
+	w := &cborWriter{}
+	err := encodeBinary(w, om, 0)
+	if err != nil {
+		return nil, err
+	}
+	return w.bytes(), nil
+}
+
+// UnmarshalCBOR is a method which sets the content of this map from a CBOR
+// data.
+// The entries are stored in the order in the data.
+// If the value type of this map is any, nested maps are decoded into
+// *Map[string, any] if all their keys are strings, into *Map[int64, any]
+// if all their keys are integers, or into *HashMap[any, any] otherwise.
+// Tags are ignored and only their contents are decoded.
+func (om *Map[K, V]) UnmarshalCBOR(data []byte) error {
+	return unmarshalBinary(&cborReader{data: data}, om)
+}
+
+// MarshalCBOR is a method which returns a byte array of CBOR data which
+// expresses the content of this map, like Map.MarshalCBOR.
+func (hm HashMap[K, V]) MarshalCBOR() ([]byte, error) {
+	w := &cborWriter{}
+	err := encodeBinary(w, hm, 0)
+	if err != nil {
+		return nil, err
+	}
+	return w.bytes(), nil
+}
+
+// UnmarshalCBOR is a method which sets the content of this map from a CBOR
+// data, like Map.UnmarshalCBOR.
+func (hm *HashMap[K, V]) UnmarshalCBOR(data []byte) error {
+	return unmarshalBinary(&cborReader{data: data}, hm)
+}
+
+type cborWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *cborWriter) format() string {
+	return cborFormat
+}
+
+func (w *cborWriter) newWriter() binaryWriter {
+	return &cborWriter{}
+}
+
+func (w *cborWriter) bytes() []byte {
+	return w.buf.Bytes()
+}
+
+func (w *cborWriter) writeRaw(b []byte) {
+	w.buf.Write(b)
+}
+
+func (w *cborWriter) writeHead(major byte, n uint64) {
+	var b [8]byte
+	switch {
+	case n < 24:
+		w.buf.WriteByte(major<<5 | byte(n))
+	case n <= math.MaxUint8:
+		w.buf.WriteByte(major<<5 | 24)
+		w.buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		w.buf.WriteByte(major<<5 | 25)
+		binary.BigEndian.PutUint16(b[:], uint16(n))
+		w.buf.Write(b[:2])
+	case n <= math.MaxUint32:
+		w.buf.WriteByte(major<<5 | 26)
+		binary.BigEndian.PutUint32(b[:], uint32(n))
+		w.buf.Write(b[:4])
+	default:
+		w.buf.WriteByte(major<<5 | 27)
+		binary.BigEndian.PutUint64(b[:], n)
+		w.buf.Write(b[:8])
+	}
+}
+
+func (w *cborWriter) writeNil() {
+	w.buf.WriteByte(0xf6)
+}
+
+func (w *cborWriter) writeBool(b bool) {
+	if b {
+		w.buf.WriteByte(0xf5)
+	} else {
+		w.buf.WriteByte(0xf4)
+	}
+}
+
+func (w *cborWriter) writeInt(n int64) {
+	if n >= 0 {
+		w.writeHead(cborMajorUint, uint64(n))
+	} else {
+		w.writeHead(cborMajorNegInt, uint64(^n))
+	}
+}
+
+func (w *cborWriter) writeUint(n uint64) {
+	w.writeHead(cborMajorUint, n)
+}
+
+func (w *cborWriter) writeFloat32(f float32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], math.Float32bits(f))
+	w.buf.WriteByte(0xfa)
+	w.buf.Write(b[:])
+}
+
+func (w *cborWriter) writeFloat64(f float64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], math.Float64bits(f))
+	w.buf.WriteByte(0xfb)
+	w.buf.Write(b[:])
+}
+
+func (w *cborWriter) writeString(s string) {
+	w.writeHead(cborMajorString, uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *cborWriter) writeBytes(b []byte) {
+	w.writeHead(cborMajorBytes, uint64(len(b)))
+	w.buf.Write(b)
+}
+
+func (w *cborWriter) writeArrayHeader(n int) {
+	w.writeHead(cborMajorArray, uint64(n))
+}
+
+func (w *cborWriter) writeMapHeader(n int) {
+	w.writeHead(cborMajorMap, uint64(n))
+}
+
+type cborReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *cborReader) format() string {
+	return cborFormat
+}
+
+func (r *cborReader) offset() int64 {
+	return int64(r.pos)
+}
+
+func (r *cborReader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+func (r *cborReader) read(n uint64) ([]byte, error) {
+	if n > uint64(r.remaining()) {
+		return nil, binaryErrorf(r, "unexpected end of data")
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// readHead reads the initial byte and the argument of a data item.
+// If the additional information is 31, indefinite is true.
+func (r *cborReader) readHead() (major, info byte, arg uint64, indefinite bool, err error) {
+	b, err := r.read(1)
+	if err != nil {
+		return
+	}
+	major = b[0] >> 5
+	info = b[0] & 0x1f
+
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info == 24:
+		b, err = r.read(1)
+		if err == nil {
+			arg = uint64(b[0])
+		}
+	case info == 25:
+		b, err = r.read(2)
+		if err == nil {
+			arg = uint64(binary.BigEndian.Uint16(b))
+		}
+	case info == 26:
+		b, err = r.read(4)
+		if err == nil {
+			arg = uint64(binary.BigEndian.Uint32(b))
+		}
+	case info == 27:
+		b, err = r.read(8)
+		if err == nil {
+			arg = binary.BigEndian.Uint64(b)
+		}
+	case info == cborIndefinite:
+		indefinite = true
+	default:
+		r.pos--
+		err = binaryErrorf(r, "invalid additional information "+
+			strconv.Itoa(int(info)))
+	}
+	return
+}
+
+func (r *cborReader) readToken() (binaryToken, error) {
+	for {
+		start := r.pos
+		major, info, arg, indefinite, err := r.readHead()
+		if err != nil {
+			return binaryToken{}, err
+		}
+
+		if indefinite {
+			switch major {
+			case cborMajorBytes, cborMajorString:
+				return r.readChunks(major)
+			case cborMajorArray:
+				return binaryToken{kind: binaryArray, n: -1}, nil
+			case cborMajorMap:
+				return binaryToken{kind: binaryMap, n: -1}, nil
+			case cborMajorSimple:
+				return binaryToken{kind: binaryBreak}, nil
+			}
+			r.pos = start
+			return binaryToken{}, binaryErrorf(r, "invalid indefinite length item")
+		}
+
+		switch major {
+		case cborMajorUint:
+			return binaryToken{kind: binaryUint, u: arg}, nil
+		case cborMajorNegInt:
+			if arg > math.MaxInt64 {
+				r.pos = start
+				return binaryToken{}, binaryErrorf(r, "negative integer overflows int64")
+			}
+			return binaryToken{kind: binaryInt, i: ^int64(arg)}, nil
+		case cborMajorBytes:
+			b, err := r.read(arg)
+			if err != nil {
+				return binaryToken{}, err
+			}
+			return binaryToken{kind: binaryBytes, bs: append([]byte{}, b...)}, nil
+		case cborMajorString:
+			b, err := r.read(arg)
+			if err != nil {
+				return binaryToken{}, err
+			}
+			return binaryToken{kind: binaryString, s: string(b)}, nil
+		case cborMajorArray:
+			if arg > math.MaxInt32 {
+				r.pos = start
+				return binaryToken{}, binaryErrorf(r, "unexpected end of data")
+			}
+			return binaryToken{kind: binaryArray, n: int(arg)}, nil
+		case cborMajorMap:
+			if arg > math.MaxInt32 {
+				r.pos = start
+				return binaryToken{}, binaryErrorf(r, "unexpected end of data")
+			}
+			return binaryToken{kind: binaryMap, n: int(arg)}, nil
+		case cborMajorTag:
+			continue
+		}
+
+		switch info {
+		case 20:
+			return binaryToken{kind: binaryBool, b: false}, nil
+		case 21:
+			return binaryToken{kind: binaryBool, b: true}, nil
+		case 22, 23:
+			return binaryToken{kind: binaryNil}, nil
+		case 25:
+			return binaryToken{kind: binaryFloat32, f: float64(halfToFloat32(uint16(arg)))}, nil
+		case 26:
+			return binaryToken{kind: binaryFloat32, f: float64(math.Float32frombits(uint32(arg)))}, nil
+		case 27:
+			return binaryToken{kind: binaryFloat64, f: math.Float64frombits(arg)}, nil
+		}
+		r.pos = start
+		return binaryToken{}, binaryErrorf(r, "unsupported simple value "+
+			strconv.FormatUint(arg, 10))
+	}
+}
+
+// readChunks reads the definite length chunks of an indefinite length byte
+// string or text string until a break.
+func (r *cborReader) readChunks(major byte) (binaryToken, error) {
+	var buf []byte
+	for {
+		start := r.pos
+		m, _, arg, indefinite, err := r.readHead()
+		if err != nil {
+			return binaryToken{}, err
+		}
+		if m == cborMajorSimple && indefinite {
+			break
+		}
+		if m != major || indefinite {
+			r.pos = start
+			return binaryToken{}, binaryErrorf(r, "invalid chunk of indefinite length string")
+		}
+		b, err := r.read(arg)
+		if err != nil {
+			return binaryToken{}, err
+		}
+		buf = append(buf, b...)
+	}
+	if major == cborMajorString {
+		return binaryToken{kind: binaryString, s: string(buf)}, nil
+	}
+	return binaryToken{kind: binaryBytes, bs: append([]byte{}, buf...)}, nil
+}
+
+// halfToFloat32 converts an IEEE 754 half precision float to a float32.
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+
+	switch exp {
+	case 0:
+		f := float32(frac) / (1 << 24)
+		if sign != 0 {
+			return -f
+		}
+		return f
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | frac<<13)
+	}
+	return math.Float32frombits(sign | (exp+112)<<23 | frac<<13)
+}
@@ -0,0 +1,204 @@
+package orderedmap_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func TestMap_MarshalCBOR_empty(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	b, err := om.MarshalCBOR()
+	assert.Nil(t, err)
+	assert.Equal(t, b, []byte{0xa0})
+}
+
+func TestMap_MarshalCBOR_order(t *testing.T) {
+	om := orderedmap.New[string, any]()
+	om.Store("z", 1)
+	om.Store("a", -1)
+	om.Store("m", 500)
+	om.Store("b", true)
+	om.Store("n", nil)
+
+	b, err := om.MarshalCBOR()
+	assert.Nil(t, err)
+	assert.Equal(t, b, []byte{
+		0xa5,
+		0x61, 'z', 0x01,
+		0x61, 'a', 0x20,
+		0x61, 'm', 0x19, 0x01, 0xf4,
+		0x61, 'b', 0xf5,
+		0x61, 'n', 0xf6,
+	})
+}
+
+func TestMap_MarshalCBOR_nonStringKeys(t *testing.T) {
+	om := orderedmap.New[int, string]()
+	om.Store(10, "c")
+	om.Store(-500, "a")
+	om.Store(0, "b")
+
+	b, err := om.MarshalCBOR()
+	assert.Nil(t, err)
+	assert.Equal(t, b, []byte{
+		0xa3,
+		0x0a, 0x61, 'c',
+		0x39, 0x01, 0xf3, 0x61, 'a',
+		0x00, 0x61, 'b',
+	})
+
+	om2 := orderedmap.New[int, string]()
+	assert.Nil(t, om2.UnmarshalCBOR(b))
+	assert.Equal(t, om2.String(), "Map[10:c -500:a 0:b]")
+}
+
+func TestMap_CBORRoundTrip_nested(t *testing.T) {
+	inner := orderedmap.New[string, any]()
+	inner.Store("y", 1.5)
+	inner.Store("x", []any{"s", false, nil})
+
+	intKeys := orderedmap.New[int, string]()
+	intKeys.Store(2, "two")
+	intKeys.Store(1, "one")
+
+	om := orderedmap.New[string, any]()
+	om.Store("name", "foo")
+	om.Store("inner", &inner)
+	om.Store("ints", intKeys)
+	om.Store("bin", []byte{1, 2, 3})
+	om.Store("f32", float32(0.25))
+	om.Store("big", uint64(math.MaxUint64))
+	om.Store("neg", int64(math.MinInt64))
+
+	b, err := om.MarshalCBOR()
+	assert.Nil(t, err)
+
+	om2 := orderedmap.New[string, any]()
+	assert.Nil(t, om2.UnmarshalCBOR(b))
+	assert.Equal(t, om2.String(),
+		"Map[name:foo inner:Map[y:1.5 x:[s false <nil>]] ints:Map[2:two 1:one] "+
+			"bin:[1 2 3] f32:0.25 big:18446744073709551615 neg:-9223372036854775808]")
+
+	v, _ := om2.Load("ints")
+	_, ok := v.(*orderedmap.Map[int64, any])
+	assert.True(t, ok)
+}
+
+func TestMap_UnmarshalCBOR_indefiniteLength(t *testing.T) {
+	b := []byte{
+		0xbf,
+		0x7f, 0x62, 'k', 'e', 0x61, 'y', 0xff,
+		0x9f, 0x01, 0x5f, 0x41, 0x01, 0x41, 0x02, 0xff, 0xff,
+		0x61, 'm',
+		0xbf, 0x61, 'z', 0x01, 0x61, 'a', 0x02, 0xff,
+		0xff,
+	}
+
+	om := orderedmap.New[string, any]()
+	assert.Nil(t, om.UnmarshalCBOR(b))
+	assert.Equal(t, om.String(), "Map[key:[1 [1 2]] m:Map[z:1 a:2]]")
+}
+
+func TestMap_UnmarshalCBOR_floatsAndSimpleValues(t *testing.T) {
+	b := []byte{
+		0xa5,
+		0x61, 'h', 0xf9, 0x3e, 0x00,
+		0x61, 's', 0xf9, 0x00, 0x01,
+		0x61, 'i', 0xf9, 0xfc, 0x00,
+		0x61, 'f', 0xfa, 0x3f, 0xc0, 0x00, 0x00,
+		0x61, 'u', 0xf7,
+	}
+
+	om := orderedmap.New[string, any]()
+	assert.Nil(t, om.UnmarshalCBOR(b))
+	assert.Equal(t, om.String(), "Map[h:1.5 s:5.9604645e-08 i:-Inf f:1.5 u:<nil>]")
+}
+
+func TestMap_UnmarshalCBOR_tagsAreIgnored(t *testing.T) {
+	b := []byte{
+		0xa1,
+		0x61, 't',
+		0xc1, 0x1a, 0x51, 0x4b, 0x67, 0xb0,
+	}
+
+	om := orderedmap.New[string, int64]()
+	assert.Nil(t, om.UnmarshalCBOR(b))
+	assert.Equal(t, om.String(), "Map[t:1363896240]")
+}
+
+func TestMap_UnmarshalCBOR_typedValues(t *testing.T) {
+	inner := orderedmap.New[string, []string]()
+	inner.Store("b", []string{"x", "y"})
+	inner.Store("a", nil)
+
+	om := orderedmap.New[string, orderedmap.Map[string, []string]]()
+	om.Store("k", inner)
+
+	b, err := om.MarshalCBOR()
+	assert.Nil(t, err)
+
+	om2 := orderedmap.New[string, orderedmap.Map[string, []string]]()
+	assert.Nil(t, om2.UnmarshalCBOR(b))
+	v, _ := om2.Load("k")
+	assert.Equal(t, v.String(), "Map[b:[x y] a:[]]")
+}
+
+func TestMap_UnmarshalCBOR_malformed(t *testing.T) {
+	testCases := []struct {
+		data []byte
+		msg  string
+	}{
+		{[]byte{}, "cbor: unexpected end of data (offset:0)"},
+		{[]byte{0x80}, "cbor: the input data is not a map but array (offset:1)"},
+		{[]byte{0xa1, 0x61}, "cbor: unexpected end of data (offset:1)"},
+		{[]byte{0xa1, 0x61, 'a', 0x1c}, "cbor: invalid additional information 28 (offset:3)"},
+		{[]byte{0xa1, 0x61, 'a', 0x3b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			"cbor: negative integer overflows int64 (offset:3)"},
+		{[]byte{0xa1, 0x61, 'a', 0xf0}, "cbor: unsupported simple value 16 (offset:3)"},
+		{[]byte{0xa1, 0x61, 'a', 0x1f}, "cbor: invalid indefinite length item (offset:3)"},
+		{[]byte{0xa1, 0x61, 'a', 0x7f, 0x41, 0x00, 0xff},
+			"cbor: invalid chunk of indefinite length string (offset:4)"},
+		{[]byte{0xa1, 0x61, 'a', 0xff}, "cbor: unexpected break (offset:4)"},
+		{[]byte{0xa0, 0x00}, "cbor: invalid data after the top-level map (offset:1)"},
+		{[]byte{0xbb, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			"cbor: unexpected end of data (offset:0)"},
+	}
+
+	for _, tc := range testCases {
+		om := orderedmap.New[string, any]()
+		err := om.UnmarshalCBOR(tc.data)
+		assert.NotNil(t, err)
+		assert.Equal(t, err.Error(), tc.msg)
+		var e orderedmap.DecodeError
+		assert.True(t, errors.As(err, &e))
+	}
+}
+
+func FuzzUnmarshalCBOR(f *testing.F) {
+	f.Add([]byte{0xa0})
+	f.Add([]byte{0xa2, 0x61, 'a', 0x01, 0x61, 'b', 0x82, 0xf5, 0xfb, 0, 0, 0, 0, 0, 0, 0, 0})
+	f.Add([]byte{0xbf, 0x61, 'a', 0xa1, 0x01, 0x5f, 0x41, 0xff, 0xff, 0xff})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		om := orderedmap.New[string, any]()
+		if om.UnmarshalCBOR(data) != nil {
+			return
+		}
+		b, err := om.MarshalCBOR()
+		if err != nil {
+			t.Fatal(err)
+		}
+		om2 := orderedmap.New[string, any]()
+		err = om2.UnmarshalCBOR(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if om.String() != om2.String() {
+			t.Fatalf("%s != %s", om.String(), om2.String())
+		}
+	})
+}
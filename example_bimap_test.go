@@ -0,0 +1,26 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleBiMap() {
+	bm := orderedmap.NewBiMap[string, int](orderedmap.RejectOnCollision)
+	bm.Store("ext-b", 2)
+	bm.Store("ext-a", 1)
+
+	k, _ := bm.LoadByValue(1)
+	fmt.Printf("key = %s\n", k)
+
+	e := bm.Store("ext-c", 1)
+	fmt.Printf("e = %v\n", e)
+
+	inv := bm.Inverse()
+	fmt.Printf("inverse = %v\n", inv)
+	// Output:
+	// key = ext-a
+	// e = value is already mapped to another key: 1
+	// inverse = BiMap[2:ext-b 1:ext-a]
+}
@@ -0,0 +1,75 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleMap_MarshalMsgpack() {
+	om := orderedmap.New[int, string]()
+	om.Store(2, "foo")
+	om.Store(1, "bar")
+
+	b, e := om.MarshalMsgpack()
+	fmt.Printf("msgpack = % x\n", b)
+	fmt.Printf("e = %v\n", e)
+	// Output:
+	// msgpack = 82 02 a3 66 6f 6f 01 a3 62 61 72
+	// e = <nil>
+}
+
+func ExampleMap_UnmarshalMsgpack() {
+	om := orderedmap.New[string, any]()
+	b := []byte{0x82, 0xa3, 'f', 'o', 'o', 0x01, 0xa3, 'b', 'a', 'r', 0x81, 0x01, 0xc3}
+
+	e := om.UnmarshalMsgpack(b)
+	fmt.Printf("om = %v\n", om)
+	fmt.Printf("e = %v\n", e)
+	// Output:
+	// om = Map[foo:1 bar:Map[1:true]]
+	// e = <nil>
+}
+
+func ExampleMap_MarshalCBOR() {
+	om := orderedmap.New[int, string]()
+	om.Store(2, "foo")
+	om.Store(1, "bar")
+
+	b, e := om.MarshalCBOR()
+	fmt.Printf("cbor = % x\n", b)
+	fmt.Printf("e = %v\n", e)
+	// Output:
+	// cbor = a2 02 63 66 6f 6f 01 63 62 61 72
+	// e = <nil>
+}
+
+func ExampleMap_UnmarshalCBOR() {
+	om := orderedmap.New[string, any]()
+	b := []byte{0xa2, 0x63, 'f', 'o', 'o', 0x01, 0x63, 'b', 'a', 'r', 0xa1, 0x01, 0xf5}
+
+	e := om.UnmarshalCBOR(b)
+	fmt.Printf("om = %v\n", om)
+	fmt.Printf("e = %v\n", e)
+	// Output:
+	// om = Map[foo:1 bar:Map[1:true]]
+	// e = <nil>
+}
+
+func ExampleMap_MarshalBinary() {
+	om := orderedmap.New[string, int]()
+	om.Store("foo", 1)
+	om.Store("bar", 2)
+
+	b, e := om.MarshalBinary()
+	fmt.Printf("e = %v\n", e)
+
+	om2 := orderedmap.New[string, int]()
+	e = om2.UnmarshalBinary(b)
+	fmt.Printf("om2 = %v\n", om2)
+	fmt.Printf("e = %v\n", e)
+	// Output:
+	// e = <nil>
+	// om2 = Map[foo:1 bar:2]
+	// e = <nil>
+}
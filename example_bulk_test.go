@@ -0,0 +1,43 @@
+package orderedmap_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleMap_Merge() {
+	counts := orderedmap.New[string, int]()
+	for _, w := range strings.Fields("to be or not to be") {
+		counts.Merge(w, 1, func(old, v int) (int, bool) {
+			return old + v, true
+		})
+	}
+	fmt.Println(counts)
+
+	counts.RetainIf(func(w string, n int) bool { return n > 1 })
+	fmt.Println(counts)
+	// Output:
+	// Map[to:2 be:2 or:1 not:1]
+	// Map[to:2 be:2]
+}
+
+func ExampleMap_Compute() {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+
+	om.Compute("a", func(v int, loaded bool) (int, bool) {
+		return v * 10, true
+	})
+	om.Compute("b", func(v int, loaded bool) (int, bool) {
+		return 0, false // deletes the entry
+	})
+	om.ComputeIfAbsent("c", func(k string) (int, bool) {
+		return 3, true
+	})
+	fmt.Println(om)
+	// Output:
+	// Map[a:10 c:3]
+}
@@ -0,0 +1,27 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleFrozen() {
+	om := orderedmap.New[string, int]()
+	om.Store("b", 2)
+	om.Store("a", 1)
+
+	f1 := om.Freeze()
+	f2 := f1.With("c", 3).Without("b")
+
+	fmt.Printf("f1 = %v\n", f1)
+	fmt.Printf("f2 = %v\n", f2)
+	for ent := f2.Front(); ent != nil; ent = ent.Next() {
+		fmt.Printf("%s: %d\n", ent.Key(), ent.Value())
+	}
+	// Output:
+	// f1 = Frozen[b:2 a:1]
+	// f2 = Frozen[a:1 c:3]
+	// a: 1
+	// c: 3
+}
@@ -0,0 +1,48 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleGroupBy() {
+	om := orderedmap.New[string, int]()
+	om.Store("apple", 3)
+	om.Store("banana", 5)
+	om.Store("avocado", 2)
+	om.Store("blueberry", 7)
+	om.Store("cherry", 1)
+
+	groups := orderedmap.GroupBy(&om, func(k string, v int) byte {
+		return k[0]
+	})
+	groups.Range(func(g byte, m *orderedmap.Map[string, int]) bool {
+		total := orderedmap.Reduce(m, 0, func(acc int, k string, v int) int {
+			return acc + v
+		})
+		fmt.Printf("%c: %v total=%d\n", g, m, total)
+		return true
+	})
+	// Output:
+	// a: Map[apple:3 avocado:2] total=5
+	// b: Map[banana:5 blueberry:7] total=12
+	// c: Map[cherry:1] total=1
+}
+
+func ExampleFilter() {
+	om := orderedmap.New[string, int]()
+	om.Store("c", 3)
+	om.Store("a", 1)
+	om.Store("b", 2)
+
+	odd := orderedmap.Filter(&om, func(k string, v int) bool {
+		return v%2 == 1
+	})
+	doubled := orderedmap.MapValues(odd, func(k string, v int) int {
+		return v * 2
+	})
+	fmt.Println(doubled)
+	// Output:
+	// Map[c:6 a:2]
+}
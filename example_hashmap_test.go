@@ -0,0 +1,31 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleNewWithHasher() {
+	hm := orderedmap.NewWithHasher[[]byte, int](
+		orderedmap.HashBytes[[]byte], orderedmap.EqualBytes[[]byte])
+	hm.Store([]byte("foo"), 1)
+	hm.Store([]byte("bar"), 2)
+	hm.Store([]byte("foo"), 3)
+
+	v, _ := hm.Load([]byte("foo"))
+	fmt.Printf("foo = %d\n", v)
+
+	hm.Range(func(k []byte, v int) bool {
+		fmt.Printf("%s: %d\n", k, v)
+		return true
+	})
+
+	bs, _ := hm.MarshalJSON()
+	fmt.Println(string(bs))
+	// Output:
+	// foo = 3
+	// foo: 3
+	// bar: 2
+	// {"Zm9v":3,"YmFy":2}
+}
@@ -0,0 +1,30 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleHistory() {
+	om := orderedmap.New[string, int]()
+	h := orderedmap.NewHistory(&om, 100)
+
+	h.Store("a", 1)
+	h.Store("b", 2)
+
+	h.BeginGroup()
+	h.Delete("a")
+	h.Store("c", 3)
+	h.EndGroup()
+	fmt.Printf("om = %v\n", om)
+
+	h.Undo()
+	fmt.Printf("om = %v\n", om)
+	h.Redo()
+	fmt.Printf("om = %v\n", om)
+	// Output:
+	// om = Map[b:2 c:3]
+	// om = Map[a:1 b:2]
+	// om = Map[b:2 c:3]
+}
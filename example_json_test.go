@@ -2,6 +2,8 @@ package orderedmap_test
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/sttk/orderedmap"
 )
@@ -30,3 +32,53 @@ func ExampleMap_UnmarshalJSON() {
 	// om = Map[foo:bar baz:qux]
 	// e = <nil>
 }
+
+func ExampleEncoder() {
+	om := orderedmap.New[string, any]()
+	om.Store("name", "foo")
+	om.Store("tags", []any{"a", "b"})
+	om.Store("password", "secret")
+
+	enc := orderedmap.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetHook(func(key string, value any) (string, any, bool) {
+		return key, value, key != "password"
+	})
+	e := enc.Encode(om)
+	fmt.Printf("e = %v\n", e)
+	// Output:
+	// {
+	//   "name": "foo",
+	//   "tags": [
+	//     "a",
+	//     "b"
+	//   ]
+	// }
+	// e = <nil>
+}
+
+func ExampleReadJSONLines() {
+	r := strings.NewReader("{\"b\":1,\"a\":2}\n{\"d\":3,\"c\":4}\n")
+
+	orderedmap.ReadJSONLines(r)(func(om *orderedmap.Map[string, any], e error) bool {
+		fmt.Printf("om = %v, e = %v\n", om, e)
+		return true
+	})
+	// Output:
+	// om = Map[b:1 a:2], e = <nil>
+	// om = Map[d:3 c:4], e = <nil>
+}
+
+func ExampleJSONLinesWriter() {
+	om := orderedmap.New[string, any]()
+	om.Store("b", 1)
+	om.Store("a", 2)
+
+	w := orderedmap.NewJSONLinesWriter(os.Stdout)
+	w.Write(&om)
+	om.Store("c", 3)
+	w.Write(&om)
+	// Output:
+	// {"b":1,"a":2}
+	// {"b":1,"a":2,"c":3}
+}
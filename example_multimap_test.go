@@ -0,0 +1,24 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleMultiMap() {
+	mm := orderedmap.NewMultiMap[string, string]()
+	mm.Add("Received", "from a")
+	mm.Add("Subject", "hello")
+	mm.Add("Received", "from b")
+
+	fmt.Printf("Received = %v\n", mm.GetAll("Received"))
+	for ent := mm.Front(); ent != nil; ent = ent.Next() {
+		fmt.Printf("%s: %s\n", ent.Key(), ent.Value())
+	}
+	// Output:
+	// Received = [from a from b]
+	// Received: from a
+	// Subject: hello
+	// Received: from b
+}
@@ -0,0 +1,24 @@
+package orderedmap_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleNewNormalized() {
+	headers := orderedmap.NewNormalized[string](strings.ToLower)
+	headers.Store("Content-Type", "text/plain")
+	headers.Store("Accept", "*/*")
+	headers.Store("content-type", "application/json")
+
+	v, _ := headers.Load("CONTENT-TYPE")
+	fmt.Println(v)
+
+	bs, _ := headers.MarshalJSON()
+	fmt.Println(string(bs))
+	// Output:
+	// application/json
+	// {"Content-Type":"application/json","Accept":"*/*"}
+}
@@ -0,0 +1,27 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleMap_Observe() {
+	om := orderedmap.New[string, int]()
+	unsubscribe := om.Observe(func(ev orderedmap.Event[string, int]) {
+		fmt.Printf("%v %s at %d\n", ev.Type, ev.Key, ev.Position)
+	})
+	defer unsubscribe()
+
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("a", 10)
+	om.MoveToFront("b")
+	om.Delete("a")
+	// Output:
+	// insert a at 0
+	// insert b at 1
+	// update a at 0
+	// move b at 0
+	// delete a at 1
+}
@@ -0,0 +1,21 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleParseQuery() {
+	q, e := orderedmap.ParseQuery("b=1&a=2&b=3")
+	fmt.Printf("e = %v\n", e)
+
+	q.Set("a", "x")
+	q.Add("c", "4")
+	fmt.Printf("b = %v\n", q.GetAll("b"))
+	fmt.Printf("query = %s\n", q.Encode())
+	// Output:
+	// e = <nil>
+	// b = [1 3]
+	// query = b=1&a=x&b=3&c=4
+}
@@ -0,0 +1,24 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleRCUMap() {
+	flags := orderedmap.NewRCUMap[string, bool]()
+	flags.Store("new-ui", false)
+	flags.Store("dark-mode", true)
+
+	snap := flags.Snapshot()
+	flags.Update(func(f orderedmap.Frozen[string, bool]) orderedmap.Frozen[string, bool] {
+		return f.With("new-ui", true).With("beta", false)
+	})
+
+	fmt.Println(flags)
+	fmt.Println(snap)
+	// Output:
+	// RCUMap[new-ui:true dark-mode:true beta:false]
+	// Frozen[new-ui:false dark-mode:true]
+}
@@ -0,0 +1,20 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleSet() {
+	a := orderedmap.SetOf("c", "a", "b")
+	b := orderedmap.SetOf("d", "b")
+
+	u := a.Union(&b)
+	i := a.Intersection(&b)
+	fmt.Printf("union = %v\n", u)
+	fmt.Printf("intersection = %v\n", i)
+	// Output:
+	// union = Set[c a b d]
+	// intersection = Set[b]
+}
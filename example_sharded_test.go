@@ -0,0 +1,34 @@
+package orderedmap_test
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleShardedMap() {
+	sm := orderedmap.NewShardedMap[string, int](0)
+	sm.Store("a", 1)
+
+	var wg sync.WaitGroup
+	for i, k := range []string{"b", "c", "d"} {
+		wg.Add(1)
+		go func(k string, v int) {
+			defer wg.Done()
+			sm.Store(k, v)
+		}(k, i+2)
+	}
+	wg.Wait()
+
+	sm.Store("a", 10)
+	sm.Delete("c")
+
+	v, _ := sm.Load("a")
+	fmt.Printf("a = %d, len = %d\n", v, sm.Len())
+	om := sm.ToMap()
+	fmt.Printf("front key = %s\n", om.Front().Key())
+	// Output:
+	// a = 10, len = 3
+	// front key = a
+}
@@ -0,0 +1,44 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleMap_Snapshot() {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+
+	s := om.Snapshot()
+	om.Store("c", 3)
+	om.Delete("a")
+
+	fmt.Printf("om = %v\n", om)
+	fmt.Printf("s = %v\n", s)
+	// Output:
+	// om = Map[b:2 c:3]
+	// s = Snapshot[a:1 b:2]
+}
+
+func ExampleMap_SafeRange() {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+
+	om.SafeRange(func(k string, v int) bool {
+		if v%2 == 1 {
+			om.Delete(k)
+		}
+		fmt.Printf("%s: %d\n", k, v)
+		return true
+	})
+	fmt.Printf("om = %v\n", om)
+	// Output:
+	// a: 1
+	// b: 2
+	// c: 3
+	// om = Map[b:2]
+}
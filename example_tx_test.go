@@ -0,0 +1,31 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleMap_Begin() {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+
+	tx := om.Begin()
+	tx.Delete("a")
+	tx.Store("d", 4)
+	tx.MoveToFront("c")
+	tx.Rollback()
+	fmt.Printf("om = %v\n", om)
+
+	tx = om.Begin()
+	tx.Delete("a")
+	tx.Store("d", 4)
+	tx.MoveToFront("c")
+	tx.Commit()
+	fmt.Printf("om = %v\n", om)
+	// Output:
+	// om = Map[a:1 b:2 c:3]
+	// om = Map[c:3 b:2 d:4]
+}
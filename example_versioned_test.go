@@ -0,0 +1,29 @@
+package orderedmap_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+)
+
+func ExampleVersionedMap() {
+	vm := orderedmap.NewVersionedMap[string, int](10)
+	vm.Store("a", 1)
+	v2 := vm.Store("b", 2)
+	vm.Delete("a")
+	vm.Store("a", 3)
+
+	fmt.Printf("current = %v\n", vm)
+
+	old, _, _ := vm.LoadAt("a", v2)
+	fmt.Printf("a at version %d = %d\n", v2, old)
+	vm.RangeAt(v2, func(k string, v int) bool {
+		fmt.Printf("%s: %d\n", k, v)
+		return true
+	})
+	// Output:
+	// current = VersionedMap[b:2 a:3]
+	// a at version 2 = 1
+	// a: 1
+	// b: 2
+}
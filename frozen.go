@@ -0,0 +1,278 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Frozen is a struct which represents an immutable ordered map.
+// Its update methods: With and Without, return new versions of the map and
+// leave the receiver unchanged, and the versions share most of their
+// internal structure, so they cost O(log n) time and space.
+//
+// Because a Frozen is never modified, it can be passed between goroutines and
+// read concurrently without copies or locks. A zero Frozen is an empty map.
+type Frozen[K comparable, V any] struct {
+	order *pnode[frozenItem[K, V]]
+	index *pnode[[]frozenSlot[K]]
+	seq   uint64
+	len   int
+}
+
+// frozenItem is an entry of the order tree which is keyed by insertion
+// sequence numbers.
+type frozenItem[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// frozenSlot is an element of a bucket of the index tree which is keyed by
+// hashes of keys.
+type frozenSlot[K comparable] struct {
+	key K
+	seq uint64
+}
+
+// NewFrozen is a function which creates a new Frozen, which is empty.
+func NewFrozen[K comparable, V any]() Frozen[K, V] {
+	return Frozen[K, V]{}
+}
+
+// Freeze is a method which returns a Frozen which has the same entries with
+// this map in the same order.
+// This method builds balanced trees directly from the entries, so it does not
+// cost as much as calling With for each entry.
+func (om *Map[K, V]) Freeze() Frozen[K, V] {
+	n := om.Len()
+	seqs := make([]uint64, n)
+	items := make([]frozenItem[K, V], n)
+	slots := make([]hashedFrozenSlot[K], n)
+
+	i := 0
+	for ent := om.Front(); ent != nil; ent = ent.Next() {
+		seqs[i] = uint64(i)
+		items[i] = frozenItem[K, V]{key: ent.key, value: ent.value}
+		slots[i] = hashedFrozenSlot[K]{
+			hash: hashComparable(ent.key),
+			slot: frozenSlot[K]{key: ent.key, seq: uint64(i)},
+		}
+		i++
+	}
+
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].hash < slots[j].hash
+	})
+	hashes := make([]uint64, 0, n)
+	buckets := make([][]frozenSlot[K], 0, n)
+	for _, s := range slots {
+		last := len(hashes) - 1
+		if last >= 0 && hashes[last] == s.hash {
+			buckets[last] = append(buckets[last], s.slot)
+			continue
+		}
+		hashes = append(hashes, s.hash)
+		buckets = append(buckets, []frozenSlot[K]{s.slot})
+	}
+
+	return Frozen[K, V]{
+		order: pbuild(seqs, items),
+		index: pbuild(hashes, buckets),
+		seq:   uint64(n),
+		len:   n,
+	}
+}
+
+type hashedFrozenSlot[K comparable] struct {
+	hash uint64
+	slot frozenSlot[K]
+}
+
+// Thaw is a method which returns a new mutable Map which has the same entries
+// with this map in the same order.
+func (f Frozen[K, V]) Thaw() Map[K, V] {
+	om := New[K, V]()
+	prange(f.order, func(item frozenItem[K, V]) bool {
+		om.Store(item.key, item.value)
+		return true
+	})
+	return om
+}
+
+// Len is a method which returns the number of entries in this map.
+func (f Frozen[K, V]) Len() int {
+	return f.len
+}
+
+func (f Frozen[K, V]) lookup(key K) (hash uint64, bucket []frozenSlot[K], pos int) {
+	hash = hashComparable(key)
+	bucket, _ = pget(f.index, hash)
+	for i, s := range bucket {
+		if s.key == key {
+			return hash, bucket, i
+		}
+	}
+	return hash, bucket, -1
+}
+
+// Load is a method which returns a value stored in this map for a key.
+// If no value was found for a key, the ok result is false.
+func (f Frozen[K, V]) Load(key K) (value V, ok bool) {
+	_, bucket, pos := f.lookup(key)
+	if pos < 0 {
+		return
+	}
+	item, _ := pget(f.order, bucket[pos].seq)
+	return item.value, true
+}
+
+// Has is a method which checks whether this map has an entry for a key.
+func (f Frozen[K, V]) Has(key K) bool {
+	_, _, pos := f.lookup(key)
+	return pos >= 0
+}
+
+// With is a method which returns a new version of this map in which a value
+// is set for a key.
+// If the key is present, its position is not changed, otherwise the entry is
+// appended at the end.
+func (f Frozen[K, V]) With(key K, value V) Frozen[K, V] {
+	hash, bucket, pos := f.lookup(key)
+	item := frozenItem[K, V]{key: key, value: value}
+
+	if pos >= 0 {
+		f.order = pput(f.order, bucket[pos].seq, item)
+		return f
+	}
+
+	newBucket := make([]frozenSlot[K], len(bucket), len(bucket)+1)
+	copy(newBucket, bucket)
+	newBucket = append(newBucket, frozenSlot[K]{key: key, seq: f.seq})
+
+	f.order = pput(f.order, f.seq, item)
+	f.index = pput(f.index, hash, newBucket)
+	f.seq++
+	f.len++
+	return f
+}
+
+// Without is a method which returns a new version of this map in which an
+// entry for a key is deleted.
+// If the key is not present, this method returns this map as it is.
+func (f Frozen[K, V]) Without(key K) Frozen[K, V] {
+	hash, bucket, pos := f.lookup(key)
+	if pos < 0 {
+		return f
+	}
+
+	f.order = pdelete(f.order, bucket[pos].seq)
+	if len(bucket) == 1 {
+		f.index = pdelete(f.index, hash)
+	} else {
+		newBucket := make([]frozenSlot[K], 0, len(bucket)-1)
+		newBucket = append(newBucket, bucket[:pos]...)
+		newBucket = append(newBucket, bucket[pos+1:]...)
+		f.index = pput(f.index, hash, newBucket)
+	}
+	f.len--
+	return f
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in this map in the order of key insertions.
+// If fn returns false, this method stops the iteration.
+func (f Frozen[K, V]) Range(fn func(key K, value V) bool) {
+	prange(f.order, func(item frozenItem[K, V]) bool {
+		return fn(item.key, item.value)
+	})
+}
+
+// FrozenEntry is a struct which is an entry of Frozen and holds a pair of key
+// and value. Its methods: Next and Prev return the next and the previous
+// entries in the order of key insertions.
+type FrozenEntry[K comparable, V any] struct {
+	path ppath[frozenItem[K, V]]
+}
+
+func newFrozenEntry[K comparable, V any](path ppath[frozenItem[K, V]]) *FrozenEntry[K, V] {
+	if len(path) == 0 {
+		return nil
+	}
+	return &FrozenEntry[K, V]{path: path}
+}
+
+// Front is a method which returns the head entry of this map.
+// If this map is empty, this method returns nil.
+func (f Frozen[K, V]) Front() *FrozenEntry[K, V] {
+	return newFrozenEntry(pfirst(f.order, nil))
+}
+
+// Back is a method which returns the last entry of this map.
+// If this map is empty, this method returns nil.
+func (f Frozen[K, V]) Back() *FrozenEntry[K, V] {
+	return newFrozenEntry(plast(f.order, nil))
+}
+
+// Next is a method which returns the next entry of this entry.
+// If this entry is the last entry, the returned value is nil.
+func (ent *FrozenEntry[K, V]) Next() *FrozenEntry[K, V] {
+	return newFrozenEntry(ent.path.next())
+}
+
+// Prev is a method which returns the previous entry of this entry.
+// If this entry is the head entry, the returned value is nil.
+func (ent *FrozenEntry[K, V]) Prev() *FrozenEntry[K, V] {
+	return newFrozenEntry(ent.path.prev())
+}
+
+// Key is a method which returns the key of this entry.
+func (ent *FrozenEntry[K, V]) Key() K {
+	return ent.path[len(ent.path)-1].item.key
+}
+
+// Value is a method which returns the value of this entry.
+func (ent *FrozenEntry[K, V]) Value() V {
+	return ent.path[len(ent.path)-1].item.value
+}
+
+// String is a method which returns a string of the content of this map.
+func (f Frozen[K, V]) String() string {
+	var buf strings.Builder
+	buf.WriteString("Frozen[")
+	first := true
+	f.Range(func(key K, value V) bool {
+		if !first {
+			buf.WriteString(" ")
+		}
+		first = false
+		buf.WriteString(fmt.Sprintf("%v:%v", key, value))
+		return true
+	})
+	buf.WriteString("]")
+	return buf.String()
+}
+
+// MarshalJSON is a method which returns a JSON string of the content of this
+// map in the order of key insertions, like Map.MarshalJSON.
+// This method is an implementation of json.Marshaler interface.
+func (f Frozen[K, V]) MarshalJSON() ([]byte, error) {
+	om := f.Thaw()
+	return om.MarshalJSON()
+}
+
+// UnmarshalJSON is a method which sets this map to a new version which has
+// the entries in a JSON string, like Map.UnmarshalJSON.
+// This method is an implementation of json.Unmarshaler interface.
+func (f *Frozen[K, V]) UnmarshalJSON(data []byte) error {
+	om := f.Thaw()
+	err := om.UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	*f = om.Freeze()
+	return nil
+}
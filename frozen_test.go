@@ -0,0 +1,275 @@
+package orderedmap_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func frozenKeys[K comparable, V any](f orderedmap.Frozen[K, V]) []K {
+	keys := make([]K, 0, f.Len())
+	for ent := f.Front(); ent != nil; ent = ent.Next() {
+		keys = append(keys, ent.Key())
+	}
+	return keys
+}
+
+func TestNewFrozen(t *testing.T) {
+	f := orderedmap.NewFrozen[string, int]()
+	assert.Equal(t, f.Len(), 0)
+	assert.Nil(t, f.Front())
+	assert.Nil(t, f.Back())
+	assert.Equal(t, f.String(), "Frozen[]")
+
+	_, ok := f.Load("a")
+	assert.False(t, ok)
+
+	var zero orderedmap.Frozen[string, int]
+	zero = zero.With("a", 1)
+	assert.Equal(t, zero.String(), "Frozen[a:1]")
+}
+
+func TestFrozen_With(t *testing.T) {
+	f0 := orderedmap.NewFrozen[string, int]()
+	f1 := f0.With("c", 3)
+	f2 := f1.With("a", 1)
+	f3 := f2.With("b", 2)
+	f4 := f3.With("a", 10)
+
+	assert.Equal(t, f0.String(), "Frozen[]")
+	assert.Equal(t, f1.String(), "Frozen[c:3]")
+	assert.Equal(t, f2.String(), "Frozen[c:3 a:1]")
+	assert.Equal(t, f3.String(), "Frozen[c:3 a:1 b:2]")
+	assert.Equal(t, f4.String(), "Frozen[c:3 a:10 b:2]")
+	assert.Equal(t, f4.Len(), 3)
+
+	v, ok := f3.Load("a")
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	v, ok = f4.Load("a")
+	assert.True(t, ok)
+	assert.Equal(t, v, 10)
+	assert.True(t, f4.Has("b"))
+	assert.False(t, f4.Has("d"))
+}
+
+func TestFrozen_Without(t *testing.T) {
+	f := orderedmap.NewFrozen[string, int]().
+		With("a", 1).With("b", 2).With("c", 3)
+
+	f1 := f.Without("b")
+	assert.Equal(t, f1.String(), "Frozen[a:1 c:3]")
+	assert.Equal(t, f1.Len(), 2)
+	assert.Equal(t, f.String(), "Frozen[a:1 b:2 c:3]")
+
+	f2 := f1.Without("x")
+	assert.Equal(t, f2.String(), "Frozen[a:1 c:3]")
+
+	f3 := f1.With("b", 20)
+	assert.Equal(t, f3.String(), "Frozen[a:1 c:3 b:20]")
+
+	f4 := f3.Without("a").Without("c").Without("b")
+	assert.Equal(t, f4.Len(), 0)
+	assert.Nil(t, f4.Front())
+}
+
+func TestFrozen_iteration(t *testing.T) {
+	f := orderedmap.NewFrozen[int, string]()
+	for i := 0; i < 100; i++ {
+		f = f.With(i*7%100, strconv.Itoa(i))
+	}
+
+	keys := frozenKeys(f)
+	assert.Equal(t, len(keys), 100)
+	for i, k := range keys {
+		assert.Equal(t, k, i*7%100)
+	}
+
+	var back []int
+	for ent := f.Back(); ent != nil; ent = ent.Prev() {
+		back = append(back, ent.Key())
+	}
+	for i, k := range back {
+		assert.Equal(t, k, keys[99-i])
+	}
+
+	ent := f.Front().Next().Next()
+	assert.Equal(t, ent.Key(), 14)
+	assert.Equal(t, ent.Value(), "2")
+	assert.Equal(t, ent.Prev().Key(), 7)
+	assert.Nil(t, f.Front().Prev())
+	assert.Nil(t, f.Back().Next())
+
+	var ranged []int
+	f.Range(func(k int, v string) bool {
+		ranged = append(ranged, k)
+		return len(ranged) < 5
+	})
+	assert.Equal(t, ranged, keys[:5])
+}
+
+func TestFrozen_randomOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	om := orderedmap.New[int, int]()
+	f := orderedmap.NewFrozen[int, int]()
+	versions := []orderedmap.Frozen[int, int]{}
+	expected := []string{}
+
+	for i := 0; i < 3000; i++ {
+		k := r.Intn(200)
+		if r.Intn(3) == 0 {
+			om.Delete(k)
+			f = f.Without(k)
+		} else {
+			om.Store(k, i)
+			f = f.With(k, i)
+		}
+		if i%100 == 0 {
+			versions = append(versions, f)
+			expected = append(expected, om.String())
+		}
+	}
+
+	assert.Equal(t, f.Len(), om.Len())
+	assert.Equal(t, f.String(), "Frozen"+om.String()[3:])
+	for i := 0; i < 200; i++ {
+		v1, ok1 := om.Load(i)
+		v2, ok2 := f.Load(i)
+		assert.Equal(t, ok1, ok2)
+		assert.Equal(t, v1, v2)
+	}
+
+	for i, v := range versions {
+		assert.Equal(t, v.String(), "Frozen"+expected[i][3:])
+	}
+}
+
+func TestMap_Freeze(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	for i := 0; i < 50; i++ {
+		om.Store("k"+strconv.Itoa(49-i), i)
+	}
+	om.Ldelete("k10")
+
+	f := om.Freeze()
+	assert.Equal(t, f.Len(), 49)
+	assert.Equal(t, f.String(), "Frozen"+om.String()[3:])
+	_, ok := f.Load("k10")
+	assert.False(t, ok)
+	v, ok := f.Load("k49")
+	assert.True(t, ok)
+	assert.Equal(t, v, 0)
+
+	om.Store("k49", 100)
+	om.Store("new", 1)
+	v, _ = f.Load("k49")
+	assert.Equal(t, v, 0)
+	assert.False(t, f.Has("new"))
+
+	f2 := f.With("new", 2).Without("k0")
+	assert.Equal(t, f2.Len(), 49)
+	assert.Equal(t, f2.Back().Key(), "new")
+
+	om2 := f2.Thaw()
+	assert.Equal(t, om2.String(), "Map"+f2.String()[6:])
+}
+
+type frozenKey struct {
+	name string
+	n    float64
+	tag  [2]string
+}
+
+func TestFrozen_structKeys(t *testing.T) {
+	f := orderedmap.NewFrozen[frozenKey, int]()
+	f = f.With(frozenKey{name: "a", n: 0, tag: [2]string{"x"}}, 1)
+	f = f.With(frozenKey{name: "a", n: 0, tag: [2]string{"", "x"}}, 2)
+	f = f.With(frozenKey{name: "b", n: 1.5}, 3)
+
+	assert.Equal(t, f.Len(), 3)
+
+	v, ok := f.Load(frozenKey{name: "a", n: math.Copysign(0, -1), tag: [2]string{"x"}})
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	v, ok = f.Load(frozenKey{name: "a", tag: [2]string{"", "x"}})
+	assert.True(t, ok)
+	assert.Equal(t, v, 2)
+	v, ok = f.Load(frozenKey{name: "b", n: 1.5})
+	assert.True(t, ok)
+	assert.Equal(t, v, 3)
+	_, ok = f.Load(frozenKey{name: "b"})
+	assert.False(t, ok)
+
+	p1, p2 := new(int), new(int)
+	fp := orderedmap.NewFrozen[*int, string]().With(p1, "p1").With(p2, "p2")
+	v2, _ := fp.Load(p2)
+	assert.Equal(t, v2, "p2")
+}
+
+func TestFrozen_concurrentReads(t *testing.T) {
+	f := orderedmap.NewFrozen[int, int]()
+	for i := 0; i < 1000; i++ {
+		f = f.With(i, i*2)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			local := f
+			for i := 0; i < 1000; i++ {
+				v, ok := local.Load(i)
+				if !ok || v != i*2 {
+					t.Errorf("unexpected value: %d", v)
+				}
+				local = local.With(i, g)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	v, _ := f.Load(10)
+	assert.Equal(t, v, 20)
+}
+
+func TestFrozen_JSON(t *testing.T) {
+	f := orderedmap.NewFrozen[string, int]().With("z", 26).With("a", 1)
+
+	b, err := json.Marshal(f)
+	assert.Nil(t, err)
+	assert.Equal(t, string(b), `{"z":26,"a":1}`)
+
+	var f2 orderedmap.Frozen[string, int]
+	assert.Nil(t, json.Unmarshal([]byte(`{"y":25,"b":2}`), &f2))
+	assert.Equal(t, f2.String(), "Frozen[y:25 b:2]")
+
+	f3 := f2
+	assert.Nil(t, json.Unmarshal([]byte(`{"c":3}`), &f3))
+	assert.Equal(t, f3.String(), "Frozen[y:25 b:2 c:3]")
+	assert.Equal(t, f2.String(), "Frozen[y:25 b:2]")
+}
+
+func BenchmarkFrozen_With(b *testing.B) {
+	f := orderedmap.NewFrozen[int, int]()
+	for i := 0; i < b.N; i++ {
+		f = f.With(i, i)
+	}
+}
+
+func BenchmarkFrozen_Load(b *testing.B) {
+	f := orderedmap.NewFrozen[int, int]()
+	for i := 0; i < 100000; i++ {
+		f = f.With(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Load(i % 100000)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"fmt"
+)
+
+// KeyCollisionPolicy is a type which specifies the behavior of MapKeys when
+// multiple entries are mapped to a same key.
+type KeyCollisionPolicy int
+
+const (
+	// KeepFirstKey is a KeyCollisionPolicy which keeps the value of the first
+	// entry mapped to a key and ignores the later ones.
+	KeepFirstKey KeyCollisionPolicy = iota
+
+	// KeepLastKey is a KeyCollisionPolicy which stores the value of the last
+	// entry mapped to a key. The position of the key is the position of the
+	// first entry mapped to it.
+	KeepLastKey
+
+	// RejectKeyCollision is a KeyCollisionPolicy which makes MapKeys return a
+	// KeyCollisionError.
+	RejectKeyCollision
+)
+
+// KeyCollisionError is an error type which is returned by MapKeys when
+// multiple entries are mapped to a same key with RejectKeyCollision.
+type KeyCollisionError struct {
+	Key any
+}
+
+func (err KeyCollisionError) Error() string {
+	return fmt.Sprintf("multiple entries are mapped to a same key: %v", err.Key)
+}
+
+// Pair is a struct which holds two values of an entry created by Zip.
+type Pair[A any, B any] struct {
+	First  A
+	Second B
+}
+
+// Filter is a function which creates a new map which has the entries of om
+// for which pred returns true, in the same order.
+func Filter[K comparable, V any](om *Map[K, V], pred func(key K, value V) bool) *Map[K, V] {
+	res := New[K, V]()
+	om.Range(func(k K, v V) bool {
+		if pred(k, v) {
+			res.Store(k, v)
+		}
+		return true
+	})
+	return &res
+}
+
+// MapValues is a function which creates a new map which has the keys of om
+// in the same order and the values converted by fn.
+func MapValues[K comparable, V any, W any](om *Map[K, V], fn func(key K, value V) W) *Map[K, W] {
+	res := New[K, W]()
+	om.Range(func(k K, v V) bool {
+		res.Store(k, fn(k, v))
+		return true
+	})
+	return &res
+}
+
+// MapKeys is a function which creates a new map which has the keys of om
+// converted by fn and the values of om, in the same order.
+// If multiple entries are mapped to a same key, the policy decides which
+// value is stored. With RejectKeyCollision, this function returns nil and a
+// KeyCollisionError.
+func MapKeys[K comparable, V any, L comparable](
+	om *Map[K, V],
+	fn func(key K, value V) L,
+	policy KeyCollisionPolicy,
+) (*Map[L, V], error) {
+	res := New[L, V]()
+	var err error
+	om.Range(func(k K, v V) bool {
+		l := fn(k, v)
+		switch policy {
+		case KeepFirstKey:
+			res.LoadOrStore(l, v)
+		case KeepLastKey:
+			res.Store(l, v)
+		default:
+			if _, loaded := res.LoadOrStore(l, v); loaded {
+				err = KeyCollisionError{Key: l}
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Reduce is a function which calls fn for each entry of om in the order of
+// key insertions with the accumulated value, which starts from init, and
+// returns the final accumulated value.
+func Reduce[K comparable, V any, A any](om *Map[K, V], init A, fn func(acc A, key K, value V) A) A {
+	acc := init
+	om.Range(func(k K, v V) bool {
+		acc = fn(acc, k, v)
+		return true
+	})
+	return acc
+}
+
+// GroupBy is a function which divides the entries of om into groups by the
+// group keys returned by fn. The groups are in the order in which their group
+// keys are first seen, and the entries in each group are in the same order as
+// om.
+func GroupBy[K comparable, V any, G comparable](om *Map[K, V], fn func(key K, value V) G) *Map[G, *Map[K, V]] {
+	res := New[G, *Map[K, V]]()
+	om.Range(func(k K, v V) bool {
+		g := fn(k, v)
+		grp, ok := res.Load(g)
+		if !ok {
+			m := New[K, V]()
+			grp = &m
+			res.Store(g, grp)
+		}
+		grp.Store(k, v)
+		return true
+	})
+	return &res
+}
+
+// Partition is a function which divides the entries of om into two maps: one
+// has the entries for which pred returns true, and the other has the rest.
+// Both maps keep the order of om.
+func Partition[K comparable, V any](om *Map[K, V], pred func(key K, value V) bool) (matched, unmatched *Map[K, V]) {
+	m := New[K, V]()
+	u := New[K, V]()
+	om.Range(func(k K, v V) bool {
+		if pred(k, v) {
+			m.Store(k, v)
+		} else {
+			u.Store(k, v)
+		}
+		return true
+	})
+	return &m, &u
+}
+
+// TakeWhile is a function which creates a new map which has the leading
+// entries of om for which pred returns true, until pred returns false.
+func TakeWhile[K comparable, V any](om *Map[K, V], pred func(key K, value V) bool) *Map[K, V] {
+	res := New[K, V]()
+	om.Range(func(k K, v V) bool {
+		if !pred(k, v) {
+			return false
+		}
+		res.Store(k, v)
+		return true
+	})
+	return &res
+}
+
+// DropWhile is a function which creates a new map which has the entries of om
+// after the leading entries for which pred returns true.
+func DropWhile[K comparable, V any](om *Map[K, V], pred func(key K, value V) bool) *Map[K, V] {
+	res := New[K, V]()
+	dropping := true
+	om.Range(func(k K, v V) bool {
+		if dropping && pred(k, v) {
+			return true
+		}
+		dropping = false
+		res.Store(k, v)
+		return true
+	})
+	return &res
+}
+
+// Chunk is a function which divides the entries of om into maps each of which
+// has n entries in the order of om, except the last one which can have fewer.
+// If n is less than 1, this function returns nil.
+func Chunk[K comparable, V any](om *Map[K, V], n int) []*Map[K, V] {
+	if n < 1 {
+		return nil
+	}
+
+	var chunks []*Map[K, V]
+	var cur *Map[K, V]
+	om.Range(func(k K, v V) bool {
+		if cur == nil || cur.Len() == n {
+			m := New[K, V]()
+			cur = &m
+			chunks = append(chunks, cur)
+		}
+		cur.Store(k, v)
+		return true
+	})
+	return chunks
+}
+
+// Zip is a function which creates a new map which has the keys present in both
+// a and b in the order of a, and of which each value is a Pair of the values
+// of a and b for the key.
+func Zip[K comparable, A any, B any](a *Map[K, A], b *Map[K, B]) *Map[K, Pair[A, B]] {
+	res := New[K, Pair[A, B]]()
+	a.Range(func(k K, va A) bool {
+		if vb, ok := b.Load(k); ok {
+			res.Store(k, Pair[A, B]{First: va, Second: vb})
+		}
+		return true
+	})
+	return &res
+}
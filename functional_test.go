@@ -0,0 +1,158 @@
+package orderedmap_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func newNumbers() orderedmap.Map[string, int] {
+	om := orderedmap.New[string, int]()
+	om.Store("one", 1)
+	om.Store("two", 2)
+	om.Store("three", 3)
+	om.Store("four", 4)
+	om.Store("five", 5)
+	return om
+}
+
+func isOdd(k string, v int) bool {
+	return v%2 == 1
+}
+
+func TestFilter(t *testing.T) {
+	om := newNumbers()
+	res := orderedmap.Filter(&om, isOdd)
+	assert.Equal(t, res.String(), "Map[one:1 three:3 five:5]")
+	assert.Equal(t, om.Len(), 5)
+
+	empty := orderedmap.New[string, int]()
+	assert.Equal(t, orderedmap.Filter(&empty, isOdd).Len(), 0)
+}
+
+func TestMapValues(t *testing.T) {
+	om := newNumbers()
+	res := orderedmap.MapValues(&om, func(k string, v int) string {
+		return strings.Repeat("*", v)
+	})
+	assert.Equal(t, res.String(), "Map[one:* two:** three:*** four:**** five:*****]")
+}
+
+func TestMapKeys(t *testing.T) {
+	om := newNumbers()
+	byLen := func(k string, v int) int { return len(k) }
+
+	res, err := orderedmap.MapKeys(&om, byLen, orderedmap.KeepFirstKey)
+	assert.Nil(t, err)
+	assert.Equal(t, res.String(), "Map[3:1 5:3 4:4]")
+
+	res, err = orderedmap.MapKeys(&om, byLen, orderedmap.KeepLastKey)
+	assert.Nil(t, err)
+	assert.Equal(t, res.String(), "Map[3:2 5:3 4:5]")
+
+	res, err = orderedmap.MapKeys(&om, byLen, orderedmap.RejectKeyCollision)
+	assert.Nil(t, res)
+	assert.Equal(t, err, orderedmap.KeyCollisionError{Key: 3})
+	assert.Equal(t, err.Error(), "multiple entries are mapped to a same key: 3")
+
+	upper, err := orderedmap.MapKeys(&om, func(k string, v int) string {
+		return strings.ToUpper(k)
+	}, orderedmap.RejectKeyCollision)
+	assert.Nil(t, err)
+	assert.Equal(t, upper.String(), "Map[ONE:1 TWO:2 THREE:3 FOUR:4 FIVE:5]")
+}
+
+func TestReduce(t *testing.T) {
+	om := newNumbers()
+	sum := orderedmap.Reduce(&om, 0, func(acc int, k string, v int) int {
+		return acc + v
+	})
+	assert.Equal(t, sum, 15)
+
+	keys := orderedmap.Reduce(&om, "", func(acc string, k string, v int) string {
+		return acc + k[:1]
+	})
+	assert.Equal(t, keys, "ottff")
+}
+
+func TestGroupBy(t *testing.T) {
+	om := newNumbers()
+	groups := orderedmap.GroupBy(&om, func(k string, v int) string {
+		return k[:1]
+	})
+	assert.Equal(t, groups.Len(), 3)
+
+	var res []string
+	groups.Range(func(g string, m *orderedmap.Map[string, int]) bool {
+		res = append(res, g+"="+m.String())
+		return true
+	})
+	assert.Equal(t, res, []string{
+		"o=Map[one:1]",
+		"t=Map[two:2 three:3]",
+		"f=Map[four:4 five:5]",
+	})
+}
+
+func TestPartition(t *testing.T) {
+	om := newNumbers()
+	matched, unmatched := orderedmap.Partition(&om, isOdd)
+	assert.Equal(t, matched.String(), "Map[one:1 three:3 five:5]")
+	assert.Equal(t, unmatched.String(), "Map[two:2 four:4]")
+}
+
+func TestTakeWhileAndDropWhile(t *testing.T) {
+	om := newNumbers()
+	lessThan3 := func(k string, v int) bool { return v < 3 }
+
+	assert.Equal(t, orderedmap.TakeWhile(&om, lessThan3).String(), "Map[one:1 two:2]")
+	assert.Equal(t, orderedmap.DropWhile(&om, lessThan3).String(),
+		"Map[three:3 four:4 five:5]")
+
+	assert.Equal(t, orderedmap.TakeWhile(&om, isOdd).String(), "Map[one:1]")
+	assert.Equal(t, orderedmap.DropWhile(&om, isOdd).String(),
+		"Map[two:2 three:3 four:4 five:5]")
+
+	always := func(string, int) bool { return true }
+	assert.Equal(t, orderedmap.TakeWhile(&om, always).Len(), 5)
+	assert.Equal(t, orderedmap.DropWhile(&om, always).Len(), 0)
+}
+
+func TestChunk(t *testing.T) {
+	om := newNumbers()
+
+	chunks := orderedmap.Chunk(&om, 2)
+	assert.Equal(t, len(chunks), 3)
+	assert.Equal(t, chunks[0].String(), "Map[one:1 two:2]")
+	assert.Equal(t, chunks[1].String(), "Map[three:3 four:4]")
+	assert.Equal(t, chunks[2].String(), "Map[five:5]")
+
+	chunks = orderedmap.Chunk(&om, 5)
+	assert.Equal(t, len(chunks), 1)
+	assert.Equal(t, chunks[0].Len(), 5)
+
+	empty := orderedmap.New[string, int]()
+	assert.Nil(t, orderedmap.Chunk(&empty, 2))
+
+	assert.Nil(t, orderedmap.Chunk(&om, 0))
+	assert.Nil(t, orderedmap.Chunk(&om, -1))
+}
+
+func TestZip(t *testing.T) {
+	om := newNumbers()
+	names := orderedmap.New[string, string]()
+	names.Store("five", "V")
+	names.Store("one", "I")
+	names.Store("ten", "X")
+	names.Store("three", "III")
+
+	res := orderedmap.Zip(&om, &names)
+	assert.Equal(t, res.String(), "Map[one:{1 I} three:{3 III} five:{5 V}]")
+
+	p, ok := res.Load("three")
+	assert.True(t, ok)
+	assert.Equal(t, p.First, 3)
+	assert.Equal(t, p.Second, "III")
+}
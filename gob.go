@@ -0,0 +1,464 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// The binary layout written by MarshalBinary and GobEncode is as follows:
+//
+//	+---------+--------+---------+-------------------------------------+
+//	| version | flags  | count   | count times of key and value        |
+//	| 1 byte  | 1 byte | uvarint |                                     |
+//	+---------+--------+---------+-------------------------------------+
+//
+// Each key and each value is a uvarint of its length followed by its bytes,
+// which are encoded in MessagePack with the primitives of binary.go.
+// If the flags has binaryFlagTombstones, a count and the keys and values of
+// the logically deleted entries follow in the same way.
+//
+// In addition to the types which MessagePack can express, this layout writes
+// a value of encoding.BinaryMarshaler, such as time.Time, as a byte string of
+// its MarshalBinary, and a struct as an array of its exported fields in the
+// order of their declarations.
+const (
+	binaryVersion1 byte = 1
+
+	binaryFlagTombstones byte = 1 << 0
+
+	binaryLayoutFormat = "binary"
+)
+
+// binaryLayoutWriter is a msgpackWriter which reports the format of the
+// binary layout, so that encodeBinary accepts structs and values of
+// encoding.BinaryMarshaler.
+type binaryLayoutWriter struct {
+	msgpackWriter
+}
+
+func (w *binaryLayoutWriter) format() string {
+	return binaryLayoutFormat
+}
+
+func (w *binaryLayoutWriter) newWriter() binaryWriter {
+	return &binaryLayoutWriter{}
+}
+
+type binaryElement struct {
+	key   []byte
+	value []byte
+}
+
+// MarshalBinary is a method which returns a byte array which expresses the
+// entries of this map in the order of key insertions.
+// This method is an implementation of encoding.BinaryMarshaler interface.
+// Logically deleted entries are not included. To include them, use
+// MarshalBinaryWithTombstones instead.
+func (om Map[K, V]) MarshalBinary() ([]byte, error) {
+	return om.marshalBinary(false)
+}
+
+// MarshalBinaryWithTombstones is a method which returns a byte array which
+// expresses the entries of this map like MarshalBinary, and also the entries
+// logically deleted by Ldelete or LoadAndLdelete.
+// Because the logically deleted entries have no order, they are written in
+// the order of their encoded keys, so that a same map is always written in a
+// same byte array.
+func (om Map[K, V]) MarshalBinaryWithTombstones() ([]byte, error) {
+	return om.marshalBinary(true)
+}
+
+func (om Map[K, V]) marshalBinary(withTombstones bool) ([]byte, error) {
+	return marshalBinaryLayout(om, withTombstones, func(key K) any {
+		return key
+	})
+}
+
+// marshalBinaryLayout writes the entries of a Map in the binary layout.
+// The keyOf function returns the key to be written for a key of the Map, so
+// that a HashMap can write its original keys.
+func marshalBinaryLayout[K comparable, V any](
+	om Map[K, V],
+	withTombstones bool,
+	keyOf func(K) any,
+) ([]byte, error) {
+	var flags byte
+	if withTombstones {
+		flags |= binaryFlagTombstones
+	}
+
+	entries := make([]binaryElement, 0, om.len)
+	for ent := om.Front(); ent != nil; ent = ent.Next() {
+		e, err := encodeBinaryElement(keyOf(ent.key), ent.value)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(binaryVersion1)
+	buf.WriteByte(flags)
+	writeBinaryElements(&buf, entries)
+
+	if withTombstones {
+		tombstones, err := sortedTombstones(om, keyOf)
+		if err != nil {
+			return nil, err
+		}
+		writeBinaryElements(&buf, tombstones)
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeBinaryElement(key, value any) (binaryElement, error) {
+	kw := &binaryLayoutWriter{}
+	err := encodeBinary(kw, key, 0)
+	if err != nil {
+		return binaryElement{}, err
+	}
+	vw := &binaryLayoutWriter{}
+	err = encodeBinary(vw, value, 0)
+	if err != nil {
+		return binaryElement{}, err
+	}
+	return binaryElement{key: kw.bytes(), value: vw.bytes()}, nil
+}
+
+func writeBinaryElements(buf *bytes.Buffer, elems []binaryElement) {
+	var n [binary.MaxVarintLen64]byte
+	buf.Write(n[:binary.PutUvarint(n[:], uint64(len(elems)))])
+	for _, e := range elems {
+		buf.Write(n[:binary.PutUvarint(n[:], uint64(len(e.key)))])
+		buf.Write(e.key)
+		buf.Write(n[:binary.PutUvarint(n[:], uint64(len(e.value)))])
+		buf.Write(e.value)
+	}
+}
+
+// sortedTombstones returns the logically deleted entries of this map sorted
+// by their encoded keys, because the iteration order of a Go map is not
+// stable.
+func sortedTombstones[K comparable, V any](
+	om Map[K, V],
+	keyOf func(K) any,
+) ([]binaryElement, error) {
+	var ts []binaryElement
+	for _, ent := range om.m {
+		if !ent.deleted {
+			continue
+		}
+		e, err := encodeBinaryElement(keyOf(ent.key), ent.value)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, e)
+	}
+	sort.Slice(ts, func(i, j int) bool {
+		return bytes.Compare(ts[i].key, ts[j].key) < 0
+	})
+	return ts, nil
+}
+
+// UnmarshalBinary is a method which replaces the content of this map with
+// the entries in a byte array written by MarshalBinary or
+// MarshalBinaryWithTombstones.
+// This method is an implementation of encoding.BinaryUnmarshaler interface.
+// If the value type of this map is any, values are decoded in the same way as
+// UnmarshalMsgpack.
+// Because this method replaces the whole content, it does not notify
+// observers of this map.
+func (om *Map[K, V]) UnmarshalBinary(data []byte) error {
+	entries, tombstones, err := decodeBinaryLayout[K, V](data)
+	if err != nil {
+		return err
+	}
+	om.replace(entries, tombstones)
+	return nil
+}
+
+func decodeBinaryLayout[K any, V any](
+	data []byte,
+) (entries, tombstones []Pair[K, V], err error) {
+	if len(data) < 2 {
+		err = DecodeError{
+			Format: binaryLayoutFormat,
+			Offset: int64(len(data)),
+			msg:    "unexpected end of data",
+		}
+		return
+	}
+	if data[0] != binaryVersion1 {
+		err = DecodeError{
+			Format: binaryLayoutFormat,
+			Offset: 0,
+			msg:    "unsupported version " + strconv.Itoa(int(data[0])),
+		}
+		return
+	}
+	flags := data[1]
+	if flags&^binaryFlagTombstones != 0 {
+		err = DecodeError{
+			Format: binaryLayoutFormat,
+			Offset: 1,
+			msg:    "unsupported flags " + strconv.Itoa(int(flags)),
+		}
+		return
+	}
+
+	r := &binaryLayoutReader{data: data, pos: 2}
+	entries, err = readBinaryElements[K, V](r)
+	if err != nil {
+		return
+	}
+	if flags&binaryFlagTombstones != 0 {
+		tombstones, err = readBinaryElements[K, V](r)
+		if err != nil {
+			return
+		}
+	}
+	if r.remaining() > 0 {
+		err = r.errorf("invalid data after the entries")
+	}
+	return
+}
+
+// replace is a method which replaces the content of this map with entries
+// and logically deleted entries, without notifying observers.
+func (om *Map[K, V]) replace(entries, tombstones []Pair[K, V]) {
+	om.m = make(map[K](*Entry[K, V]), len(entries)+len(tombstones))
+	om.head = nil
+	om.last = nil
+	om.len = 0
+	om.mod++
+	om.cow = nil
+
+	for _, p := range entries {
+		if ent, exists := om.m[p.First]; exists {
+			ent.value = p.Second
+			continue
+		}
+		ent := &Entry[K, V]{key: p.First, value: p.Second}
+		om.m[p.First] = ent
+		if om.last == nil {
+			om.head = ent
+		} else {
+			ent.prev = om.last
+			om.last.next = ent
+		}
+		om.last = ent
+		om.len++
+	}
+	for _, p := range tombstones {
+		if _, exists := om.m[p.First]; exists {
+			continue
+		}
+		om.m[p.First] = &Entry[K, V]{key: p.First, value: p.Second, deleted: true}
+	}
+}
+
+// binaryLayoutReader reads the counts and the length-prefixed elements of the
+// binary layout.
+type binaryLayoutReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *binaryLayoutReader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+func (r *binaryLayoutReader) errorf(msg string) error {
+	return DecodeError{
+		Format: binaryLayoutFormat,
+		Offset: int64(r.pos),
+		msg:    msg,
+	}
+}
+
+func (r *binaryLayoutReader) readUvarint() (uint64, error) {
+	n, size := binary.Uvarint(r.data[r.pos:])
+	if size == 0 {
+		return 0, r.errorf("unexpected end of data")
+	}
+	if size < 0 {
+		return 0, r.errorf("too large length")
+	}
+	r.pos += size
+	return n, nil
+}
+
+// readBinaryValue decodes a length-prefixed element into a value of a type
+// parameter. The offset of a DecodeError is made relative to the whole data.
+func readBinaryValue[T any](r *binaryLayoutReader) (T, error) {
+	var t T
+	n, err := r.readUvarint()
+	if err != nil {
+		return t, err
+	}
+	if n > uint64(r.remaining()) {
+		return t, r.errorf("unexpected end of data")
+	}
+	start := r.pos
+	mr := &msgpackReader{data: r.data[start : start+int(n)]}
+	r.pos += int(n)
+
+	v, err := decodeBinary(mr, 0)
+	if err == nil && mr.remaining() > 0 {
+		err = binaryErrorf(mr, "invalid data after the element")
+	}
+	if err != nil {
+		var de DecodeError
+		if errors.As(err, &de) {
+			de.Format = binaryLayoutFormat
+			de.Offset += int64(start)
+			return t, de
+		}
+		return t, err
+	}
+	return convertBinary[T](binaryLayoutFormat, v)
+}
+
+func readBinaryElements[K any, V any](
+	r *binaryLayoutReader,
+) ([]Pair[K, V], error) {
+	n, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.remaining()/2) {
+		return nil, r.errorf("unexpected end of data")
+	}
+	entries := make([]Pair[K, V], n)
+	for i := range entries {
+		entries[i].First, err = readBinaryValue[K](r)
+		if err != nil {
+			return nil, err
+		}
+		entries[i].Second, err = readBinaryValue[V](r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// encodeBinaryStruct writes a struct as an array of its exported fields.
+func encodeBinaryStruct(w binaryWriter, rv reflect.Value, depth int) error {
+	fields := exportedFieldIndexes(rv.Type())
+	w.writeArrayHeader(len(fields))
+	for _, i := range fields {
+		err := encodeBinary(w, rv.Field(i).Interface(), depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignBinaryStruct sets the exported fields of a struct from an array
+// written by encodeBinaryStruct.
+func assignBinaryStruct(dst reflect.Value, arr []any) bool {
+	fields := exportedFieldIndexes(dst.Type())
+	if len(fields) != len(arr) {
+		return false
+	}
+	for j, i := range fields {
+		if assignBinary(binaryLayoutFormat, dst.Field(i), arr[j]) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func exportedFieldIndexes(t reflect.Type) []int {
+	fields := make([]int, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			fields = append(fields, i)
+		}
+	}
+	return fields
+}
+
+// GobEncode is a method which returns a byte array which expresses the
+// entries of this map. This method is an implementation of gob.GobEncoder
+// interface and writes the same layout as MarshalBinaryWithTombstones, so
+// that a map decoded by GobDecode keeps the logically deleted entries.
+func (om Map[K, V]) GobEncode() ([]byte, error) {
+	return om.MarshalBinaryWithTombstones()
+}
+
+// GobDecode is a method which replaces the content of this map with the
+// entries in a byte array written by GobEncode. This method is an
+// implementation of gob.GobDecoder interface.
+func (om *Map[K, V]) GobDecode(data []byte) error {
+	return om.UnmarshalBinary(data)
+}
+
+// MarshalBinary is a method which returns a byte array which expresses the
+// entries of this map in the order of key insertions, like Map.MarshalBinary.
+func (hm HashMap[K, V]) MarshalBinary() ([]byte, error) {
+	return marshalBinaryLayout(hm.om, false, originalKey[K])
+}
+
+// MarshalBinaryWithTombstones is a method which returns a byte array which
+// expresses the entries of this map and also the logically deleted entries,
+// like Map.MarshalBinaryWithTombstones.
+func (hm HashMap[K, V]) MarshalBinaryWithTombstones() ([]byte, error) {
+	return marshalBinaryLayout(hm.om, true, originalKey[K])
+}
+
+func originalKey[K any](box *hashKey[K]) any {
+	return box.key
+}
+
+// UnmarshalBinary is a method which replaces the content of this map with
+// the entries in a byte array written by MarshalBinary or
+// MarshalBinaryWithTombstones, like Map.UnmarshalBinary.
+func (hm *HashMap[K, V]) UnmarshalBinary(data []byte) error {
+	if hm.keys == nil {
+		return DecodeTypeError{
+			Format: binaryLayoutFormat,
+			Value:  "map",
+			Type:   reflect.TypeOf(hm).Elem(),
+		}
+	}
+	entries, tombstones, err := decodeBinaryLayout[K, V](data)
+	if err != nil {
+		return err
+	}
+
+	keys := hm.keys.empty()
+	boxed := func(pairs []Pair[K, V]) []Pair[*hashKey[K], V] {
+		bs := make([]Pair[*hashKey[K], V], len(pairs))
+		for i, p := range pairs {
+			bs[i].First, keys = keys.intern(p.First)
+			bs[i].Second = p.Second
+		}
+		return bs
+	}
+	hm.om.replace(boxed(entries), boxed(tombstones))
+	hm.keys = keys
+	return nil
+}
+
+// GobEncode is a method which returns a byte array which expresses the
+// entries of this map like Map.GobEncode.
+func (hm HashMap[K, V]) GobEncode() ([]byte, error) {
+	return hm.MarshalBinaryWithTombstones()
+}
+
+// GobDecode is a method which replaces the content of this map with the
+// entries in a byte array written by GobEncode.
+func (hm *HashMap[K, V]) GobDecode(data []byte) error {
+	return hm.UnmarshalBinary(data)
+}
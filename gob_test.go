@@ -0,0 +1,358 @@
+package orderedmap_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = orderedmap.Map[string, int]{}
+	_ encoding.BinaryUnmarshaler = (*orderedmap.Map[string, int])(nil)
+	_ gob.GobEncoder             = orderedmap.Map[string, int]{}
+	_ gob.GobDecoder             = (*orderedmap.Map[string, int])(nil)
+)
+
+type point struct {
+	X, Y int
+}
+
+func binaryRoundTrip[K comparable, V any](
+	t *testing.T, om orderedmap.Map[K, V],
+) orderedmap.Map[K, V] {
+	b, err := om.MarshalBinary()
+	assert.Nil(t, err)
+	om2 := orderedmap.New[K, V]()
+	assert.Nil(t, om2.UnmarshalBinary(b))
+	assert.Equal(t, om2.String(), om.String())
+	assert.Equal(t, om2.Len(), om.Len())
+	return om2
+}
+
+func TestMap_MarshalBinary_empty(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	b, err := om.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, b[:2], []byte{1, 0})
+
+	om2 := binaryRoundTrip(t, om)
+	assert.Equal(t, om2.Len(), 0)
+	assert.Nil(t, om2.Front())
+}
+
+func TestMap_MarshalBinary_instantiations(t *testing.T) {
+	om0 := orderedmap.New[string, int]()
+	om0.Store("z", 0)
+	om0.Store("a", 1)
+	om0.Store("", -2)
+	binaryRoundTrip(t, om0)
+
+	om1 := orderedmap.New[int, string]()
+	om1.Store(3, "c")
+	om1.Store(-1, "")
+	om1.Store(0, "a")
+	binaryRoundTrip(t, om1)
+
+	om2 := orderedmap.New[float64, []byte]()
+	om2.Store(1.5, []byte{1, 2})
+	om2.Store(-0.25, nil)
+	binaryRoundTrip(t, om2)
+
+	om3 := orderedmap.New[point, *point]()
+	om3.Store(point{X: 1, Y: 2}, &point{X: 3, Y: 4})
+	om3.Store(point{}, nil)
+	om4 := binaryRoundTrip(t, om3)
+	v, _ := om4.Load(point{X: 1, Y: 2})
+	assert.Equal(t, *v, point{X: 3, Y: 4})
+	v, ok := om4.Load(point{})
+	assert.True(t, ok)
+	assert.Nil(t, v)
+
+	om5 := orderedmap.New[bool, map[string]int]()
+	om5.Store(true, map[string]int{"a": 1})
+	om5.Store(false, map[string]int{})
+	binaryRoundTrip(t, om5)
+
+	om6 := orderedmap.New[string, time.Time]()
+	om6.Store("t", time.Date(2023, 4, 5, 6, 7, 8, 9, time.UTC))
+	binaryRoundTrip(t, om6)
+
+	om7 := orderedmap.New[uint8, []string]()
+	om7.Store(255, []string{"x", "y"})
+	om7.Store(0, []string{})
+	binaryRoundTrip(t, om7)
+}
+
+func TestMap_MarshalBinary_layout(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("b", 1)
+	om.Store("a", 300)
+	om.Store("c", 3)
+	om.Ldelete("c")
+
+	b, err := om.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, b, []byte{
+		1, 0, 2,
+		2, 0xa1, 'b', 1, 0x01,
+		2, 0xa1, 'a', 3, 0xcd, 0x01, 0x2c,
+	})
+
+	b, err = om.MarshalBinaryWithTombstones()
+	assert.Nil(t, err)
+	assert.Equal(t, b[15:], []byte{1, 2, 0xa1, 'c', 1, 0x03})
+}
+
+func TestMap_MarshalBinary_structFields(t *testing.T) {
+	type record struct {
+		Name   string
+		hidden int
+		At     time.Time
+		Next   *point
+	}
+
+	at := time.Date(2023, 4, 5, 6, 7, 8, 9, time.UTC)
+	om := orderedmap.New[string, record]()
+	om.Store("r", record{Name: "n", hidden: 1, At: at, Next: &point{X: 1}})
+	om2 := orderedmap.New[string, record]()
+	b, err := om.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Nil(t, om2.UnmarshalBinary(b))
+
+	r, _ := om2.Load("r")
+	assert.Equal(t, r.Name, "n")
+	assert.Equal(t, r.hidden, 0)
+	assert.True(t, r.At.Equal(at))
+	assert.Equal(t, *r.Next, point{X: 1})
+
+	om3 := orderedmap.New[string, any]()
+	om3.Store("p", point{X: 1, Y: 2})
+	b, err = om3.MarshalBinary()
+	assert.Nil(t, err)
+	om4 := orderedmap.New[string, any]()
+	assert.Nil(t, om4.UnmarshalBinary(b))
+	assert.Equal(t, om4.String(), "Map[p:[1 2]]")
+
+	om5 := orderedmap.New[string, chan int]()
+	om5.Store("ch", make(chan int))
+	_, err = om5.MarshalBinary()
+	assert.Equal(t, err.Error(), "binary: unsupported value type: chan int")
+}
+
+func TestMap_MarshalBinary_interfaceValues(t *testing.T) {
+	om := orderedmap.New[string, any]()
+	om.Store("s", "str")
+	om.Store("i", 12)
+	om.Store("f", 1.5)
+	om.Store("n", nil)
+	om.Store("b", true)
+	binaryRoundTrip(t, om)
+}
+
+func TestMap_MarshalBinary_nestedMaps(t *testing.T) {
+	inner := orderedmap.New[string, int]()
+	inner.Store("y", 1)
+	inner.Store("x", 2)
+
+	om := orderedmap.New[int, orderedmap.Map[string, int]]()
+	om.Store(2, inner)
+	om.Store(1, orderedmap.New[string, int]())
+	binaryRoundTrip(t, om)
+
+	omp := orderedmap.New[string, *orderedmap.Map[string, int]]()
+	omp.Store("p", &inner)
+	omp2 := binaryRoundTrip(t, omp)
+	p, _ := omp2.Load("p")
+	assert.Equal(t, p.Front().Key(), "y")
+}
+
+func TestMap_MarshalBinary_ldeletedEntriesAreExcluded(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+	om.Ldelete("b")
+
+	b, err := om.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, b[:2], []byte{1, 0})
+
+	om2 := orderedmap.New[string, int]()
+	assert.Nil(t, om2.UnmarshalBinary(b))
+	assert.Equal(t, om2.String(), "Map[a:1 c:3]")
+
+	om2.Store("b", 20)
+	assert.Equal(t, om2.String(), "Map[a:1 c:3 b:20]")
+}
+
+func TestMap_MarshalBinaryWithTombstones(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+	om.Ldelete("b")
+	om.LoadAndLdelete("a")
+
+	b, err := om.MarshalBinaryWithTombstones()
+	assert.Nil(t, err)
+	assert.Equal(t, b[:2], []byte{1, 1})
+
+	om2 := orderedmap.New[string, int]()
+	assert.Nil(t, om2.UnmarshalBinary(b))
+	assert.Equal(t, om2.String(), "Map[c:3]")
+	assert.Equal(t, om2.Len(), 1)
+
+	_, ok := om2.Load("b")
+	assert.False(t, ok)
+
+	b2, err := om2.MarshalBinaryWithTombstones()
+	assert.Nil(t, err)
+	om3 := orderedmap.New[string, int]()
+	assert.Nil(t, om3.UnmarshalBinary(b2))
+	assert.Equal(t, om3.String(), "Map[c:3]")
+
+	om2.Store("a", 10)
+	assert.Equal(t, om2.String(), "Map[c:3 a:10]")
+}
+
+func TestMap_MarshalBinaryWithTombstones_deterministic(t *testing.T) {
+	newMap := func() orderedmap.Map[int, string] {
+		om := orderedmap.New[int, string]()
+		for i := 0; i < 50; i++ {
+			om.Store(i, strconv.Itoa(i))
+		}
+		for i := 0; i < 50; i += 2 {
+			om.Ldelete(i)
+		}
+		return om
+	}
+
+	om := newMap()
+	b, err := om.MarshalBinaryWithTombstones()
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		b2, err := om.MarshalBinaryWithTombstones()
+		assert.Nil(t, err)
+		assert.Equal(t, b2, b)
+
+		b3, err := newMap().MarshalBinaryWithTombstones()
+		assert.Nil(t, err)
+		assert.Equal(t, b3, b)
+	}
+}
+
+func TestMap_UnmarshalBinary_replacesContent(t *testing.T) {
+	src := orderedmap.New[string, int]()
+	src.Store("x", 1)
+	b, err := src.MarshalBinary()
+	assert.Nil(t, err)
+
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	assert.Nil(t, om.UnmarshalBinary(b))
+	assert.Equal(t, om.String(), "Map[x:1]")
+
+	var zero orderedmap.Map[string, int]
+	assert.Nil(t, zero.UnmarshalBinary(b))
+	zero.Store("y", 2)
+	assert.Equal(t, zero.String(), "Map[x:1 y:2]")
+}
+
+func TestMap_UnmarshalBinary_errors(t *testing.T) {
+	om := orderedmap.New[string, int]()
+
+	err := om.UnmarshalBinary([]byte{1})
+	assert.Equal(t, err.Error(), "binary: unexpected end of data (offset:1)")
+	var e orderedmap.DecodeError
+	assert.True(t, errors.As(err, &e))
+
+	err = om.UnmarshalBinary([]byte{2, 0})
+	assert.Equal(t, err.Error(), "binary: unsupported version 2 (offset:0)")
+
+	err = om.UnmarshalBinary([]byte{1, 4})
+	assert.Equal(t, err.Error(), "binary: unsupported flags 4 (offset:1)")
+
+	err = om.UnmarshalBinary([]byte{1, 0, 0xff})
+	assert.Equal(t, err.Error(), "binary: unexpected end of data (offset:2)")
+
+	err = om.UnmarshalBinary([]byte{1, 0, 1, 2, 0xa1, 'a', 1, 0xc1})
+	assert.Equal(t, err.Error(), "binary: unsupported format code 0xc1 (offset:7)")
+
+	err = om.UnmarshalBinary([]byte{1, 0, 0, 0})
+	assert.Equal(t, err.Error(), "binary: invalid data after the entries (offset:3)")
+
+	src := orderedmap.New[string, string]()
+	src.Store("a", "b")
+	b, _ := src.MarshalBinary()
+	err = om.UnmarshalBinary(b)
+	assert.NotNil(t, err)
+}
+
+func TestMap_Gob(t *testing.T) {
+	type cache struct {
+		Name string
+		Data orderedmap.Map[string, int]
+		Ptr  *orderedmap.Map[int, string]
+	}
+
+	data := orderedmap.New[string, int]()
+	data.Store("z", 26)
+	data.Store("a", 1)
+	ptr := orderedmap.New[int, string]()
+	ptr.Store(2, "b")
+	ptr.Store(1, "a")
+
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(cache{Name: "c", Data: data, Ptr: &ptr})
+	assert.Nil(t, err)
+
+	var c cache
+	err = gob.NewDecoder(&buf).Decode(&c)
+	assert.Nil(t, err)
+	assert.Equal(t, c.Name, "c")
+	assert.Equal(t, c.Data.String(), "Map[z:26 a:1]")
+	assert.Equal(t, c.Ptr.String(), "Map[2:b 1:a]")
+}
+
+func TestMap_Gob_tombstones(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Ldelete("a")
+
+	var buf bytes.Buffer
+	assert.Nil(t, gob.NewEncoder(&buf).Encode(om))
+
+	om2 := orderedmap.New[string, int]()
+	assert.Nil(t, gob.NewDecoder(&buf).Decode(&om2))
+	assert.Equal(t, om2.String(), "Map[b:2]")
+
+	om2.Store("a", 10)
+	assert.Equal(t, om2.String(), "Map[b:2 a:10]")
+	om.Store("a", 10)
+	assert.Equal(t, om.String(), "Map[b:2 a:10]")
+}
+
+func TestMap_Gob_interfaceValue(t *testing.T) {
+	gob.Register(orderedmap.Map[string, any]{})
+
+	inner := orderedmap.New[string, any]()
+	inner.Store("k", "v")
+	om := orderedmap.New[string, any]()
+	om.Store("inner", inner)
+	om.Store("n", 1)
+
+	var buf bytes.Buffer
+	assert.Nil(t, gob.NewEncoder(&buf).Encode(om))
+
+	om2 := orderedmap.New[string, any]()
+	assert.Nil(t, gob.NewDecoder(&buf).Decode(&om2))
+	assert.Equal(t, om2.String(), "Map[inner:Map[k:v] n:1]")
+}
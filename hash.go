@@ -0,0 +1,100 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+var hashSeed = maphash.MakeSeed()
+
+// hashComparable is a function which computes a hash of a comparable value.
+// Values which are equal with == have a same hash, but values which are not
+// equal can have a same hash.
+func hashComparable[K comparable](key K) uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+
+	switch x := any(key).(type) {
+	case string:
+		h.WriteString(x)
+	case int:
+		writeHashUint(&h, uint64(x))
+	case int64:
+		writeHashUint(&h, uint64(x))
+	case uint64:
+		writeHashUint(&h, x)
+	default:
+		writeHashValue(&h, reflect.ValueOf(&key).Elem())
+	}
+	return h.Sum64()
+}
+
+// hashAny is a function which computes a hash of a value of which dynamic
+// type is comparable, in the same way as hashComparable.
+func hashAny(key any) uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+	writeHashValue(&h, reflect.ValueOf(&key).Elem())
+	return h.Sum64()
+}
+
+func writeHashUint(h *maphash.Hash, n uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], n)
+	h.Write(b[:])
+}
+
+func writeHashFloat(h *maphash.Hash, f float64) {
+	if f == 0 {
+		f = 0 // -0 == +0
+	}
+	writeHashUint(h, math.Float64bits(f))
+}
+
+func writeHashValue(h *maphash.Hash, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeHashUint(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		writeHashUint(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeHashFloat(h, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		writeHashFloat(h, real(c))
+		writeHashFloat(h, imag(c))
+	case reflect.String:
+		h.WriteString(v.String())
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		writeHashUint(h, uint64(v.Pointer()))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			writeHashValue(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			writeHashValue(h, v.Field(i))
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			h.WriteByte(0)
+			return
+		}
+		e := v.Elem()
+		h.WriteString(e.Type().String())
+		writeHashValue(h, e)
+	}
+}
@@ -0,0 +1,573 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/maphash"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// HashMap is a struct which represents an ordered map of which keys are
+// compared with a hash function and an equality function given by users
+// instead of Go's == operator, so that keys can be of types which are not
+// comparable, such as slices or structs with slice fields.
+//
+// This map has the methods of Map and preserves the order of key insertions
+// in the same way. (But not support concurrent use.)
+// A HashMap is a Map of which keys are pointers to interned keys: each key is
+// looked up in a table by the hash function and the equality function, and
+// the Map holds the pointer to the first equal key which was stored. So a
+// HashMap shares the entries, snapshots, transactions, observers and
+// encodings with Map.
+//
+// A key of a byte slice type is copied when it is inserted, so that the
+// caller can reuse the slice.
+// A HashMap has to be created by NewWithHasher.
+type HashMap[K any, V any] struct {
+	om   Map[*hashKey[K], V]
+	keys *hashKeys[K]
+}
+
+// HashEntry is a struct which is an element of a HashMap and holds a pair of
+// key and value.
+type HashEntry[K any, V any] Entry[*hashKey[K], V]
+
+// hashKey is an interned key of a HashMap with its hash.
+type hashKey[K any] struct {
+	key  K
+	hash uint64
+}
+
+// hashKeys is a table of the interned keys of a HashMap, which has a key for
+// each entry including logically deleted entries.
+// A table which is shared with snapshots or transactions is marked as shared,
+// and is copied by the first write after that, like the entries of a Map.
+type hashKeys[K any] struct {
+	buckets   map[uint64][]*hashKey[K]
+	hash      func(K) uint64
+	eq        func(a, b K) bool
+	copyBytes bool
+	shared    bool
+}
+
+// NewWithHasher is a function which creates a new HashMap, which is empty.
+// The hash function has to return a same hash for keys which are equal with
+// the eq function.
+func NewWithHasher[K any, V any](
+	hash func(K) uint64,
+	eq func(a, b K) bool,
+) HashMap[K, V] {
+	t := reflect.TypeOf((*K)(nil)).Elem()
+	return HashMap[K, V]{
+		om: New[*hashKey[K], V](),
+		keys: &hashKeys[K]{
+			buckets: make(map[uint64][]*hashKey[K]),
+			hash:    hash,
+			eq:      eq,
+			copyBytes: t.Kind() == reflect.Interface ||
+				(t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8),
+		},
+	}
+}
+
+// HashBytes is a hash function for keys of byte slice types, which can be
+// given to NewWithHasher with EqualBytes.
+func HashBytes[K ~[]byte](key K) uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+	h.Write(key)
+	return h.Sum64()
+}
+
+// EqualBytes is an equality function for keys of byte slice types, which
+// can be given to NewWithHasher with HashBytes.
+// A nil slice and an empty slice are equal.
+func EqualBytes[K ~[]byte](a, b K) bool {
+	return bytes.Equal(a, b)
+}
+
+// HashString is a hash function for keys of string types, which can be given
+// to NewWithHasher with EqualString.
+func HashString[K ~string](key K) uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+	h.WriteString(string(key))
+	return h.Sum64()
+}
+
+// EqualString is an equality function for keys of string types, which can be
+// given to NewWithHasher with HashString.
+func EqualString[K ~string](a, b K) bool {
+	return a == b
+}
+
+func (t *hashKeys[K]) find(key K) (box *hashKey[K], hash uint64) {
+	hash = t.hash(key)
+	for _, b := range t.buckets[hash] {
+		if t.eq(b.key, key) {
+			return b, hash
+		}
+	}
+	return nil, hash
+}
+
+// lookup is a method which returns the interned key which is equal to a key,
+// or nil if the key is not present.
+func (t *hashKeys[K]) lookup(key K) *hashKey[K] {
+	if t == nil {
+		return nil
+	}
+	box, _ := t.find(key)
+	return box
+}
+
+// intern is a method which returns the interned key which is equal to a key,
+// and adds a new one if the key is not present. If the key is a byte slice,
+// the new interned key has a copy of it.
+// This method returns the table which has the interned key, which is a copy
+// of this table if this table is shared.
+func (t *hashKeys[K]) intern(key K) (*hashKey[K], *hashKeys[K]) {
+	box, hash := t.find(key)
+	if box != nil {
+		return box, t
+	}
+
+	if t.copyBytes {
+		v := reflect.ValueOf(key)
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 &&
+			!v.IsNil() {
+			c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+			reflect.Copy(c, v)
+			key = c.Interface().(K)
+		}
+	}
+
+	t = t.own()
+	box = &hashKey[K]{key: key, hash: hash}
+	t.buckets[hash] = append(t.buckets[hash], box)
+	return box, t
+}
+
+// remove is a method which removes an interned key, and returns the table
+// without it, which is a copy of this table if this table is shared.
+func (t *hashKeys[K]) remove(box *hashKey[K]) *hashKeys[K] {
+	t = t.own()
+	bucket := t.buckets[box.hash]
+	for i, b := range bucket {
+		if b == box {
+			bucket[i] = bucket[len(bucket)-1]
+			bucket[len(bucket)-1] = nil
+			bucket = bucket[:len(bucket)-1]
+			break
+		}
+	}
+	if len(bucket) == 0 {
+		delete(t.buckets, box.hash)
+	} else {
+		t.buckets[box.hash] = bucket
+	}
+	return t
+}
+
+func (t *hashKeys[K]) own() *hashKeys[K] {
+	if !t.shared {
+		return t
+	}
+	c := t.empty()
+	for hash, bucket := range t.buckets {
+		c.buckets[hash] = append([]*hashKey[K](nil), bucket...)
+	}
+	return c
+}
+
+func (t *hashKeys[K]) empty() *hashKeys[K] {
+	return &hashKeys[K]{
+		buckets:   make(map[uint64][]*hashKey[K]),
+		hash:      t.hash,
+		eq:        t.eq,
+		copyBytes: t.copyBytes,
+	}
+}
+
+// release is a method which removes an interned key from the table if no
+// entry has it.
+func (hm *HashMap[K, V]) release(box *hashKey[K]) {
+	if _, exists := hm.om.m[box]; !exists {
+		hm.keys = hm.keys.remove(box)
+	}
+}
+
+func (hm *HashMap[K, V]) intern(key K) *hashKey[K] {
+	box, keys := hm.keys.intern(key)
+	hm.keys = keys
+	return box
+}
+
+// Len is a method which returns the number of entries in this map.
+func (hm *HashMap[K, V]) Len() int {
+	return hm.om.Len()
+}
+
+// Store is a method which sets a value for a key.
+func (hm *HashMap[K, V]) Store(key K, value V) {
+	hm.om.Store(hm.intern(key), value)
+}
+
+// Swap is a method which sets a value for a key. If the key was present, this
+// map returns the previous value and the loaded flag which is set to true.
+func (hm *HashMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	return hm.om.Swap(hm.intern(key), value)
+}
+
+// Load is a method which returns a value stored in this map for a key.
+// If no value was found for a key, the ok result is false.
+func (hm *HashMap[K, V]) Load(key K) (value V, ok bool) {
+	box := hm.keys.lookup(key)
+	if box == nil {
+		return
+	}
+	return hm.om.Load(box)
+}
+
+// LoadOrStore is a method which returns a value for a key if presents,
+// otherwise stores and returns a given value.
+// The loaded flag is true if the value was loaded, false if stored.
+func (hm *HashMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	return hm.om.LoadOrStore(hm.intern(key), value)
+}
+
+// LoadOrStoreFunc is a method which returns a value for a key if presents,
+// otherwise executes a give function, then stores and returns the result
+// value.
+// The loaded flag is true if the value was loaded, false if stored.
+func (hm *HashMap[K, V]) LoadOrStoreFunc(
+	key K,
+	fn func() (V, error),
+) (actual V, loaded bool, err error) {
+	box := hm.intern(key)
+	actual, loaded, err = hm.om.LoadOrStoreFunc(box, fn)
+	if err != nil {
+		hm.release(box)
+	}
+	return
+}
+
+// Delete is a method which deletes a value for a key.
+func (hm *HashMap[K, V]) Delete(key K) {
+	hm.LoadAndDelete(key)
+}
+
+// Ldelete is a method which logically deletes a value for a key.
+func (hm *HashMap[K, V]) Ldelete(key K) {
+	hm.LoadAndLdelete(key)
+}
+
+// LoadAndDelete is a method which deletes a value for a key, and returns the
+// previous value if any.
+// The loaded flag is true if the key was present.
+func (hm *HashMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	box := hm.keys.lookup(key)
+	if box == nil {
+		return
+	}
+	value, loaded = hm.om.LoadAndDelete(box)
+	hm.release(box)
+	return
+}
+
+// LoadAndLdelete is a method which logically deletes a value for a key, and
+// returns the previous value if any.
+// The loaded flag is true if the key was present.
+func (hm *HashMap[K, V]) LoadAndLdelete(key K) (value V, loaded bool) {
+	box := hm.keys.lookup(key)
+	if box == nil {
+		return
+	}
+	return hm.om.LoadAndLdelete(box)
+}
+
+// FrontAndDelete is a method which deletes the first entry and returns it.
+// If this map has no entry, this method returns nil
+func (hm *HashMap[K, V]) FrontAndDelete() *HashEntry[K, V] {
+	ent := hm.om.FrontAndDelete()
+	if ent != nil {
+		hm.release(ent.key)
+	}
+	return (*HashEntry[K, V])(ent)
+}
+
+// FrontAndLdelete is a method which logically deletes the first entry and
+// returns it.
+// If this map has no entry, this method returns nil
+func (hm *HashMap[K, V]) FrontAndLdelete() *HashEntry[K, V] {
+	return (*HashEntry[K, V])(hm.om.FrontAndLdelete())
+}
+
+// BackAndDelete is a method which deletes the last entry and returns it.
+// If this map has no entry, this method returns nil
+func (hm *HashMap[K, V]) BackAndDelete() *HashEntry[K, V] {
+	ent := hm.om.BackAndDelete()
+	if ent != nil {
+		hm.release(ent.key)
+	}
+	return (*HashEntry[K, V])(ent)
+}
+
+// BackAndLdelete is a method which logically deletes the last entry and
+// returns it.
+// If this map has no entry, this method returns nil
+func (hm *HashMap[K, V]) BackAndLdelete() *HashEntry[K, V] {
+	return (*HashEntry[K, V])(hm.om.BackAndLdelete())
+}
+
+// MoveToFront is a method which moves an entry for a key to the head of this
+// map.
+// If the key is not present, this method does nothing and returns false.
+func (hm *HashMap[K, V]) MoveToFront(key K) bool {
+	box := hm.keys.lookup(key)
+	return box != nil && hm.om.MoveToFront(box)
+}
+
+// MoveToBack is a method which moves an entry for a key to the end of this
+// map.
+// If the key is not present, this method does nothing and returns false.
+func (hm *HashMap[K, V]) MoveToBack(key K) bool {
+	box := hm.keys.lookup(key)
+	return box != nil && hm.om.MoveToBack(box)
+}
+
+// MoveBefore is a method which moves an entry for a key to the position just
+// before an entry for a mark key.
+// If either key is not present or both keys are same, this method does
+// nothing and returns false.
+func (hm *HashMap[K, V]) MoveBefore(key, mark K) bool {
+	box, markBox := hm.keys.lookup(key), hm.keys.lookup(mark)
+	return box != nil && markBox != nil && hm.om.MoveBefore(box, markBox)
+}
+
+// MoveAfter is a method which moves an entry for a key to the position just
+// after an entry for a mark key.
+// If either key is not present or both keys are same, this method does
+// nothing and returns false.
+func (hm *HashMap[K, V]) MoveAfter(key, mark K) bool {
+	box, markBox := hm.keys.lookup(key), hm.keys.lookup(mark)
+	return box != nil && markBox != nil && hm.om.MoveAfter(box, markBox)
+}
+
+// SortFunc is a method which sorts the entries of this map with the specified
+// function: less, which reports whether the entry a should be placed before
+// the entry b.
+// The sort is stable, so the entries which are equal keep their order.
+func (hm *HashMap[K, V]) SortFunc(less func(a, b *HashEntry[K, V]) bool) {
+	hm.om.SortFunc(func(a, b *Entry[*hashKey[K], V]) bool {
+		return less((*HashEntry[K, V])(a), (*HashEntry[K, V])(b))
+	})
+}
+
+// Clear is a method which deletes all entries of this map, including
+// logically deleted entries.
+func (hm *HashMap[K, V]) Clear() {
+	hm.om.Clear()
+	hm.keys = hm.keys.empty()
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in this map.
+// If fn returns false, this method stops the iteration.
+func (hm *HashMap[K, V]) Range(fn func(key K, value V) bool) {
+	hm.om.Range(func(box *hashKey[K], value V) bool {
+		return fn(box.key, value)
+	})
+}
+
+// Front is a method which returns the head entry of this map.
+func (hm *HashMap[K, V]) Front() *HashEntry[K, V] {
+	return (*HashEntry[K, V])(hm.om.Front())
+}
+
+// Back is a method which returns the last entry of this map.
+func (hm *HashMap[K, V]) Back() *HashEntry[K, V] {
+	return (*HashEntry[K, V])(hm.om.Back())
+}
+
+// String is a method which returns a string of the content of this map.
+func (hm HashMap[K, V]) String() string {
+	return hashMapString("HashMap[", hm.om.head)
+}
+
+func hashMapString[K any, V any](prefix string, ent *Entry[*hashKey[K], V]) string {
+	var buf strings.Builder
+	buf.WriteString(prefix)
+	for first := true; ent != nil; ent = ent.next {
+		if !first {
+			buf.WriteString(" ")
+		}
+		first = false
+		buf.WriteString(fmt.Sprintf("%v:%v", ent.key.key, ent.value))
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
+
+// Prev is a method which returns the previous entry of this entry.
+// If this entry is a head entry of a map, the returned value is nil.
+func (ent *HashEntry[K, V]) Prev() *HashEntry[K, V] {
+	return (*HashEntry[K, V])(ent.prev)
+}
+
+// Next is a method which returns the next entry of this entry.
+// If this entry is a last entry of a map, the returned value is nil.
+func (ent *HashEntry[K, V]) Next() *HashEntry[K, V] {
+	return (*HashEntry[K, V])(ent.next)
+}
+
+// Key is a method which returns the key of this entry.
+func (ent *HashEntry[K, V]) Key() K {
+	return ent.key.key
+}
+
+// Value is a method which returns the value of this entry.
+func (ent *HashEntry[K, V]) Value() V {
+	return ent.value
+}
+
+// MarshalJSON returns a byte array of JSON string which expresses the content
+// of this map.
+// A key is encoded as a JSON string if its type implements
+// encoding.TextMarshaler, or is a string, a byte slice, a boolean or a number
+// type. A byte slice is encoded as a base64 string like encoding/json does for
+// a byte slice value. Otherwise this method returns an UnsupportedKeyTypeError.
+func (hm HashMap[K, V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+
+	for ent := hm.om.head; ent != nil; ent = ent.next {
+		if ent != hm.om.head {
+			buf.WriteString(",")
+		}
+		text, err := encodeKeyText(ent.key.key)
+		if err != nil {
+			return nil, err
+		}
+		addJsonString(&buf, text)
+		buf.WriteString(":")
+		err = addJsonValue(&buf, ent.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+func encodeKeyText(key any) (string, error) {
+	if tm, ok := key.(encoding.TextMarshaler); ok {
+		bs, err := tm.MarshalText()
+		return string(bs), err
+	}
+
+	v := reflect.ValueOf(key)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return "", UnsupportedKeyTypeError{Type: reflect.TypeOf(key)}
+}
+
+func decodeKeyText(text string, key any) error {
+	if tu, ok := key.(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(text))
+	}
+
+	v := reflect.ValueOf(key).Elem()
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(text)
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			bs, err := base64.StdEncoding.DecodeString(text)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(bs)
+			return nil
+		}
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return json.Unmarshal([]byte(text), key)
+	}
+	return UnsupportedKeyTypeError{Type: v.Type()}
+}
+
+// UnmarshalJSON sets the content of this map from a JSON data.
+// A key is decoded from a JSON string in the same way as MarshalJSON.
+func (hm *HashMap[K, V]) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return SyntaxError{
+			Offset: 0,
+			msg:    "The input JSON does not start with '{'",
+		}
+	}
+
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		var key K
+		err = decodeKeyText(tok.(string), &key)
+		if err != nil {
+			return err
+		}
+		var val V
+		err = dec.Decode(&val)
+		if err != nil {
+			return err
+		}
+		hm.Store(key, val)
+	}
+
+	tok, err = dec.Token()
+	if err != nil || tok != json.Delim('}') {
+		return SyntaxError{
+			Offset: dec.InputOffset(),
+			msg:    "The input JSON does not end with '}'",
+		}
+	}
+	return nil
+}
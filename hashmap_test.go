@@ -0,0 +1,604 @@
+package orderedmap_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func newBytesMap() orderedmap.HashMap[[]byte, int] {
+	return orderedmap.NewWithHasher[[]byte, int](
+		orderedmap.HashBytes[[]byte], orderedmap.EqualBytes[[]byte])
+}
+
+func hashKeysOf[V any](hm *orderedmap.HashMap[[]byte, V]) string {
+	var fwd, bwd []string
+	for ent := hm.Front(); ent != nil; ent = ent.Next() {
+		fwd = append(fwd, string(ent.Key()))
+	}
+	for ent := hm.Back(); ent != nil; ent = ent.Prev() {
+		bwd = append(bwd, string(ent.Key()))
+	}
+	return strings.Join(fwd, "") + "/" + strings.Join(bwd, "")
+}
+
+func TestNewWithHasher(t *testing.T) {
+	hm := newBytesMap()
+	assert.Equal(t, hm.Len(), 0)
+	assert.Nil(t, hm.Front())
+	assert.Nil(t, hm.Back())
+	assert.Equal(t, hm.String(), "HashMap[]")
+}
+
+func TestHashMap_storeAndLoad(t *testing.T) {
+	hm := newBytesMap()
+	hm.Store([]byte("a"), 1)
+	hm.Store([]byte("b"), 2)
+	hm.Store([]byte("c"), 3)
+	hm.Store([]byte("a"), 10)
+
+	assert.Equal(t, hm.Len(), 3)
+	assert.Equal(t, hashKeysOf(&hm), "abc/cba")
+
+	v, ok := hm.Load([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, v, 10)
+	v, ok = hm.Load([]byte("x"))
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+
+	prev, loaded := hm.Swap([]byte("b"), 20)
+	assert.True(t, loaded)
+	assert.Equal(t, prev, 2)
+	prev, loaded = hm.Swap([]byte("d"), 4)
+	assert.False(t, loaded)
+	assert.Equal(t, prev, 0)
+
+	actual, loaded := hm.LoadOrStore([]byte("d"), 40)
+	assert.True(t, loaded)
+	assert.Equal(t, actual, 4)
+	actual, loaded = hm.LoadOrStore([]byte("e"), 5)
+	assert.False(t, loaded)
+	assert.Equal(t, actual, 5)
+
+	actual, loaded, err := hm.LoadOrStoreFunc([]byte("f"), func() (int, error) {
+		return 0, errors.New("fail")
+	})
+	assert.Equal(t, err.Error(), "fail")
+	assert.False(t, loaded)
+	assert.Equal(t, actual, 0)
+	actual, loaded, err = hm.LoadOrStoreFunc([]byte("f"), func() (int, error) {
+		return 6, nil
+	})
+	assert.Nil(t, err)
+	assert.False(t, loaded)
+	assert.Equal(t, actual, 6)
+
+	assert.Equal(t, hashKeysOf(&hm), "abcdef/fedcba")
+}
+
+func TestHashMap_delete(t *testing.T) {
+	hm := newBytesMap()
+	for i, k := range []string{"a", "b", "c", "d", "e"} {
+		hm.Store([]byte(k), i+1)
+	}
+
+	hm.Delete([]byte("c"))
+	hm.Delete([]byte("x"))
+	assert.Equal(t, hashKeysOf(&hm), "abde/edba")
+	assert.Equal(t, hm.Len(), 4)
+
+	hm.Ldelete([]byte("a"))
+	assert.Equal(t, hashKeysOf(&hm), "bde/edb")
+	v, ok := hm.Load([]byte("a"))
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+
+	hm.Store([]byte("a"), 7)
+	assert.Equal(t, hashKeysOf(&hm), "bdea/aedb")
+
+	v, loaded := hm.LoadAndLdelete([]byte("d"))
+	assert.True(t, loaded)
+	assert.Equal(t, v, 4)
+	v, loaded = hm.LoadAndLdelete([]byte("d"))
+	assert.False(t, loaded)
+	v, loaded = hm.LoadAndDelete([]byte("d"))
+	assert.False(t, loaded)
+	v, loaded = hm.LoadAndDelete([]byte("b"))
+	assert.True(t, loaded)
+	assert.Equal(t, v, 2)
+	assert.Equal(t, hashKeysOf(&hm), "ea/ae")
+	assert.Equal(t, hm.Len(), 2)
+}
+
+func TestHashMap_frontAndBackDelete(t *testing.T) {
+	hm := newBytesMap()
+	assert.Nil(t, hm.FrontAndDelete())
+	assert.Nil(t, hm.BackAndLdelete())
+	for i, k := range []string{"a", "b", "c", "d"} {
+		hm.Store([]byte(k), i+1)
+	}
+
+	ent := hm.FrontAndDelete()
+	assert.Equal(t, string(ent.Key()), "a")
+	assert.Equal(t, ent.Value(), 1)
+	ent = hm.BackAndDelete()
+	assert.Equal(t, string(ent.Key()), "d")
+	ent = hm.FrontAndLdelete()
+	assert.Equal(t, string(ent.Key()), "b")
+	ent = hm.BackAndLdelete()
+	assert.Equal(t, string(ent.Key()), "c")
+	assert.Equal(t, hm.Len(), 0)
+	assert.Equal(t, hashKeysOf(&hm), "/")
+
+	hm.Store([]byte("c"), 30)
+	hm.Store([]byte("a"), 10)
+	assert.Equal(t, hm.String(), "HashMap[[99]:30 [97]:10]")
+}
+
+func TestHashMap_move(t *testing.T) {
+	hm := newBytesMap()
+	for i, k := range []string{"a", "b", "c", "d"} {
+		hm.Store([]byte(k), i+1)
+	}
+
+	assert.True(t, hm.MoveToFront([]byte("c")))
+	assert.Equal(t, hashKeysOf(&hm), "cabd/dbac")
+	assert.True(t, hm.MoveToBack([]byte("a")))
+	assert.Equal(t, hashKeysOf(&hm), "cbda/adbc")
+	assert.True(t, hm.MoveBefore([]byte("a"), []byte("c")))
+	assert.Equal(t, hashKeysOf(&hm), "acbd/dbca")
+	assert.True(t, hm.MoveAfter([]byte("a"), []byte("d")))
+	assert.Equal(t, hashKeysOf(&hm), "cbda/adbc")
+	assert.True(t, hm.MoveAfter([]byte("a"), []byte("d")))
+	assert.Equal(t, hashKeysOf(&hm), "cbda/adbc")
+
+	assert.False(t, hm.MoveToFront([]byte("x")))
+	assert.False(t, hm.MoveBefore([]byte("a"), []byte("a")))
+	assert.False(t, hm.MoveAfter([]byte("a"), []byte("x")))
+	hm.Ldelete([]byte("b"))
+	assert.False(t, hm.MoveToBack([]byte("b")))
+	assert.False(t, hm.MoveBefore([]byte("a"), []byte("b")))
+	assert.Equal(t, hashKeysOf(&hm), "cda/adc")
+}
+
+func TestHashMap_SortFuncAndClear(t *testing.T) {
+	hm := newBytesMap()
+	for i, k := range []string{"d", "b", "a", "c"} {
+		hm.Store([]byte(k), i)
+	}
+	hm.SortFunc(func(a, b *orderedmap.HashEntry[[]byte, int]) bool {
+		return string(a.Key()) < string(b.Key())
+	})
+	assert.Equal(t, hashKeysOf(&hm), "abcd/dcba")
+
+	var keys []string
+	hm.Range(func(k []byte, v int) bool {
+		keys = append(keys, string(k))
+		return len(keys) < 2
+	})
+	assert.Equal(t, keys, []string{"a", "b"})
+
+	hm.Ldelete([]byte("a"))
+	hm.Clear()
+	assert.Equal(t, hm.Len(), 0)
+	assert.Equal(t, hashKeysOf(&hm), "/")
+	hm.Store([]byte("a"), 1)
+	assert.Equal(t, hashKeysOf(&hm), "a/a")
+}
+
+type compositeKey struct {
+	Name string
+	Tags []string
+}
+
+func TestHashMap_compositeKey(t *testing.T) {
+	hash := func(k compositeKey) uint64 {
+		h := fnv.New64a()
+		h.Write([]byte(k.Name))
+		for _, tag := range k.Tags {
+			h.Write([]byte{0})
+			h.Write([]byte(tag))
+		}
+		return h.Sum64()
+	}
+	eq := func(a, b compositeKey) bool {
+		if a.Name != b.Name || len(a.Tags) != len(b.Tags) {
+			return false
+		}
+		for i := range a.Tags {
+			if a.Tags[i] != b.Tags[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	hm := orderedmap.NewWithHasher[compositeKey, string](hash, eq)
+	hm.Store(compositeKey{"x", []string{"a", "b"}}, "first")
+	hm.Store(compositeKey{"x", []string{"a"}}, "second")
+	hm.Store(compositeKey{"x", []string{"a", "b"}}, "third")
+
+	assert.Equal(t, hm.Len(), 2)
+	v, ok := hm.Load(compositeKey{"x", []string{"a", "b"}})
+	assert.True(t, ok)
+	assert.Equal(t, v, "third")
+	assert.Equal(t, hm.String(), "HashMap[{x [a b]}:third {x [a]}:second]")
+
+	_, err := hm.MarshalJSON()
+	assert.Equal(t, err.Error(),
+		"json: unsupported key type: orderedmap_test.compositeKey")
+}
+
+func TestHashMap_collision(t *testing.T) {
+	hm := orderedmap.NewWithHasher[string, int](
+		func(string) uint64 { return 0 }, orderedmap.EqualString[string])
+	for i, k := range []string{"a", "b", "c"} {
+		hm.Store(k, i+1)
+	}
+	hm.Delete("b")
+	hm.Store("d", 4)
+	assert.Equal(t, hm.String(), "HashMap[a:1 c:3 d:4]")
+	v, ok := hm.Load("c")
+	assert.True(t, ok)
+	assert.Equal(t, v, 3)
+}
+
+func TestHashString(t *testing.T) {
+	type name string
+	assert.Equal(t, orderedmap.HashString(name("a")), orderedmap.HashString(name("a")))
+	assert.NotEqual(t, orderedmap.HashString(name("a")), orderedmap.HashString(name("b")))
+	assert.Equal(t, orderedmap.HashBytes([]byte("a")), orderedmap.HashString("a"))
+	assert.Equal(t, orderedmap.HashBytes([]byte(nil)), orderedmap.HashBytes([]byte{}))
+	assert.True(t, orderedmap.EqualBytes([]byte(nil), []byte{}))
+}
+
+func TestHashMap_MarshalJSON(t *testing.T) {
+	hm := newBytesMap()
+	hm.Store([]byte("b"), 1)
+	hm.Store([]byte(`a"x`), 2)
+	bs, err := hm.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), `{"Yg==":1,"YSJ4":2}`)
+
+	hm2 := orderedmap.NewWithHasher[float64, []int](
+		func(f float64) uint64 { return uint64(f) },
+		func(a, b float64) bool { return a == b })
+	hm2.Store(2.5, []int{1})
+	hm2.Store(-1, nil)
+	bs, err = hm2.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), `{"2.5":[1],"-1":null}`)
+}
+
+func TestHashMap_UnmarshalJSON(t *testing.T) {
+	hm := newBytesMap()
+	err := hm.UnmarshalJSON([]byte(`{"Yw==":3, "YQ==":1, "Yg==":2, "YQ==":10}`))
+	assert.Nil(t, err)
+	assert.Equal(t, hashKeysOf(&hm), "cab/bac")
+	v, _ := hm.Load([]byte("a"))
+	assert.Equal(t, v, 10)
+
+	hm = newBytesMap()
+	assert.Nil(t, hm.UnmarshalJSON([]byte(``)))
+	assert.Equal(t, hm.Len(), 0)
+
+	err = hm.UnmarshalJSON([]byte(`[1]`))
+	assert.Equal(t, err.Error(), "The input JSON does not start with '{' (offset:0)")
+	err = hm.UnmarshalJSON([]byte(`{"YQ==":"x"}`))
+	assert.NotNil(t, err)
+	err = hm.UnmarshalJSON([]byte(`{"a":1}`))
+	assert.NotNil(t, err)
+
+	hm3 := orderedmap.NewWithHasher[int, string](
+		func(n int) uint64 { return uint64(n) },
+		func(a, b int) bool { return a == b })
+	err = hm3.UnmarshalJSON([]byte(`{"2":"b","1":"a"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, hm3.String(), "HashMap[2:b 1:a]")
+	err = hm3.UnmarshalJSON([]byte(`{"x":"c"}`))
+	assert.NotNil(t, err)
+
+	hm4 := orderedmap.NewWithHasher[compositeKey, int](
+		func(compositeKey) uint64 { return 0 },
+		func(a, b compositeKey) bool { return a.Name == b.Name })
+	err = hm4.UnmarshalJSON([]byte(`{"x":1}`))
+	assert.Equal(t, err.Error(),
+		"json: unsupported key type: orderedmap_test.compositeKey")
+}
+
+func TestHashMap_jsonRoundTrip(t *testing.T) {
+	hm := newBytesMap()
+	for i := 0; i < 10; i++ {
+		hm.Store([]byte(fmt.Sprintf("k%d", 9-i)), i)
+	}
+	hm.Store([]byte{0xff, 0xfe, '"'}, 10)
+	bs, err := hm.MarshalJSON()
+	assert.Nil(t, err)
+
+	hm2 := newBytesMap()
+	assert.Nil(t, hm2.UnmarshalJSON(bs))
+	assert.Equal(t, hm2.String(), hm.String())
+}
+
+func TestHashMap_bytesKeyIsCopied(t *testing.T) {
+	hm := newBytesMap()
+	key := []byte("a")
+	hm.Store(key, 1)
+	key[0] = 'b'
+
+	v, ok := hm.Load([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	_, ok = hm.Load(key)
+	assert.False(t, ok)
+	assert.Equal(t, hashKeysOf(&hm), "a/a")
+
+	hm.Delete([]byte("a"))
+	assert.Equal(t, hm.Len(), 0)
+	_, ok = hm.Load([]byte("a"))
+	assert.False(t, ok)
+
+	hm2 := orderedmap.NewWithHasher[any, int](
+		func(k any) uint64 { return orderedmap.HashBytes(k.([]byte)) },
+		func(a, b any) bool { return orderedmap.EqualBytes(a.([]byte), b.([]byte)) })
+	key = []byte("x")
+	hm2.Store(key, 1)
+	key[0] = 'y'
+	v, ok = hm2.Load([]byte("x"))
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+}
+
+func newStringHashMap() orderedmap.HashMap[string, int] {
+	return orderedmap.NewWithHasher[string, int](
+		orderedmap.HashString[string], orderedmap.EqualString[string])
+}
+
+func TestHashMap_MarshalJSON_keyIsNotHTMLEscaped(t *testing.T) {
+	hm := newStringHashMap()
+	hm.Store("<a&b>", 1)
+	hm.Store("\"\n", 2)
+	bs, err := hm.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), `{"<a&b>":1,"\"\n":2}`)
+
+	om := orderedmap.New[string, int]()
+	om.Store("<a&b>", 1)
+	om.Store("\"\n", 2)
+	bs2, err := om.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), string(bs2))
+}
+
+func TestHashMap_snapshot(t *testing.T) {
+	hm := newBytesMap()
+	hm.Store([]byte("a"), 1)
+	hm.Store([]byte("b"), 2)
+	hm.Ldelete([]byte("b"))
+	s := hm.Snapshot()
+
+	hm.Delete([]byte("a"))
+	hm.Store([]byte("c"), 3)
+	hm.Store([]byte("a"), 10)
+	hm.Store([]byte("b"), 20)
+
+	assert.Equal(t, s.String(), "HashSnapshot[[97]:1]")
+	assert.Equal(t, s.Len(), 1)
+	v, ok := s.Load([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	_, ok = s.Load([]byte("b"))
+	assert.False(t, ok)
+	_, ok = s.Load([]byte("c"))
+	assert.False(t, ok)
+	assert.Equal(t, string(s.Front().Key()), "a")
+	assert.Equal(t, string(s.Back().Key()), "a")
+
+	assert.Equal(t, hashKeysOf(&hm), "cab/bac")
+
+	var keys []string
+	hm.SafeRange(func(k []byte, v int) bool {
+		keys = append(keys, string(k))
+		hm.Delete(k)
+		return true
+	})
+	assert.Equal(t, keys, []string{"c", "a", "b"})
+	assert.Equal(t, hm.Len(), 0)
+}
+
+func TestHashMap_Begin(t *testing.T) {
+	hm := newBytesMap()
+	hm.Store([]byte("a"), 1)
+	hm.Store([]byte("b"), 2)
+
+	tx := hm.Begin()
+	tx.Store([]byte("c"), 3)
+	tx.Delete([]byte("a"))
+	tx.MoveToFront([]byte("c"))
+	v, ok := tx.Load([]byte("c"))
+	assert.True(t, ok)
+	assert.Equal(t, v, 3)
+	_, ok = hm.Load([]byte("c"))
+	assert.False(t, ok)
+	assert.Equal(t, tx.Len(), 2)
+	assert.Equal(t, string(tx.Front().Key()), "c")
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, hashKeysOf(&hm), "cb/bc")
+	_, ok = hm.Load([]byte("a"))
+	assert.False(t, ok)
+	assert.Equal(t, tx.Commit(), orderedmap.TxDoneError{})
+
+	tx = hm.Begin()
+	tx.Store([]byte("d"), 4)
+	tx.Delete([]byte("b"))
+	assert.Nil(t, tx.Rollback())
+	assert.Equal(t, hashKeysOf(&hm), "cb/bc")
+	_, ok = hm.Load([]byte("d"))
+	assert.False(t, ok)
+
+	tx = hm.Begin()
+	tx.Store([]byte("d"), 4)
+	hm.Store([]byte("e"), 5)
+	assert.Equal(t, tx.Commit(), orderedmap.TxConflictError{})
+	assert.Equal(t, hashKeysOf(&hm), "cbe/ebc")
+	_, ok = hm.Load([]byte("d"))
+	assert.False(t, ok)
+}
+
+func TestHashMap_Observe(t *testing.T) {
+	hm := newStringHashMap()
+	var events []string
+	unsubscribe := hm.Observe(func(ev orderedmap.Event[string, int]) {
+		events = append(events, fmt.Sprintf("%v %s %d->%d @%d<-%d",
+			ev.Type, ev.Key, ev.OldValue, ev.NewValue, ev.Position, ev.OldPosition))
+	})
+	var batches int
+	hm.ObserveBatch(func(evs []orderedmap.Event[string, int]) {
+		batches++
+	})
+
+	hm.Store("a", 1)
+	hm.Batch(func() {
+		hm.Store("b", 2)
+		hm.Store("a", 10)
+	})
+	hm.MoveToBack("a")
+	hm.Delete("b")
+	hm.Clear()
+
+	assert.Equal(t, events, []string{
+		"insert a 0->1 @0<--1",
+		"insert b 0->2 @1<--1",
+		"update a 1->10 @0<--1",
+		"move a 10->10 @1<-0",
+		"delete b 2->0 @0<--1",
+		"clear  0->0 @-1<--1",
+	})
+	assert.Equal(t, batches, 5)
+
+	unsubscribe()
+	hm.Store("c", 3)
+	assert.Equal(t, len(events), 6)
+}
+
+func TestHashMap_computeAndMerge(t *testing.T) {
+	hm := newBytesMap()
+
+	v, ok := hm.Compute([]byte("a"), func(v int, loaded bool) (int, bool) {
+		return v + 1, true
+	})
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	v, ok = hm.Compute([]byte("x"), func(v int, loaded bool) (int, bool) {
+		return 0, false
+	})
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+
+	v, loaded, ok := hm.ComputeIfAbsent([]byte("b"), func(k []byte) (int, bool) {
+		return len(k) + 1, true
+	})
+	assert.Equal(t, []any{v, loaded, ok}, []any{2, false, true})
+	v, loaded, ok = hm.ComputeIfAbsent([]byte("b"), func(k []byte) (int, bool) {
+		return 0, true
+	})
+	assert.Equal(t, []any{v, loaded, ok}, []any{2, true, true})
+
+	v, ok = hm.ComputeIfPresent([]byte("a"), func(v int) (int, bool) {
+		return 0, false
+	})
+	assert.False(t, ok)
+	v, ok = hm.ComputeIfPresent([]byte("x"), func(v int) (int, bool) {
+		return 1, true
+	})
+	assert.False(t, ok)
+
+	v, ok = hm.Merge([]byte("b"), 3, func(old, v int) (int, bool) {
+		return old + v, true
+	})
+	assert.True(t, ok)
+	assert.Equal(t, v, 5)
+	v, ok = hm.Merge([]byte("c"), 3, func(old, v int) (int, bool) {
+		return 0, false
+	})
+	assert.True(t, ok)
+	assert.Equal(t, v, 3)
+	assert.Equal(t, hashKeysOf(&hm), "bc/cb")
+}
+
+func TestHashMap_bulk(t *testing.T) {
+	hm := newStringHashMap()
+	hm.StorePairs(
+		orderedmap.Pair[string, int]{First: "a", Second: 1},
+		orderedmap.Pair[string, int]{First: "b", Second: 2},
+	)
+	om := orderedmap.New[string, int]()
+	om.Store("c", 3)
+	om.Store("d", 4)
+	om.Store("e", 5)
+	om.Store("f", 6)
+	hm.StoreAll(om.Range)
+	assert.Equal(t, hm.String(), "HashMap[a:1 b:2 c:3 d:4 e:5 f:6]")
+
+	assert.Equal(t, hm.DeleteAll("a", "x", "b"), 2)
+	assert.Equal(t, hm.DeleteIf(func(k string, v int) bool { return v == 3 }), 1)
+	assert.Equal(t, hm.LdeleteIf(func(k string, v int) bool { return v == 4 }), 1)
+	assert.Equal(t, hm.RetainIf(func(k string, v int) bool { return v == 5 }), 1)
+	assert.Equal(t, hm.String(), "HashMap[e:5]")
+
+	hm.Store("c", 30)
+	hm.Store("d", 40)
+	assert.Equal(t, hm.String(), "HashMap[e:5 c:30 d:40]")
+}
+
+func TestHashMap_binaryEncodings(t *testing.T) {
+	hm := newBytesMap()
+	hm.Store([]byte("b"), 1)
+	hm.Store([]byte("a"), 2)
+	hm.Store([]byte("c"), 3)
+	hm.Ldelete([]byte("c"))
+
+	b, err := hm.MarshalMsgpack()
+	assert.Nil(t, err)
+	assert.Equal(t, b, []byte{0x82, 0xc4, 0x01, 'b', 0x01, 0xc4, 0x01, 'a', 0x02})
+	hm2 := newBytesMap()
+	assert.Nil(t, hm2.UnmarshalMsgpack(b))
+	assert.Equal(t, hashKeysOf(&hm2), "ba/ab")
+
+	b, err = hm.MarshalCBOR()
+	assert.Nil(t, err)
+	assert.Equal(t, b, []byte{0xa2, 0x41, 'b', 0x01, 0x41, 'a', 0x02})
+	hm2 = newBytesMap()
+	assert.Nil(t, hm2.UnmarshalCBOR(b))
+	assert.Equal(t, hashKeysOf(&hm2), "ba/ab")
+
+	b, err = hm.MarshalBinaryWithTombstones()
+	assert.Nil(t, err)
+	hm2 = newBytesMap()
+	hm2.Store([]byte("x"), 0)
+	assert.Nil(t, hm2.UnmarshalBinary(b))
+	assert.Equal(t, hashKeysOf(&hm2), "ba/ab")
+	_, ok := hm2.Load([]byte("x"))
+	assert.False(t, ok)
+	hm2.Store([]byte("c"), 30)
+	assert.Equal(t, hashKeysOf(&hm2), "bac/cab")
+
+	var buf bytes.Buffer
+	assert.Nil(t, gob.NewEncoder(&buf).Encode(hm))
+	hm3 := newBytesMap()
+	assert.Nil(t, gob.NewDecoder(&buf).Decode(&hm3))
+	assert.Equal(t, hm3.String(), hm.String())
+
+	var zero orderedmap.HashMap[[]byte, int]
+	err = zero.UnmarshalBinary(b)
+	assert.Equal(t, err.Error(),
+		"binary: cannot decode map into Go value of type orderedmap.HashMap[[]uint8,int]")
+}
@@ -0,0 +1,579 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+// History is a struct which wraps a Map and records mutations through it, so
+// that they can be undone and redone.
+// Each undo restores both the values and the positions of entries exactly,
+// including logically deleted entries.
+//
+// Mutations of the wrapped map which are not done through this History are
+// not recorded, and undoing earlier mutations after them can lead to an
+// unexpected result.
+type History[K comparable, V any] struct {
+	om    *Map[K, V]
+	undo  [][]historyOp
+	redo  [][]historyOp
+	group []historyOp
+	depth int
+	limit int
+}
+
+type historyOp struct {
+	undo func()
+	redo func()
+}
+
+// NewHistory is a function which creates a new History which records
+// mutations of the specified map.
+// The limit is the maximum number of steps which can be undone. If the limit
+// is zero or negative, the number of steps is not limited.
+func NewHistory[K comparable, V any](om *Map[K, V], limit int) *History[K, V] {
+	return &History[K, V]{om: om, limit: limit}
+}
+
+// Map is a method which returns the map wrapped by this History.
+// The returned map is intended for reading. Mutations through it are not
+// recorded, like other mutations not done through this History.
+func (h *History[K, V]) Map() *Map[K, V] {
+	return h.om
+}
+
+// BeginGroup is a method which starts grouping of mutations, so that all
+// mutations until the corresponding EndGroup are undone and redone as one
+// step.
+// Groups can be nested, and the outermost group makes a step.
+func (h *History[K, V]) BeginGroup() {
+	h.depth++
+}
+
+// EndGroup is a method which ends grouping of mutations started by
+// BeginGroup.
+func (h *History[K, V]) EndGroup() {
+	if h.depth == 0 {
+		return
+	}
+	h.depth--
+	if h.depth == 0 && len(h.group) > 0 {
+		h.push(h.group)
+		h.redo = nil
+		h.group = nil
+	}
+}
+
+// CanUndo is a method which reports whether there is a step to be undone.
+func (h *History[K, V]) CanUndo() bool {
+	return h.depth == 0 && len(h.undo) > 0
+}
+
+// CanRedo is a method which reports whether there is a step to be redone.
+func (h *History[K, V]) CanRedo() bool {
+	return h.depth == 0 && len(h.redo) > 0
+}
+
+// Undo is a method which reverts the last step of mutations.
+// If there is no step to be undone or a group is being recorded, this method
+// does nothing and returns false.
+func (h *History[K, V]) Undo() bool {
+	if !h.CanUndo() {
+		return false
+	}
+	n := len(h.undo) - 1
+	step := h.undo[n]
+	h.undo = h.undo[:n]
+
+	for i := len(step) - 1; i >= 0; i-- {
+		step[i].undo()
+	}
+	h.redo = append(h.redo, step)
+	return true
+}
+
+// Redo is a method which applies the last undone step of mutations again.
+// If there is no step to be redone or a group is being recorded, this method
+// does nothing and returns false.
+func (h *History[K, V]) Redo() bool {
+	if !h.CanRedo() {
+		return false
+	}
+	n := len(h.redo) - 1
+	step := h.redo[n]
+	h.redo = h.redo[:n]
+
+	for _, op := range step {
+		op.redo()
+	}
+	h.push(step)
+	return true
+}
+
+// Reset is a method which discards all recorded steps.
+// The content of the wrapped map is not changed.
+func (h *History[K, V]) Reset() {
+	h.undo = nil
+	h.redo = nil
+	h.group = nil
+	h.depth = 0
+}
+
+func (h *History[K, V]) push(step []historyOp) {
+	h.undo = append(h.undo, step)
+	if h.limit > 0 && len(h.undo) > h.limit {
+		n := len(h.undo) - h.limit
+		copy(h.undo, h.undo[n:])
+		for i := len(h.undo) - n; i < len(h.undo); i++ {
+			h.undo[i] = nil
+		}
+		h.undo = h.undo[:h.limit]
+	}
+}
+
+func (h *History[K, V]) record(undo, redo func()) {
+	op := historyOp{undo: undo, redo: redo}
+	if h.depth > 0 {
+		h.group = append(h.group, op)
+		return
+	}
+	h.push([]historyOp{op})
+	h.redo = nil
+}
+
+// position is a method which returns a function which moves an entry for a
+// key back to the current position, next to the current previous entry.
+func (h *History[K, V]) position(key K) func() {
+	om := h.om
+	ent := om.m[key]
+	if ent.prev == nil {
+		return func() { om.MoveToFront(key) }
+	}
+	prev := ent.prev.key
+	return func() { om.MoveAfter(key, prev) }
+}
+
+// recordStore is a method which records the inverse of storing a value for a
+// key.
+func (h *History[K, V]) recordStore(key K, value V) {
+	om := h.om
+	ent, exists := om.m[key]
+	redo := func() { om.Store(key, value) }
+
+	switch {
+	case !exists:
+		h.record(func() { om.Delete(key) }, redo)
+	case ent.deleted:
+		old := ent.value
+		h.record(func() { om.Store(key, old); om.Ldelete(key) }, redo)
+	default:
+		old := ent.value
+		h.record(func() { om.Store(key, old) }, redo)
+	}
+}
+
+// recordDelete is a method which records the inverse of deleting or logically
+// deleting an entry for a key.
+func (h *History[K, V]) recordDelete(key K, logical bool) {
+	om := h.om
+	ent, exists := om.m[key]
+	if !exists || (ent.deleted && logical) {
+		return
+	}
+
+	old := ent.value
+	redo := func() { om.Delete(key) }
+	if logical {
+		redo = func() { om.Ldelete(key) }
+	}
+
+	if ent.deleted {
+		h.record(func() { om.Store(key, old); om.Ldelete(key) }, redo)
+		return
+	}
+
+	restore := h.position(key)
+	h.record(func() { om.Store(key, old); restore() }, redo)
+}
+
+// recordMove is a method which records the inverse of moving an entry for a
+// key, and moves it with the specified function.
+func (h *History[K, V]) recordMove(key K, move func() bool) bool {
+	ent, exists := h.om.m[key]
+	if !exists || ent.deleted {
+		return false
+	}
+	prev := ent.prev
+	restore := h.position(key)
+	if !move() {
+		return false
+	}
+	if h.samePrev(key, prev) {
+		return true
+	}
+	h.record(restore, func() { move() })
+	return true
+}
+
+// samePrev is a method which reports whether the previous entry of an entry
+// for a key has the same key with the specified entry, because entries can be
+// copied when they are shared with snapshots.
+func (h *History[K, V]) samePrev(key K, prev *Entry[K, V]) bool {
+	cur := h.om.m[key].prev
+	if cur == nil || prev == nil {
+		return cur == prev
+	}
+	return cur.key == prev.key
+}
+
+// Store is a method which sets a value for a key like Map.Store, and records
+// it.
+func (h *History[K, V]) Store(key K, value V) {
+	h.recordStore(key, value)
+	h.om.Store(key, value)
+}
+
+// Swap is a method which sets a value for a key and returns the previous
+// value like Map.Swap, and records it.
+func (h *History[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	h.recordStore(key, value)
+	return h.om.Swap(key, value)
+}
+
+// LoadOrStore is a method which returns the value for a key if present,
+// otherwise stores and returns the specified value like Map.LoadOrStore, and
+// records it.
+func (h *History[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if _, ok := h.om.Load(key); !ok {
+		h.recordStore(key, value)
+	}
+	return h.om.LoadOrStore(key, value)
+}
+
+// LoadOrStoreFunc is a method which returns the value for a key if present,
+// otherwise stores and returns the result of fn like Map.LoadOrStoreFunc, and
+// records it.
+func (h *History[K, V]) LoadOrStoreFunc(
+	key K,
+	fn func() (V, error),
+) (actual V, loaded bool, err error) {
+	return h.om.LoadOrStoreFunc(key, func() (V, error) {
+		v, e := fn()
+		if e == nil {
+			h.recordStore(key, v)
+		}
+		return v, e
+	})
+}
+
+// Delete is a method which deletes a value for a key like Map.Delete, and
+// records it.
+func (h *History[K, V]) Delete(key K) {
+	h.recordDelete(key, false)
+	h.om.Delete(key)
+}
+
+// Ldelete is a method which logically deletes a value for a key like
+// Map.Ldelete, and records it.
+func (h *History[K, V]) Ldelete(key K) {
+	h.recordDelete(key, true)
+	h.om.Ldelete(key)
+}
+
+// LoadAndDelete is a method which deletes a value for a key and returns the
+// previous value like Map.LoadAndDelete, and records it.
+func (h *History[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	h.recordDelete(key, false)
+	return h.om.LoadAndDelete(key)
+}
+
+// LoadAndLdelete is a method which logically deletes a value for a key and
+// returns the previous value like Map.LoadAndLdelete, and records it.
+func (h *History[K, V]) LoadAndLdelete(key K) (value V, loaded bool) {
+	h.recordDelete(key, true)
+	return h.om.LoadAndLdelete(key)
+}
+
+// FrontAndDelete is a method which deletes the first entry and returns it
+// like Map.FrontAndDelete, and records it.
+func (h *History[K, V]) FrontAndDelete() *Entry[K, V] {
+	if h.om.head != nil {
+		h.recordDelete(h.om.head.key, false)
+	}
+	return h.om.FrontAndDelete()
+}
+
+// FrontAndLdelete is a method which logically deletes the first entry and
+// returns it like Map.FrontAndLdelete, and records it.
+func (h *History[K, V]) FrontAndLdelete() *Entry[K, V] {
+	if h.om.head != nil {
+		h.recordDelete(h.om.head.key, true)
+	}
+	return h.om.FrontAndLdelete()
+}
+
+// BackAndDelete is a method which deletes the last entry and returns it like
+// Map.BackAndDelete, and records it.
+func (h *History[K, V]) BackAndDelete() *Entry[K, V] {
+	if h.om.last != nil {
+		h.recordDelete(h.om.last.key, false)
+	}
+	return h.om.BackAndDelete()
+}
+
+// BackAndLdelete is a method which logically deletes the last entry and
+// returns it like Map.BackAndLdelete, and records it.
+func (h *History[K, V]) BackAndLdelete() *Entry[K, V] {
+	if h.om.last != nil {
+		h.recordDelete(h.om.last.key, true)
+	}
+	return h.om.BackAndLdelete()
+}
+
+// MoveToFront is a method which moves an entry for a key to the head like
+// Map.MoveToFront, and records it.
+func (h *History[K, V]) MoveToFront(key K) bool {
+	return h.recordMove(key, func() bool { return h.om.MoveToFront(key) })
+}
+
+// MoveToBack is a method which moves an entry for a key to the end like
+// Map.MoveToBack, and records it.
+func (h *History[K, V]) MoveToBack(key K) bool {
+	return h.recordMove(key, func() bool { return h.om.MoveToBack(key) })
+}
+
+// MoveBefore is a method which moves an entry for a key to the position just
+// before an entry for a mark key like Map.MoveBefore, and records it.
+func (h *History[K, V]) MoveBefore(key, mark K) bool {
+	return h.recordMove(key, func() bool { return h.om.MoveBefore(key, mark) })
+}
+
+// MoveAfter is a method which moves an entry for a key to the position just
+// after an entry for a mark key like Map.MoveAfter, and records it.
+func (h *History[K, V]) MoveAfter(key, mark K) bool {
+	return h.recordMove(key, func() bool { return h.om.MoveAfter(key, mark) })
+}
+
+// SortFunc is a method which sorts the entries like Map.SortFunc, and records
+// it if any entry is moved.
+func (h *History[K, V]) SortFunc(less func(a, b *Entry[K, V]) bool) {
+	om := h.om
+	if om.len < 2 {
+		return
+	}
+	keys := make([]K, 0, om.len)
+	for ent := om.head; ent != nil; ent = ent.next {
+		keys = append(keys, ent.key)
+	}
+
+	om.SortFunc(less)
+
+	i := 0
+	for ent := om.head; ent != nil; ent = ent.next {
+		if ent.key != keys[i] {
+			break
+		}
+		i++
+	}
+	if i == len(keys) {
+		return
+	}
+
+	h.record(func() {
+		for _, key := range keys {
+			om.MoveToBack(key)
+		}
+	}, func() {
+		om.SortFunc(less)
+	})
+}
+
+// Clear is a method which deletes all entries like Map.Clear, and records
+// it.
+func (h *History[K, V]) Clear() {
+	om := h.om
+	if len(om.m) == 0 {
+		return
+	}
+
+	live := make([]Pair[K, V], 0, om.len)
+	for ent := om.head; ent != nil; ent = ent.next {
+		live = append(live, Pair[K, V]{First: ent.key, Second: ent.value})
+	}
+	deleted := make([]Pair[K, V], 0, len(om.m)-om.len)
+	for k, ent := range om.m {
+		if ent.deleted {
+			deleted = append(deleted, Pair[K, V]{First: k, Second: ent.value})
+		}
+	}
+
+	om.Clear()
+
+	h.record(func() {
+		om.bulk(func() {
+			om.Clear()
+			for _, p := range live {
+				om.Store(p.First, p.Second)
+			}
+			for _, p := range deleted {
+				om.Store(p.First, p.Second)
+				om.Ldelete(p.First)
+			}
+		})
+	}, func() {
+		om.Clear()
+	})
+}
+
+// StoreAll is a method which stores each key and value yielded by seq like
+// Map.StoreAll, and records them as one step.
+func (h *History[K, V]) StoreAll(seq func(yield func(key K, value V) bool)) {
+	h.BeginGroup()
+	h.om.bulk(func() {
+		seq(func(k K, v V) bool {
+			h.Store(k, v)
+			return true
+		})
+	})
+	h.EndGroup()
+}
+
+// StorePairs is a method which stores the keys and values of pairs like
+// Map.StorePairs, and records them as one step.
+func (h *History[K, V]) StorePairs(pairs ...Pair[K, V]) {
+	h.BeginGroup()
+	h.om.bulk(func() {
+		for _, p := range pairs {
+			h.Store(p.First, p.Second)
+		}
+	})
+	h.EndGroup()
+}
+
+// DeleteAll is a method which deletes values for keys like Map.DeleteAll, and
+// records them as one step.
+func (h *History[K, V]) DeleteAll(keys ...K) int {
+	n := 0
+	h.BeginGroup()
+	h.om.bulk(func() {
+		for _, k := range keys {
+			if _, loaded := h.LoadAndDelete(k); loaded {
+				n++
+			}
+		}
+	})
+	h.EndGroup()
+	return n
+}
+
+// DeleteIf is a method which deletes the entries for which pred returns true
+// like Map.DeleteIf, and records them as one step.
+func (h *History[K, V]) DeleteIf(pred func(key K, value V) bool) int {
+	return h.removeIf(pred, false)
+}
+
+// LdeleteIf is a method which logically deletes the entries for which pred
+// returns true like Map.LdeleteIf, and records them as one step.
+func (h *History[K, V]) LdeleteIf(pred func(key K, value V) bool) int {
+	return h.removeIf(pred, true)
+}
+
+// RetainIf is a method which deletes the entries for which pred returns false
+// like Map.RetainIf, and records them as one step.
+func (h *History[K, V]) RetainIf(pred func(key K, value V) bool) int {
+	return h.removeIf(func(k K, v V) bool { return !pred(k, v) }, false)
+}
+
+func (h *History[K, V]) removeIf(pred func(key K, value V) bool, logical bool) int {
+	keys := make([]K, 0)
+	for ent := h.om.head; ent != nil; ent = ent.next {
+		if pred(ent.key, ent.value) {
+			keys = append(keys, ent.key)
+		}
+	}
+	if len(keys) == 0 {
+		return 0
+	}
+
+	h.BeginGroup()
+	h.om.bulk(func() {
+		for _, k := range keys {
+			if logical {
+				h.Ldelete(k)
+			} else {
+				h.Delete(k)
+			}
+		}
+	})
+	h.EndGroup()
+	return len(keys)
+}
+
+// Compute is a method which updates or deletes a value for a key with fn like
+// Map.Compute, and records it.
+func (h *History[K, V]) Compute(
+	key K,
+	fn func(value V, loaded bool) (newValue V, keep bool),
+) (actual V, ok bool) {
+	return h.om.Compute(key, func(v V, loaded bool) (V, bool) {
+		nv, keep := fn(v, loaded)
+		if keep || loaded {
+			h.recordRemap(key, nv, keep)
+		}
+		return nv, keep
+	})
+}
+
+// ComputeIfAbsent is a method which stores the value returned by fn for a key
+// if absent like Map.ComputeIfAbsent, and records it.
+func (h *History[K, V]) ComputeIfAbsent(
+	key K,
+	fn func(key K) (value V, store bool),
+) (actual V, loaded, ok bool) {
+	return h.om.ComputeIfAbsent(key, func(k K) (V, bool) {
+		v, store := fn(k)
+		if store {
+			h.recordStore(key, v)
+		}
+		return v, store
+	})
+}
+
+// ComputeIfPresent is a method which updates or deletes a value for a key
+// with fn if present like Map.ComputeIfPresent, and records it.
+func (h *History[K, V]) ComputeIfPresent(
+	key K,
+	fn func(value V) (newValue V, keep bool),
+) (actual V, ok bool) {
+	return h.om.ComputeIfPresent(key, func(v V) (V, bool) {
+		nv, keep := fn(v)
+		h.recordRemap(key, nv, keep)
+		return nv, keep
+	})
+}
+
+// Merge is a method which stores a value for a key if absent, otherwise
+// stores the value returned by fn like Map.Merge, and records it.
+func (h *History[K, V]) Merge(
+	key K,
+	value V,
+	fn func(old, value V) (newValue V, keep bool),
+) (actual V, ok bool) {
+	if _, loaded := h.om.Load(key); !loaded {
+		h.recordStore(key, value)
+	}
+	return h.om.Merge(key, value, func(old, v V) (V, bool) {
+		nv, keep := fn(old, v)
+		h.recordRemap(key, nv, keep)
+		return nv, keep
+	})
+}
+
+// recordRemap is a method which records the inverse of updating a value for
+// a key which is present, or deleting it if keep is false.
+func (h *History[K, V]) recordRemap(key K, value V, keep bool) {
+	if keep {
+		h.recordStore(key, value)
+	} else {
+		h.recordDelete(key, false)
+	}
+}
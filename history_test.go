@@ -0,0 +1,377 @@
+package orderedmap_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func TestHistory_undoRedo(t *testing.T) {
+	om := newABC()
+	h := orderedmap.NewHistory(&om, 0)
+	assert.False(t, h.CanUndo())
+	assert.False(t, h.Undo())
+	assert.False(t, h.Redo())
+
+	h.Store("b", 20)
+	h.Store("d", 4)
+	h.Delete("a")
+	h.MoveToFront("c")
+	assert.Equal(t, om.String(), "Map[c:3 b:20 d:4]")
+
+	assert.True(t, h.Undo())
+	assert.Equal(t, om.String(), "Map[b:20 c:3 d:4]")
+	assert.True(t, h.Undo())
+	assert.Equal(t, om.String(), "Map[a:1 b:20 c:3 d:4]")
+	assert.True(t, h.Undo())
+	assert.Equal(t, om.String(), "Map[a:1 b:20 c:3]")
+	assert.True(t, h.Undo())
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:3]")
+	assert.False(t, h.Undo())
+
+	assert.True(t, h.CanRedo())
+	assert.True(t, h.Redo())
+	assert.True(t, h.Redo())
+	assert.Equal(t, om.String(), "Map[a:1 b:20 c:3 d:4]")
+
+	h.Store("e", 5)
+	assert.False(t, h.CanRedo())
+	assert.False(t, h.Redo())
+	assert.Equal(t, om.String(), "Map[a:1 b:20 c:3 d:4 e:5]")
+
+	assert.Equal(t, h.Map(), &om)
+}
+
+func TestHistory_deletes(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+	om.Store("d", 4)
+	om.Store("e", 5)
+	h := orderedmap.NewHistory(&om, 0)
+
+	v, loaded := h.Swap("c", 30)
+	assert.True(t, loaded)
+	assert.Equal(t, v, 3)
+	h.Ldelete("c")
+	v, loaded = h.LoadAndDelete("b")
+	assert.True(t, loaded)
+	assert.Equal(t, v, 2)
+	v, loaded = h.LoadAndLdelete("d")
+	assert.True(t, loaded)
+	assert.Equal(t, v, 4)
+	assert.Equal(t, h.FrontAndDelete().Key(), "a")
+	assert.Equal(t, h.BackAndLdelete().Key(), "e")
+	assert.Nil(t, h.FrontAndLdelete())
+	assert.Nil(t, h.BackAndDelete())
+	h.Delete("c")
+	h.Store("d", 40)
+	assert.Equal(t, om.String(), "Map[d:40]")
+
+	for h.Undo() {
+	}
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:3 d:4 e:5]")
+
+	for h.Redo() {
+	}
+	assert.Equal(t, om.String(), "Map[d:40]")
+
+	h.Undo()
+	assert.Equal(t, om.String(), "Map[]")
+	h.Undo()
+	h.Store("c", 300)
+	assert.Equal(t, om.String(), "Map[c:300]")
+	h.Undo()
+	h.Undo()
+	h.Undo()
+	assert.Equal(t, om.String(), "Map[a:1 e:5]")
+}
+
+func TestHistory_group(t *testing.T) {
+	om := newABC()
+	h := orderedmap.NewHistory(&om, 0)
+
+	h.BeginGroup()
+	h.Store("d", 4)
+	h.BeginGroup()
+	h.Delete("a")
+	h.EndGroup()
+	assert.False(t, h.CanUndo())
+	assert.False(t, h.Undo())
+	h.MoveToBack("b")
+	h.EndGroup()
+	h.EndGroup()
+
+	assert.Equal(t, om.String(), "Map[c:3 d:4 b:2]")
+	assert.True(t, h.Undo())
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:3]")
+	assert.False(t, h.CanUndo())
+	assert.True(t, h.Redo())
+	assert.Equal(t, om.String(), "Map[c:3 d:4 b:2]")
+
+	h.BeginGroup()
+	h.EndGroup()
+	assert.True(t, h.Undo())
+	assert.False(t, h.CanUndo())
+
+	h.Reset()
+	assert.False(t, h.CanRedo())
+}
+
+func TestHistory_limit(t *testing.T) {
+	om := orderedmap.New[int, int]()
+	h := orderedmap.NewHistory(&om, 3)
+
+	for i := 0; i < 5; i++ {
+		h.Store(i, i)
+	}
+	n := 0
+	for h.Undo() {
+		n++
+	}
+	assert.Equal(t, n, 3)
+	assert.Equal(t, om.String(), "Map[0:0 1:1]")
+
+	for h.Redo() {
+	}
+	assert.Equal(t, om.String(), "Map[0:0 1:1 2:2 3:3 4:4]")
+}
+
+func TestHistory_moves(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+	om.Store("d", 4)
+	h := orderedmap.NewHistory(&om, 0)
+
+	assert.True(t, h.MoveToFront("a"))
+	assert.False(t, h.CanUndo())
+	assert.False(t, h.MoveToBack("x"))
+	assert.True(t, h.MoveAfter("a", "c"))
+	assert.True(t, h.MoveBefore("d", "b"))
+	assert.False(t, h.MoveBefore("d", "d"))
+	assert.True(t, h.MoveToBack("b"))
+	assert.Equal(t, keysOf(&om), "dcab/bacd")
+
+	h.SortFunc(func(a, b *orderedmap.Entry[string, int]) bool {
+		return a.Key() < b.Key()
+	})
+	assert.Equal(t, keysOf(&om), "abcd/dcba")
+
+	h.Undo()
+	assert.Equal(t, keysOf(&om), "dcab/bacd")
+	h.Undo()
+	assert.Equal(t, keysOf(&om), "dbca/acbd")
+	h.Undo()
+	assert.Equal(t, keysOf(&om), "bcad/dacb")
+	h.Undo()
+	assert.Equal(t, keysOf(&om), "abcd/dcba")
+	assert.False(t, h.Undo())
+
+	for h.Redo() {
+	}
+	assert.Equal(t, keysOf(&om), "abcd/dcba")
+	h.Undo()
+	assert.Equal(t, keysOf(&om), "dcab/bacd")
+}
+
+func TestHistory_random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	om := orderedmap.New[int, int]()
+	h := orderedmap.NewHistory(&om, 0)
+	states := []string{om.String()}
+
+	for i := 0; i < 2000; i++ {
+		k := r.Intn(20)
+		switch r.Intn(9) {
+		case 0, 1:
+			h.Store(k, i)
+		case 2:
+			h.Swap(k, i)
+		case 3:
+			h.Delete(k)
+		case 4:
+			h.Ldelete(k)
+		case 5:
+			h.FrontAndLdelete()
+		case 6:
+			h.MoveAfter(k, r.Intn(20))
+		case 7:
+			h.MoveToFront(k)
+		case 8:
+			if r.Intn(10) == 0 {
+				h.SortFunc(func(a, b *orderedmap.Entry[int, int]) bool {
+					return a.Value() < b.Value()
+				})
+			} else {
+				h.BackAndDelete()
+			}
+		}
+		if s := om.String(); s != states[len(states)-1] {
+			states = append(states, s)
+		}
+	}
+
+	for i := len(states) - 1; i > 0; i-- {
+		assert.Equal(t, om.String(), states[i])
+		if !h.Undo() {
+			t.Fatal("no step to undo")
+		}
+		for om.String() == states[i] && h.Undo() {
+		}
+	}
+	assert.Equal(t, om.String(), states[0])
+
+	for h.Redo() {
+	}
+	assert.Equal(t, om.String(), states[len(states)-1])
+}
+
+func TestHistory_snapshot(t *testing.T) {
+	om := newABC()
+	h := orderedmap.NewHistory(&om, 0)
+	s := om.Snapshot()
+
+	h.MoveToFront("c")
+	h.Delete("a")
+	assert.Equal(t, om.String(), "Map[c:3 b:2]")
+	assert.Equal(t, s.String(), "Snapshot[a:1 b:2 c:3]")
+
+	h.Undo()
+	h.Undo()
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:3]")
+}
+
+func TestHistory_loadOrStoreAndCompute(t *testing.T) {
+	om := newABC()
+	h := orderedmap.NewHistory(&om, 0)
+
+	v, loaded := h.LoadOrStore("a", 10)
+	assert.True(t, loaded)
+	assert.Equal(t, v, 1)
+	assert.False(t, h.CanUndo())
+	h.LoadOrStore("d", 4)
+
+	_, _, err := h.LoadOrStoreFunc("e", func() (int, error) {
+		return 0, errors.New("x")
+	})
+	assert.NotNil(t, err)
+	h.LoadOrStoreFunc("e", func() (int, error) { return 5, nil })
+
+	h.Compute("a", func(v int, ok bool) (int, bool) { return v + 10, true })
+	h.Compute("z", func(v int, ok bool) (int, bool) { return 0, false })
+	h.Compute("b", func(v int, ok bool) (int, bool) { return 0, false })
+	h.ComputeIfAbsent("f", func(k string) (int, bool) { return 6, true })
+	h.ComputeIfAbsent("g", func(k string) (int, bool) { return 0, false })
+	h.ComputeIfPresent("c", func(v int) (int, bool) { return v * 10, true })
+	h.Merge("d", 40, func(old, v int) (int, bool) { return 0, false })
+	h.Merge("h", 8, func(old, v int) (int, bool) { return old + v, true })
+	h.Merge("h", 8, func(old, v int) (int, bool) { return old + v, true })
+	assert.Equal(t, om.String(), "Map[a:11 c:30 e:5 f:6 h:16]")
+
+	expected := []string{
+		"Map[a:11 c:30 e:5 f:6 h:8]",
+		"Map[a:11 c:30 e:5 f:6]",
+		"Map[a:11 c:30 d:4 e:5 f:6]",
+		"Map[a:11 c:3 d:4 e:5 f:6]",
+		"Map[a:11 c:3 d:4 e:5]",
+		"Map[a:11 b:2 c:3 d:4 e:5]",
+		"Map[a:1 b:2 c:3 d:4 e:5]",
+		"Map[a:1 b:2 c:3 d:4]",
+		"Map[a:1 b:2 c:3]",
+	}
+	for _, s := range expected {
+		assert.True(t, h.Undo())
+		assert.Equal(t, om.String(), s)
+	}
+	assert.False(t, h.Undo())
+
+	for h.Redo() {
+	}
+	assert.Equal(t, om.String(), "Map[a:11 c:30 e:5 f:6 h:16]")
+}
+
+func TestHistory_bulk(t *testing.T) {
+	om := newABC()
+	h := orderedmap.NewHistory(&om, 0)
+
+	other := orderedmap.New[string, int]()
+	other.Store("d", 4)
+	other.Store("a", 10)
+	h.StoreAll(other.Range)
+	assert.Equal(t, om.String(), "Map[a:10 b:2 c:3 d:4]")
+	h.StorePairs(orderedmap.Pair[string, int]{First: "e", Second: 5})
+	assert.Equal(t, h.DeleteAll("b", "x", "d"), 2)
+	assert.Equal(t, om.String(), "Map[a:10 c:3 e:5]")
+	assert.Equal(t, h.LdeleteIf(func(k string, v int) bool { return v > 9 }), 1)
+	assert.Equal(t, h.DeleteIf(func(k string, v int) bool { return false }), 0)
+	assert.Equal(t, h.RetainIf(func(k string, v int) bool { return k == "e" }), 1)
+	assert.Equal(t, om.String(), "Map[e:5]")
+
+	for _, s := range []string{
+		"Map[c:3 e:5]",
+		"Map[a:10 c:3 e:5]",
+		"Map[a:10 b:2 c:3 d:4 e:5]",
+		"Map[a:10 b:2 c:3 d:4]",
+		"Map[a:1 b:2 c:3]",
+	} {
+		assert.True(t, h.Undo())
+		assert.Equal(t, om.String(), s)
+	}
+	assert.False(t, h.Undo())
+
+	for h.Redo() {
+	}
+	assert.Equal(t, om.String(), "Map[e:5]")
+}
+
+func TestHistory_clear(t *testing.T) {
+	om := newABC()
+	om.Ldelete("b")
+	h := orderedmap.NewHistory(&om, 0)
+
+	var events []orderedmap.EventType
+	om.Observe(func(ev orderedmap.Event[string, int]) {
+		events = append(events, ev.Type)
+	})
+
+	before, err := om.MarshalBinaryWithTombstones()
+	assert.Nil(t, err)
+
+	h.Clear()
+	assert.Equal(t, om.String(), "Map[]")
+	h.Clear()
+	assert.True(t, h.Undo())
+	assert.Equal(t, om.String(), "Map[a:1 c:3]")
+	assert.False(t, h.CanUndo())
+
+	after, err := om.MarshalBinaryWithTombstones()
+	assert.Nil(t, err)
+	assert.Equal(t, after, before)
+
+	assert.True(t, h.Redo())
+	assert.Equal(t, om.String(), "Map[]")
+	assert.Equal(t, events[0], orderedmap.EventClear)
+}
+
+func TestHistory_sortWithoutMoves(t *testing.T) {
+	om := newABC()
+	h := orderedmap.NewHistory(&om, 0)
+
+	h.SortFunc(func(a, b *orderedmap.Entry[string, int]) bool {
+		return a.Key() < b.Key()
+	})
+	assert.False(t, h.CanUndo())
+
+	h.SortFunc(func(a, b *orderedmap.Entry[string, int]) bool {
+		return a.Key() > b.Key()
+	})
+	assert.Equal(t, om.String(), "Map[c:3 b:2 a:1]")
+	assert.True(t, h.Undo())
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:3]")
+}
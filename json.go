@@ -78,16 +78,12 @@ func (err SyntaxError) Error() string {
 func addJsonKey(buf *bytes.Buffer, key any) error {
 	switch key.(type) {
 	case string:
-		buf.WriteString(`"`)
-		buf.WriteString(key.(string))
-		buf.WriteString(`"`)
+		addJsonString(buf, key.(string))
 	case *string:
 		if key == (*string)(nil) {
 			buf.WriteString(`"null"`)
 		} else {
-			buf.WriteString(`"`)
-			buf.WriteString(*(key.(*string)))
-			buf.WriteString(`"`)
+			addJsonString(buf, *(key.(*string)))
 		}
 	case bool:
 		buf.WriteString(`"`)
@@ -251,6 +247,15 @@ func addJsonKey(buf *bytes.Buffer, key any) error {
 	return nil
 }
 
+// addJsonString writes a JSON quoted string of s, in which quotation marks,
+// backslashes and control characters are escaped but HTML characters are not.
+func addJsonString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	buf.Truncate(buf.Len() - 1) // Encode appends a newline.
+}
+
 func addJsonValue[V any](buf *bytes.Buffer, val V) error {
 	bs, err := json.Marshal(val)
 	if err != nil {
@@ -0,0 +1,684 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// EncodeHook is a function type which is called for each entry of ordered
+// maps and Go maps while encoding them with Encoder, including the maps nested
+// in structs, slices, arrays and other maps.
+// This function receives a key and a value of an entry, and returns a key and
+// a value to be written.
+// If the returned keep flag is false, the entry is omitted.
+type EncodeHook func(key string, value any) (newKey string, newValue any, keep bool)
+
+// Encoder is a struct which writes JSON strings of ordered maps to an output
+// stream.
+// This encoder can indent nested ordered maps, sort their keys, and rename,
+// omit or transform their entries with a hook function.
+type Encoder struct {
+	w          io.Writer
+	prefix     string
+	indent     string
+	escapeHTML bool
+	sortKeys   bool
+	hook       EncodeHook
+}
+
+// jsonObject is an interface which is implemented by ordered maps to make
+// Encoder be able to iterate their entries with keys converted to strings.
+type jsonObject interface {
+	rangeJSON(fn func(key string, value any) error) error
+}
+
+type jsonEntry struct {
+	key   string
+	value any
+}
+
+// NewEncoder is a function which creates a new Encoder which writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, escapeHTML: true}
+}
+
+// SetIndent is a method which makes this encoder format each subsequent
+// encoded value as if indented by json.Indent.
+// Calling SetIndent("", "") disables indentation.
+func (enc *Encoder) SetIndent(prefix, indent string) {
+	enc.prefix = prefix
+	enc.indent = indent
+}
+
+// SetEscapeHTML is a method which specifies whether problematic HTML
+// characters should be escaped inside JSON quoted strings.
+// The default behavior is to escape them, like json.Encoder.
+func (enc *Encoder) SetEscapeHTML(on bool) {
+	enc.escapeHTML = on
+}
+
+// SetSortKeys is a method which specifies whether entries of ordered maps are
+// written in the sorted order of their keys instead of the order of key
+// insertions.
+func (enc *Encoder) SetSortKeys(on bool) {
+	enc.sortKeys = on
+}
+
+// SetHook is a method which sets a function which is called for each entry of
+// ordered maps.
+// If fn is nil, entries are written as they are.
+func (enc *Encoder) SetHook(fn EncodeHook) {
+	enc.hook = fn
+}
+
+// Encode is a method which writes the JSON string of v to the stream,
+// followed by a newline character.
+// If v is an ordered map or contains ordered maps in its struct fields, slice
+// or array elements, or map values, their entries are written in the order of
+// key insertions with the settings of this encoder. The struct fields are
+// written in the same way as encoding/json, following their json tags. The
+// omitzero option is supported also before Go 1.24.
+func (enc *Encoder) Encode(v any) error {
+	var buf bytes.Buffer
+	err := enc.encodeValue(&buf, v, 0)
+	if err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+	_, err = enc.w.Write(buf.Bytes())
+	return err
+}
+
+func (enc *Encoder) isIndented() bool {
+	return len(enc.prefix) > 0 || len(enc.indent) > 0
+}
+
+func (enc *Encoder) newline(buf *bytes.Buffer, depth int) {
+	buf.WriteString("\n")
+	buf.WriteString(enc.prefix)
+	for i := 0; i < depth; i++ {
+		buf.WriteString(enc.indent)
+	}
+}
+
+func (enc *Encoder) encodeValue(buf *bytes.Buffer, v any, depth int) error {
+	if obj, ok := v.(jsonObject); ok && !isNilPointer(v) {
+		return enc.encodeObject(buf, obj, depth)
+	}
+
+	if v != nil && enc.needsWalk(reflect.TypeOf(v)) {
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if rv.IsNil() {
+				buf.WriteString("null")
+				return nil
+			}
+			return enc.encodeValue(buf, rv.Elem().Interface(), depth)
+		case reflect.Slice:
+			if rv.IsNil() {
+				buf.WriteString("null")
+				return nil
+			}
+			return enc.encodeArray(buf, rv, depth)
+		case reflect.Array:
+			return enc.encodeArray(buf, rv, depth)
+		case reflect.Map:
+			if rv.IsNil() {
+				buf.WriteString("null")
+				return nil
+			}
+			return enc.encodeObject(buf, goMapObject{rv}, depth)
+		case reflect.Struct:
+			return enc.encodeStruct(buf, rv, depth)
+		}
+	}
+
+	bs, err := enc.marshal(v)
+	if err != nil {
+		return err
+	}
+	if !enc.isIndented() {
+		buf.Write(bs)
+		return nil
+	}
+	return json.Indent(buf, bs, enc.prefix+strings.Repeat(enc.indent, depth), enc.indent)
+}
+
+func (enc *Encoder) encodeObject(buf *bytes.Buffer, obj jsonObject, depth int) error {
+	entries := make([]jsonEntry, 0)
+	err := obj.rangeJSON(func(key string, value any) error {
+		if enc.hook != nil {
+			k, v, keep := enc.hook(key, value)
+			if !keep {
+				return nil
+			}
+			key, value = k, v
+		}
+		entries = append(entries, jsonEntry{key: key, value: value})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if enc.sortKeys {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].key < entries[j].key
+		})
+	}
+	return enc.encodeEntries(buf, entries, depth)
+}
+
+func (enc *Encoder) encodeEntries(buf *bytes.Buffer, entries []jsonEntry, depth int) error {
+	if len(entries) == 0 {
+		buf.WriteString("{}")
+		return nil
+	}
+
+	buf.WriteString("{")
+	for i, ent := range entries {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		if enc.isIndented() {
+			enc.newline(buf, depth+1)
+		}
+		bs, err := enc.marshal(ent.key)
+		if err != nil {
+			return err
+		}
+		buf.Write(bs)
+		if enc.isIndented() {
+			buf.WriteString(": ")
+		} else {
+			buf.WriteString(":")
+		}
+		err = enc.encodeValue(buf, ent.value, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	if enc.isIndented() {
+		enc.newline(buf, depth)
+	}
+	buf.WriteString("}")
+	return nil
+}
+
+func (enc *Encoder) encodeArray(buf *bytes.Buffer, arr reflect.Value, depth int) error {
+	if arr.Len() == 0 {
+		buf.WriteString("[]")
+		return nil
+	}
+
+	buf.WriteString("[")
+	for i := 0; i < arr.Len(); i++ {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		if enc.isIndented() {
+			enc.newline(buf, depth+1)
+		}
+		err := enc.encodeValue(buf, arr.Index(i).Interface(), depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	if enc.isIndented() {
+		enc.newline(buf, depth)
+	}
+	buf.WriteString("]")
+	return nil
+}
+
+func (enc *Encoder) marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(enc.escapeHTML)
+	err := e.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+func (om Map[K, V]) rangeJSON(fn func(key string, value any) error) error {
+	for ent := om.Front(); ent != nil; ent = ent.Next() {
+		key, err := jsonKeyString(ent.Key())
+		if err != nil {
+			return err
+		}
+		err = fn(key, ent.Value())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func jsonKeyString(key any) (string, error) {
+	var buf bytes.Buffer
+	err := addJsonKey(&buf, key)
+	if err != nil {
+		return "", err
+	}
+	var s string
+	err = json.Unmarshal(buf.Bytes(), &s)
+	return s, err
+}
+
+var (
+	jsonObjectType    = reflect.TypeOf((*jsonObject)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// typeTraits is a struct which records whether values of a type can contain
+// ordered maps or Go maps, which have to be written by Encoder itself instead
+// of json.Encoder.
+type typeTraits struct {
+	ordered bool
+	goMap   bool
+}
+
+var typeTraitsCache sync.Map // map[reflect.Type]typeTraits
+
+func (enc *Encoder) needsWalk(t reflect.Type) bool {
+	var tr typeTraits
+	if c, ok := typeTraitsCache.Load(t); ok {
+		tr = c.(typeTraits)
+	} else {
+		tr = traitsOf(t, make(map[reflect.Type]bool))
+		typeTraitsCache.Store(t, tr)
+	}
+	return tr.ordered || (enc.hook != nil && tr.goMap)
+}
+
+func traitsOf(t reflect.Type, visiting map[reflect.Type]bool) typeTraits {
+	if t.Implements(jsonObjectType) {
+		return typeTraits{ordered: true}
+	}
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return typeTraits{}
+	}
+	if visiting[t] {
+		return typeTraits{}
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return traitsOf(t.Elem(), visiting)
+	case reflect.Map:
+		tr := traitsOf(t.Elem(), visiting)
+		tr.goMap = true
+		return tr
+	case reflect.Interface:
+		return typeTraits{ordered: true, goMap: true}
+	case reflect.Struct:
+		var tr typeTraits
+		for _, f := range cachedStructFields(t) {
+			ft := traitsOf(f.typ, visiting)
+			tr.ordered = tr.ordered || ft.ordered
+			tr.goMap = tr.goMap || ft.goMap
+		}
+		return tr
+	}
+	return typeTraits{}
+}
+
+// goMapObject is a jsonObject which iterates the entries of a Go map in the
+// sorted order of their keys, like encoding/json.
+type goMapObject struct {
+	rv reflect.Value
+}
+
+func (o goMapObject) rangeJSON(fn func(key string, value any) error) error {
+	entries := make([]jsonEntry, 0, o.rv.Len())
+	iter := o.rv.MapRange()
+	for iter.Next() {
+		key, err := goMapKeyString(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, jsonEntry{key: key, value: iter.Value().Interface()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	for _, ent := range entries {
+		err := fn(ent.key, ent.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func goMapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		bs, err := tm.MarshalText()
+		return string(bs), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", UnsupportedKeyTypeError{Type: k.Type()}
+}
+
+func (enc *Encoder) encodeStruct(buf *bytes.Buffer, rv reflect.Value, depth int) error {
+	fields := cachedStructFields(rv.Type())
+	entries := make([]jsonEntry, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) ||
+			(f.omitZero && isZeroValue(fv)) {
+			continue
+		}
+
+		var value any
+		if f.quoted && fv.Kind() == reflect.Ptr && fv.IsNil() {
+			value = nil
+		} else if f.quoted {
+			if fv.Kind() == reflect.Ptr {
+				fv = fv.Elem()
+			}
+			bs, err := enc.marshal(fv.Interface())
+			if err != nil {
+				return err
+			}
+			bs, err = enc.marshal(string(bs))
+			if err != nil {
+				return err
+			}
+			value = json.RawMessage(bs)
+		} else if fv.Kind() != reflect.Ptr && fv.CanAddr() &&
+			(reflect.PtrTo(fv.Type()).Implements(jsonMarshalerType) ||
+				reflect.PtrTo(fv.Type()).Implements(textMarshalerType)) {
+			value = fv.Addr().Interface()
+		} else {
+			value = fv.Interface()
+		}
+		entries = append(entries, jsonEntry{key: f.name, value: value})
+	}
+	return enc.encodeEntries(buf, entries, depth)
+}
+
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return v, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+type isZeroer interface {
+	IsZero() bool
+}
+
+var isZeroerType = reflect.TypeOf((*isZeroer)(nil)).Elem()
+
+// isZeroValue is a function which reports whether a field with the omitzero
+// option is omitted. Like encoding/json, this function uses the IsZero method
+// of the field type or its pointer type if exists, otherwise reports whether
+// the field is the zero value of its type.
+func isZeroValue(v reflect.Value) bool {
+	t := v.Type()
+	switch {
+	case t.Kind() == reflect.Interface && t.Implements(isZeroerType):
+		return v.IsNil() ||
+			(v.Elem().Kind() == reflect.Ptr && v.Elem().IsNil()) ||
+			v.Interface().(isZeroer).IsZero()
+	case t.Kind() == reflect.Ptr && t.Implements(isZeroerType):
+		return v.IsNil() || v.Interface().(isZeroer).IsZero()
+	case t.Implements(isZeroerType):
+		return v.Interface().(isZeroer).IsZero()
+	case reflect.PtrTo(t).Implements(isZeroerType):
+		if !v.CanAddr() {
+			a := reflect.New(t).Elem()
+			a.Set(v)
+			v = a
+		}
+		return v.Addr().Interface().(isZeroer).IsZero()
+	}
+	return v.IsZero()
+}
+
+// structField is a struct which holds the information of a struct field to be
+// written as a JSON object member.
+type structField struct {
+	name      string
+	tagged    bool
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+	omitZero  bool
+	quoted    bool
+}
+
+var structFieldsCache sync.Map // map[reflect.Type][]structField
+
+func cachedStructFields(t reflect.Type) []structField {
+	if c, ok := structFieldsCache.Load(t); ok {
+		return c.([]structField)
+	}
+	fields := structFields(t)
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+// structFields is a function which returns the fields of a struct type to be
+// written as JSON object members, by the same rules as encoding/json: the
+// fields of embedded structs are promoted, and a name which is given to
+// multiple fields at the same depth is omitted unless exactly one of them is
+// tagged.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+
+	current := []structField{}
+	next := []structField{{typ: t}}
+	var count, nextCount map[reflect.Type]int
+	visited := map[reflect.Type]bool{}
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, f := range current {
+			if visited[f.typ] {
+				continue
+			}
+			visited[f.typ] = true
+
+			for i := 0; i < f.typ.NumField(); i++ {
+				sf := f.typ.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+
+				index := make([]int, len(f.index)+1)
+				copy(index, f.index)
+				index[len(f.index)] = i
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+					quoted := false
+					if hasTagOption(opts, "string") && !isMarshaler(sf.Type) {
+						switch ft.Kind() {
+						case reflect.Bool,
+							reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+							reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+							reflect.Uint64, reflect.Uintptr,
+							reflect.Float32, reflect.Float64, reflect.String:
+							quoted = true
+						}
+					}
+					tagged := name != ""
+					if name == "" {
+						name = sf.Name
+					}
+					fields = append(fields, structField{
+						name:      name,
+						tagged:    tagged,
+						index:     index,
+						typ:       sf.Type,
+						omitEmpty: hasTagOption(opts, "omitempty"),
+						omitZero:  hasTagOption(opts, "omitzero"),
+						quoted:    quoted,
+					})
+					if count[f.typ] > 1 {
+						// Two copies annihilate each other at the same depth.
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, structField{name: ft.Name(), index: index, typ: ft})
+				}
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		x := fields
+		if x[i].name != x[j].name {
+			return x[i].name < x[j].name
+		}
+		if len(x[i].index) != len(x[j].index) {
+			return len(x[i].index) < len(x[j].index)
+		}
+		if x[i].tagged != x[j].tagged {
+			return x[i].tagged
+		}
+		return lessIndex(x[i].index, x[j].index)
+	})
+
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+		fi := fields[i]
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != fi.name {
+				break
+			}
+		}
+		if advance == 1 {
+			out = append(out, fi)
+			continue
+		}
+		dominant := fields[i]
+		second := fields[i+1]
+		if len(dominant.index) == len(second.index) && dominant.tagged == second.tagged {
+			continue
+		}
+		out = append(out, dominant)
+	}
+
+	fields = out
+	sort.Slice(fields, func(i, j int) bool {
+		return lessIndex(fields[i].index, fields[j].index)
+	})
+	return fields
+}
+
+// isMarshaler is a function which reports whether a type writes itself by
+// MarshalJSON or MarshalText, for which encoding/json ignores the string
+// option.
+func isMarshaler(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType)
+}
+
+func lessIndex(a, b []int) bool {
+	for k, x := range a {
+		if k >= len(b) {
+			return false
+		}
+		if x != b[k] {
+			return x < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func hasTagOption(opts, name string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
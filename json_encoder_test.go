@@ -0,0 +1,420 @@
+package orderedmap_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func TestEncoder_Encode_mapIsEmpty(t *testing.T) {
+	om := orderedmap.New[string, string]()
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	err := enc.Encode(om)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), "{}\n")
+
+	buf.Reset()
+	enc.SetIndent("", "  ")
+	err = enc.Encode(&om)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), "{}\n")
+}
+
+func TestEncoder_Encode_compact(t *testing.T) {
+	om := orderedmap.New[string, any]()
+	om.Store("foo", "bar")
+	om.Store("baz", 123)
+	om.Store("qux", []any{1, "a"})
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	err := enc.Encode(om)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), `{"foo":"bar","baz":123,"qux":[1,"a"]}`+"\n")
+}
+
+func TestEncoder_Encode_indent(t *testing.T) {
+	inner := orderedmap.New[string, any]()
+	inner.Store("z", 1)
+	inner.Store("a", map[string]int{"y": 2, "b": 3})
+
+	om := orderedmap.New[string, any]()
+	om.Store("foo", "bar")
+	om.Store("inner", &inner)
+	om.Store("list", []any{inner, "x", []any{}})
+	om.Store("empty", []int{})
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(&om)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), strings.Join([]string{
+		`{`,
+		`  "foo": "bar",`,
+		`  "inner": {`,
+		`    "z": 1,`,
+		`    "a": {`,
+		`      "b": 3,`,
+		`      "y": 2`,
+		`    }`,
+		`  },`,
+		`  "list": [`,
+		`    {`,
+		`      "z": 1,`,
+		`      "a": {`,
+		`        "b": 3,`,
+		`        "y": 2`,
+		`      }`,
+		`    },`,
+		`    "x",`,
+		`    []`,
+		`  ],`,
+		`  "empty": []`,
+		`}`,
+		``,
+	}, "\n"))
+}
+
+func TestEncoder_Encode_indentWithPrefix(t *testing.T) {
+	om := orderedmap.New[int, string]()
+	om.Store(2, "b")
+	om.Store(1, "a")
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	enc.SetIndent(">", "\t")
+	err := enc.Encode(om)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), "{\n>\t\"2\": \"b\",\n>\t\"1\": \"a\"\n>}\n")
+}
+
+func TestEncoder_Encode_escapeHTML(t *testing.T) {
+	om := orderedmap.New[string, string]()
+	om.Store("<a>", "x & y")
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	err := enc.Encode(om)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), `{"\u003ca\u003e":"x \u0026 y"}`+"\n")
+
+	buf.Reset()
+	enc.SetEscapeHTML(false)
+	err = enc.Encode(om)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), `{"<a>":"x & y"}`+"\n")
+}
+
+func TestEncoder_Encode_escapeKey(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store(`a"b`, 1)
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	err := enc.Encode(om)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), `{"a\"b":1}`+"\n")
+}
+
+func TestEncoder_Encode_sortKeys(t *testing.T) {
+	inner := orderedmap.New[string, int]()
+	inner.Store("b", 1)
+	inner.Store("a", 2)
+
+	om := orderedmap.New[string, any]()
+	om.Store("foo", &inner)
+	om.Store("bar", 3)
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	enc.SetSortKeys(true)
+	err := enc.Encode(om)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), `{"bar":3,"foo":{"a":2,"b":1}}`+"\n")
+}
+
+func TestEncoder_Encode_hook(t *testing.T) {
+	inner := orderedmap.New[string, int]()
+	inner.Store("password", 1)
+	inner.Store("count", 2)
+
+	om := orderedmap.New[string, any]()
+	om.Store("name", "foo")
+	om.Store("password", "secret")
+	om.Store("inner", inner)
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	enc.SetHook(func(key string, value any) (string, any, bool) {
+		switch key {
+		case "password":
+			return "", nil, false
+		case "name":
+			return "Name", strings.ToUpper(value.(string)), true
+		case "count":
+			return key, value.(int) * 10, true
+		}
+		return key, value, true
+	})
+	err := enc.Encode(om)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), `{"Name":"FOO","inner":{"count":20}}`+"\n")
+
+	buf.Reset()
+	enc.SetHook(nil)
+	err = enc.Encode(om)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(),
+		`{"name":"foo","password":"secret","inner":{"password":1,"count":2}}`+"\n")
+}
+
+func TestEncoder_Encode_nilPointer(t *testing.T) {
+	om := orderedmap.New[string, *orderedmap.Map[string, int]]()
+	om.Store("foo", nil)
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	err := enc.Encode(om)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), `{"foo":null}`+"\n")
+}
+
+func TestEncoder_Encode_unsupportedKeyTypeError(t *testing.T) {
+	om := orderedmap.New[complex64, string]()
+	om.Store(complex64(1), "a")
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	err := enc.Encode(om)
+	assert.Equal(t, err.Error(), "json: unsupported key type: complex64")
+	assert.Equal(t, buf.Len(), 0)
+}
+
+func TestEncoder_Encode_unsupportedValueTypeError(t *testing.T) {
+	om := orderedmap.New[string, any]()
+	om.Store("foo", make(chan int))
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(om)
+	assert.NotNil(t, err)
+	assert.Equal(t, buf.Len(), 0)
+}
+
+type encoderCredentials struct {
+	User  string                            `json:"user"`
+	Attrs *orderedmap.Map[string, any]      `json:"attrs"`
+	Extra map[string]any                    `json:"extra,omitempty"`
+	List  []*orderedmap.Map[string, string] `json:"list"`
+	Skip  string                            `json:"-"`
+	Count int                               `json:"count,string"`
+	Empty *orderedmap.Map[string, any]      `json:"empty,omitempty"`
+	inner string
+}
+
+func TestEncoder_Encode_nestedInStruct(t *testing.T) {
+	attrs := orderedmap.New[string, any]()
+	attrs.Store("z", "<a>")
+	attrs.Store("password", "secret")
+	attrs.Store("a", 1)
+
+	item := orderedmap.New[string, string]()
+	item.Store("password", "p")
+	item.Store("b", "<b>")
+
+	v := encoderCredentials{
+		User:  "foo",
+		Attrs: &attrs,
+		Extra: map[string]any{"password": "x", "y": []any{attrs}},
+		List:  []*orderedmap.Map[string, string]{&item, nil},
+		Skip:  "skip",
+		Count: 3,
+		inner: "inner",
+	}
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetSortKeys(true)
+	enc.SetHook(func(key string, value any) (string, any, bool) {
+		return key, value, key != "password"
+	})
+	err := enc.Encode(v)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), `{"user":"foo","attrs":{"a":1,"z":"<a>"},`+
+		`"extra":{"y":[{"a":1,"z":"<a>"}]},"list":[{"b":"<b>"},null],`+
+		`"count":"3"}`+"\n")
+	assert.NotContains(t, buf.String(), "secret")
+
+	buf.Reset()
+	enc = orderedmap.NewEncoder(&buf)
+	err = enc.Encode(&v)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), `{"user":"foo",`+
+		`"attrs":{"z":"\u003ca\u003e","password":"secret","a":1},`+
+		`"extra":{"password":"x",`+
+		`"y":[{"z":"\u003ca\u003e","password":"secret","a":1}]},`+
+		`"list":[{"password":"p","b":"\u003cb\u003e"},null],"count":"3"}`+"\n")
+}
+
+type encoderEmbedded struct {
+	ID   int                         `json:"id"`
+	Meta orderedmap.Map[string, int] `json:"meta"`
+}
+
+type encoderOuter struct {
+	encoderEmbedded
+	Name string
+	Any  any
+	Arr  [2]any
+}
+
+func TestEncoder_Encode_structFields(t *testing.T) {
+	meta := orderedmap.New[string, int]()
+	meta.Store("b", 2)
+	meta.Store("a", 1)
+
+	v := encoderOuter{
+		encoderEmbedded: encoderEmbedded{ID: 1, Meta: meta},
+		Name:            "n",
+		Any:             map[int]any{2: "two", 10: &meta},
+		Arr:             [2]any{nil, meta},
+	}
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	enc.SetIndent("", " ")
+	err := enc.Encode(v)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), strings.Join([]string{
+		`{`,
+		` "id": 1,`,
+		` "meta": {`,
+		`  "b": 2,`,
+		`  "a": 1`,
+		` },`,
+		` "Name": "n",`,
+		` "Any": {`,
+		`  "10": {`,
+		`   "b": 2,`,
+		`   "a": 1`,
+		`  },`,
+		`  "2": "two"`,
+		` },`,
+		` "Arr": [`,
+		`  null,`,
+		`  {`,
+		`   "b": 2,`,
+		`   "a": 1`,
+		`  }`,
+		` ]`,
+		`}`,
+	}, "\n")+"\n")
+}
+
+func TestEncoder_Encode_sameAsJSONWithoutOrderedMaps(t *testing.T) {
+	type plain struct {
+		A int               `json:"a,omitempty"`
+		B []string          `json:"b"`
+		C map[string]int    `json:"c"`
+		D *int              `json:"d"`
+		E bool              `json:",string"`
+		F map[string][]byte `json:"f"`
+	}
+	v := []any{
+		plain{B: []string{"<x>"}, C: map[string]int{"y": 1, "x": 2}, E: true,
+			F: map[string][]byte{"k": []byte("hi")}},
+		map[string]any{"b": plain{}, "a": nil},
+	}
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	enc.SetHook(func(key string, value any) (string, any, bool) {
+		return key, value, true
+	})
+	err := enc.Encode(v)
+	assert.Nil(t, err)
+
+	bs, err := json.Marshal(v)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), string(bs)+"\n")
+}
+
+type zeroIfNegative int
+
+func (z zeroIfNegative) IsZero() bool {
+	return z < 0
+}
+
+type zeroIfEmptyName struct {
+	Name string
+}
+
+func (z *zeroIfEmptyName) IsZero() bool {
+	return z.Name == ""
+}
+
+func TestEncoder_Encode_quotedPointerFields(t *testing.T) {
+	n, s, f := 12, "x", 1.5
+	type quoted struct {
+		N   *int       `json:"n,string"`
+		S   *string    `json:"s,string"`
+		F   *float64   `json:"f,string"`
+		Nil *bool      `json:"nil,string"`
+		T   time.Time  `json:"t,string"`
+		TP  *time.Time `json:"tp,string"`
+		M   orderedmap.Map[string, int]
+	}
+	tm := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	v := quoted{N: &n, S: &s, F: &f, T: tm, TP: &tm, M: orderedmap.New[string, int]()}
+
+	var buf bytes.Buffer
+	err := orderedmap.NewEncoder(&buf).Encode(v)
+	assert.Nil(t, err)
+
+	bs, err := json.Marshal(v)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), string(bs)+"\n")
+	assert.True(t, strings.HasPrefix(buf.String(),
+		`{"n":"12","s":"\"x\"","f":"1.5","nil":null,`))
+}
+
+func TestEncoder_Encode_omitzero(t *testing.T) {
+	type omitted struct {
+		A int                         `json:"a,omitzero"`
+		B [2]int                      `json:"b,omitzero"`
+		C struct{ X int }             `json:"c,omitzero"`
+		D time.Time                   `json:"d,omitzero"`
+		E zeroIfNegative              `json:"e,omitzero"`
+		F zeroIfEmptyName             `json:"f,omitzero"`
+		G *zeroIfEmptyName            `json:"g,omitzero"`
+		H []int                       `json:"h,omitzero"`
+		M orderedmap.Map[string, int] `json:"m,omitzero"`
+	}
+
+	var buf bytes.Buffer
+	enc := orderedmap.NewEncoder(&buf)
+	err := enc.Encode(omitted{E: -1, G: &zeroIfEmptyName{}})
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(), "{}\n")
+
+	buf.Reset()
+	m := orderedmap.New[string, int]()
+	m.Store("k", 1)
+	err = enc.Encode(omitted{
+		A: 1, B: [2]int{0, 1}, C: struct{ X int }{1},
+		E: 0, F: zeroIfEmptyName{"f"}, H: []int{}, M: m,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, buf.String(),
+		`{"a":1,"b":[0,1],"c":{"X":1},"e":0,"f":{"Name":"f"},"h":[],"m":{"k":1}}`+"\n")
+}
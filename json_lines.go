@@ -0,0 +1,179 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// JSONLinesError is an error type which is returned by ReadJSONLines when a
+// line of an input stream is not a JSON object.
+type JSONLinesError struct {
+	Line int
+	Err  error
+}
+
+func (err JSONLinesError) Error() string {
+	return "line " + strconv.Itoa(err.Line) + ": " + err.Err.Error()
+}
+
+// Unwrap is a method which returns the error which caused this error.
+func (err JSONLinesError) Unwrap() error {
+	return err.Err
+}
+
+// ReadJSONLines is a function which returns an iterator which reads JSON
+// objects from r line by line, in the format of JSON Lines (NDJSON), and
+// yields each of them as an ordered map.
+// The entries of a yielded map, and also of nested objects in it, are in the
+// same order with fields in the line.
+// Nested objects are decoded into *Map[string, any], nested arrays are
+// decoded into []any, and numbers are decoded into json.Number so that they
+// are written back unchanged.
+//
+// Blank lines are skipped.
+// If a line is not a JSON object, a JSONLinesError is yielded with a nil map,
+// and the iteration continues if the yield function returns true.
+// If reading from r fails, the error is yielded and the iteration stops.
+//
+// The returned function has the same signature with iter.Seq2, so it can be
+// used with a range-over-func loop on Go 1.23 or later, or can be called
+// with a callback function on older Go.
+func ReadJSONLines(r io.Reader) func(yield func(*Map[string, any], error) bool) {
+	return func(yield func(*Map[string, any], error) bool) {
+		br := bufio.NewReader(r)
+		lineNo := 0
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(line) > 0 {
+				lineNo++
+				line = bytes.TrimSpace(line)
+				if len(line) > 0 {
+					om, e := decodeJSONLine(line)
+					if e != nil {
+						if !yield(nil, JSONLinesError{Line: lineNo, Err: e}) {
+							return
+						}
+					} else if !yield(om, nil) {
+						return
+					}
+				}
+			}
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+	}
+}
+
+func decodeJSONLine(line []byte) (*Map[string, any], error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, SyntaxError{
+			Offset: 0,
+			msg:    "The input JSON does not start with '{'",
+		}
+	}
+
+	om, err := decodeOrderedJSONObject(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = dec.Token()
+	if err != io.EOF {
+		return nil, SyntaxError{
+			Offset: dec.InputOffset(),
+			msg:    "Invalid data after the top-level JSON object",
+		}
+	}
+	return om, nil
+}
+
+// decodeOrderedJSONObject decodes the rest of a JSON object after its opening
+// bracket. Nested objects are decoded into ordered maps too.
+func decodeOrderedJSONObject(dec *json.Decoder) (*Map[string, any], error) {
+	om := New[string, any]()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+		val, err := decodeOrderedJSONValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		om.Store(key, val)
+	}
+	_, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return &om, nil
+}
+
+func decodeOrderedJSONValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		return decodeOrderedJSONObject(dec)
+	case json.Delim('['):
+		arr := make([]any, 0)
+		for dec.More() {
+			elem, err := decodeOrderedJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, elem)
+		}
+		_, err = dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		return arr, nil
+	}
+	return tok, nil
+}
+
+// JSONLinesWriter is a struct which writes ordered maps to an output stream
+// in the format of JSON Lines (NDJSON), one JSON object per line.
+type JSONLinesWriter struct {
+	enc *Encoder
+}
+
+// NewJSONLinesWriter is a function which creates a new JSONLinesWriter which
+// writes to w.
+func NewJSONLinesWriter(w io.Writer) *JSONLinesWriter {
+	enc := NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONLinesWriter{enc: enc}
+}
+
+// Write is a method which writes the JSON string of the specified ordered map
+// followed by a newline character.
+// The entries of the map, and also of ordered maps nested in it, are written
+// in the order of key insertions. HTML characters are not escaped, so a map
+// read by ReadJSONLines is written back as the same line.
+func (jw *JSONLinesWriter) Write(om *Map[string, any]) error {
+	return jw.enc.Encode(om)
+}
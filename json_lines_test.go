@@ -0,0 +1,219 @@
+package orderedmap_test
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+type errReader struct {
+	data []byte
+	err  error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestReadJSONLines_empty(t *testing.T) {
+	n := 0
+	orderedmap.ReadJSONLines(strings.NewReader(""))(
+		func(om *orderedmap.Map[string, any], err error) bool {
+			n++
+			return true
+		})
+	assert.Equal(t, n, 0)
+}
+
+func TestReadJSONLines_multipleLines(t *testing.T) {
+	input := `{"z":1,"a":"x","m":true}` + "\n" +
+		"\n" +
+		`  {"b":null,"nested":{"y":1,"c":[{"q":1,"p":2}]}}  ` + "\r\n" +
+		`{}`
+
+	oms := make([]*orderedmap.Map[string, any], 0)
+	orderedmap.ReadJSONLines(strings.NewReader(input))(
+		func(om *orderedmap.Map[string, any], err error) bool {
+			assert.Nil(t, err)
+			oms = append(oms, om)
+			return true
+		})
+
+	assert.Equal(t, len(oms), 3)
+	assert.Equal(t, oms[0].String(), "Map[z:1 a:x m:true]")
+	assert.Equal(t, oms[1].String(), "Map[b:<nil> nested:Map[y:1 c:[Map[q:1 p:2]]]]")
+	assert.Equal(t, oms[2].Len(), 0)
+
+	nested, _ := oms[1].Load("nested")
+	inner := nested.(*orderedmap.Map[string, any])
+	assert.Equal(t, inner.Front().Key(), "y")
+	assert.Equal(t, inner.Back().Key(), "c")
+}
+
+func TestReadJSONLines_stopIteration(t *testing.T) {
+	input := "{\"a\":1}\n{\"b\":2}\n{\"c\":3}\n"
+
+	keys := make([]string, 0)
+	orderedmap.ReadJSONLines(strings.NewReader(input))(
+		func(om *orderedmap.Map[string, any], err error) bool {
+			keys = append(keys, om.Front().Key())
+			return len(keys) < 2
+		})
+	assert.Equal(t, keys, []string{"a", "b"})
+}
+
+func TestReadJSONLines_badLines(t *testing.T) {
+	input := "{\"a\":1}\n[1,2]\n{\"b\":\n{\"c\":3} x\n{\"d\":4}\n"
+
+	results := make([]string, 0)
+	orderedmap.ReadJSONLines(strings.NewReader(input))(
+		func(om *orderedmap.Map[string, any], err error) bool {
+			if err != nil {
+				var e orderedmap.JSONLinesError
+				assert.True(t, errors.As(err, &e))
+				assert.Nil(t, om)
+				results = append(results, "error at line "+strconv.Itoa(e.Line))
+				return true
+			}
+			results = append(results, om.String())
+			return true
+		})
+	assert.Equal(t, results, []string{
+		"Map[a:1]",
+		"error at line 2",
+		"error at line 3",
+		"error at line 4",
+		"Map[d:4]",
+	})
+}
+
+func TestReadJSONLines_badLineAndStop(t *testing.T) {
+	input := "[1]\n{\"a\":1}\n"
+
+	n := 0
+	orderedmap.ReadJSONLines(strings.NewReader(input))(
+		func(om *orderedmap.Map[string, any], err error) bool {
+			n++
+			assert.Equal(t, err.Error(),
+				"line 1: The input JSON does not start with '{' (offset:0)")
+			return false
+		})
+	assert.Equal(t, n, 1)
+}
+
+func TestReadJSONLines_readError(t *testing.T) {
+	readErr := errors.New("read error")
+	r := &errReader{data: []byte("{\"a\":1}\n{\"b\""), err: readErr}
+
+	results := make([]any, 0)
+	orderedmap.ReadJSONLines(r)(
+		func(om *orderedmap.Map[string, any], err error) bool {
+			if err != nil {
+				results = append(results, err)
+			} else {
+				results = append(results, om.String())
+			}
+			return true
+		})
+	assert.Equal(t, len(results), 3)
+	assert.Equal(t, results[0], "Map[a:1]")
+	assert.IsType(t, orderedmap.JSONLinesError{}, results[1])
+	assert.Equal(t, results[2], readErr)
+}
+
+func TestJSONLinesWriter_Write(t *testing.T) {
+	om0 := orderedmap.New[string, any]()
+	om0.Store("z", 1)
+	om0.Store("a", "x\ny")
+
+	inner := orderedmap.New[int, bool]()
+	inner.Store(2, true)
+	inner.Store(1, false)
+
+	om1 := orderedmap.New[string, any]()
+	om1.Store("html", "<a&b>")
+	om1.Store("inner", &inner)
+
+	var buf bytes.Buffer
+	w := orderedmap.NewJSONLinesWriter(&buf)
+	assert.Nil(t, w.Write(&om0))
+	assert.Nil(t, w.Write(&om1))
+	assert.Equal(t, buf.String(), "{\"z\":1,\"a\":\"x\\ny\"}\n"+
+		"{\"html\":\"<a&b>\",\"inner\":{\"2\":true,\"1\":false}}\n")
+}
+
+func TestJSONLinesWriter_Write_error(t *testing.T) {
+	om := orderedmap.New[string, any]()
+	om.Store("a", make(chan int))
+
+	var buf bytes.Buffer
+	w := orderedmap.NewJSONLinesWriter(&buf)
+	err := w.Write(&om)
+	assert.Equal(t, err.Error(), "json: unsupported type: chan int")
+	assert.Equal(t, buf.Len(), 0)
+}
+
+func TestJSONLines_roundTrip(t *testing.T) {
+	input := `{"time":"2024-01-01","level":"info","ctx":{"req":"abc","user":{"name":"foo","id":3}},"tags":["b","a"]}` + "\n" +
+		`{"time":"2024-01-02","level":"warn","msg":"<tag> & more","<k>":1}` + "\n"
+
+	var buf bytes.Buffer
+	w := orderedmap.NewJSONLinesWriter(&buf)
+	orderedmap.ReadJSONLines(strings.NewReader(input))(
+		func(om *orderedmap.Map[string, any], err error) bool {
+			assert.Nil(t, err)
+			assert.Nil(t, w.Write(om))
+			return true
+		})
+	assert.Equal(t, buf.String(), input)
+}
+
+func TestJSONLines_roundTripNumbers(t *testing.T) {
+	input := `{"big":12345678901234567890,"f":1.0,"e":1e3,"n":[-0.50,7]}` + "\n"
+
+	var buf bytes.Buffer
+	w := orderedmap.NewJSONLinesWriter(&buf)
+	orderedmap.ReadJSONLines(strings.NewReader(input))(
+		func(om *orderedmap.Map[string, any], err error) bool {
+			assert.Nil(t, err)
+			assert.Nil(t, w.Write(om))
+			return true
+		})
+	assert.Equal(t, buf.String(), input)
+}
+
+func TestJSONLines_roundTripEscapedKeys(t *testing.T) {
+	om := orderedmap.New[string, any]()
+	om.Store(`a"b`, 1)
+	om.Store("tab\tkey", 2)
+	om.Store("\u0001", 3)
+	om.Store(`back\slash`, 4)
+
+	var buf bytes.Buffer
+	w := orderedmap.NewJSONLinesWriter(&buf)
+	assert.Nil(t, w.Write(&om))
+	assert.Equal(t, buf.String(),
+		`{"a\"b":1,"tab\tkey":2,"\u0001":3,"back\\slash":4}`+"\n")
+
+	var keys []string
+	orderedmap.ReadJSONLines(&buf)(
+		func(om2 *orderedmap.Map[string, any], err error) bool {
+			assert.Nil(t, err)
+			om2.Range(func(k string, v any) bool {
+				keys = append(keys, k)
+				return true
+			})
+			return true
+		})
+	assert.Equal(t, keys, []string{`a"b`, "tab\tkey", "\u0001", `back\slash`})
+}
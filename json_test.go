@@ -44,6 +44,29 @@ func TestMarshalJSON_variousKeyAndValueTypes_string(t *testing.T) {
 	assert.Equal(t, b, []byte(`{"foo":"bar","":"qux"}`))
 }
 
+func TestMarshalJSON_variousKeyAndValueTypes_stringToBeEscaped(t *testing.T) {
+	om := orderedmap.New[string, string]()
+	om.Store(`a"b`, "1")
+	om.Store("c\\d\ne", "2")
+	om.Store("<f&g>", "<h>")
+	p := "\u0001"
+	om2 := orderedmap.New[*string, int]()
+	om2.Store(&p, 3)
+
+	b, e := om.MarshalJSON()
+	assert.Nil(t, e)
+	assert.Equal(t, b, []byte(`{"a\"b":"1","c\\d\ne":"2","<f&g>":"\u003ch\u003e"}`))
+
+	b, e = om2.MarshalJSON()
+	assert.Nil(t, e)
+	assert.Equal(t, b, []byte(`{"\u0001":3}`))
+
+	om3 := orderedmap.New[string, string]()
+	assert.Nil(t, om3.UnmarshalJSON([]byte(`{"a\"b":"1","c\\d\ne":"2","<f&g>":"<h>"}`)))
+	assert.Equal(t, om3.Front().Key(), `a"b`)
+	assert.Equal(t, om3.Front().Next().Key(), "c\\d\ne")
+}
+
 func TestMarshalJSON_variousKeyAndValueTypes_bool(t *testing.T) {
 	om := orderedmap.New[bool, bool]()
 	om.Store(true, true)
@@ -0,0 +1,330 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
+const msgpackFormat = "msgpack"
+
+// MarshalMsgpack is a method which returns a byte array of MessagePack data
+// which expresses the content of this map.
+// The entries are written in the order of key insertions, and keys of any
+// types which can be expressed in MessagePack are written as they are.
+func (om Map[K, V]) MarshalMsgpack() ([]byte, error) {
+	w := &msgpackWriter{}
+	err := encodeBinary(w, om, 0)
+	if err != nil {
+		return nil, err
+	}
+	return w.bytes(), nil
+}
+
+// UnmarshalMsgpack is a method which sets the content of this map from a
+// MessagePack data.
+// The entries are stored in the order in the data.
+// If the value type of this map is any, nested maps are decoded into
+// *Map[string, any] if all their keys are strings, into *Map[int64, any]
+// if all their keys are integers, or into *HashMap[any, any] otherwise.
+func (om *Map[K, V]) UnmarshalMsgpack(data []byte) error {
+	return unmarshalBinary(&msgpackReader{data: data}, om)
+}
+
+// MarshalMsgpack is a method which returns a byte array of MessagePack data
+// which expresses the content of this map, like Map.MarshalMsgpack.
+func (hm HashMap[K, V]) MarshalMsgpack() ([]byte, error) {
+	w := &msgpackWriter{}
+	err := encodeBinary(w, hm, 0)
+	if err != nil {
+		return nil, err
+	}
+	return w.bytes(), nil
+}
+
+// UnmarshalMsgpack is a method which sets the content of this map from a
+// MessagePack data, like Map.UnmarshalMsgpack.
+func (hm *HashMap[K, V]) UnmarshalMsgpack(data []byte) error {
+	return unmarshalBinary(&msgpackReader{data: data}, hm)
+}
+
+type msgpackWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *msgpackWriter) format() string {
+	return msgpackFormat
+}
+
+func (w *msgpackWriter) newWriter() binaryWriter {
+	return &msgpackWriter{}
+}
+
+func (w *msgpackWriter) bytes() []byte {
+	return w.buf.Bytes()
+}
+
+func (w *msgpackWriter) writeRaw(b []byte) {
+	w.buf.Write(b)
+}
+
+func (w *msgpackWriter) writeNil() {
+	w.buf.WriteByte(0xc0)
+}
+
+func (w *msgpackWriter) writeBool(b bool) {
+	if b {
+		w.buf.WriteByte(0xc3)
+	} else {
+		w.buf.WriteByte(0xc2)
+	}
+}
+
+func (w *msgpackWriter) writeHead(code byte, n uint64, size int) {
+	w.buf.WriteByte(code)
+	var b [8]byte
+	switch size {
+	case 1:
+		w.buf.WriteByte(byte(n))
+	case 2:
+		binary.BigEndian.PutUint16(b[:], uint16(n))
+		w.buf.Write(b[:2])
+	case 4:
+		binary.BigEndian.PutUint32(b[:], uint32(n))
+		w.buf.Write(b[:4])
+	case 8:
+		binary.BigEndian.PutUint64(b[:], n)
+		w.buf.Write(b[:8])
+	}
+}
+
+func (w *msgpackWriter) writeInt(n int64) {
+	switch {
+	case n >= 0:
+		w.writeUint(uint64(n))
+	case n >= -32:
+		w.buf.WriteByte(byte(n))
+	case n >= math.MinInt8:
+		w.writeHead(0xd0, uint64(n), 1)
+	case n >= math.MinInt16:
+		w.writeHead(0xd1, uint64(n), 2)
+	case n >= math.MinInt32:
+		w.writeHead(0xd2, uint64(n), 4)
+	default:
+		w.writeHead(0xd3, uint64(n), 8)
+	}
+}
+
+func (w *msgpackWriter) writeUint(n uint64) {
+	switch {
+	case n < 0x80:
+		w.buf.WriteByte(byte(n))
+	case n <= math.MaxUint8:
+		w.writeHead(0xcc, n, 1)
+	case n <= math.MaxUint16:
+		w.writeHead(0xcd, n, 2)
+	case n <= math.MaxUint32:
+		w.writeHead(0xce, n, 4)
+	default:
+		w.writeHead(0xcf, n, 8)
+	}
+}
+
+func (w *msgpackWriter) writeFloat32(f float32) {
+	w.writeHead(0xca, uint64(math.Float32bits(f)), 4)
+}
+
+func (w *msgpackWriter) writeFloat64(f float64) {
+	w.writeHead(0xcb, math.Float64bits(f), 8)
+}
+
+func (w *msgpackWriter) writeString(s string) {
+	n := uint64(len(s))
+	switch {
+	case n < 32:
+		w.buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		w.writeHead(0xd9, n, 1)
+	case n <= math.MaxUint16:
+		w.writeHead(0xda, n, 2)
+	default:
+		w.writeHead(0xdb, n, 4)
+	}
+	w.buf.WriteString(s)
+}
+
+func (w *msgpackWriter) writeBytes(b []byte) {
+	n := uint64(len(b))
+	switch {
+	case n <= math.MaxUint8:
+		w.writeHead(0xc4, n, 1)
+	case n <= math.MaxUint16:
+		w.writeHead(0xc5, n, 2)
+	default:
+		w.writeHead(0xc6, n, 4)
+	}
+	w.buf.Write(b)
+}
+
+func (w *msgpackWriter) writeArrayHeader(n int) {
+	switch {
+	case n < 16:
+		w.buf.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		w.writeHead(0xdc, uint64(n), 2)
+	default:
+		w.writeHead(0xdd, uint64(n), 4)
+	}
+}
+
+func (w *msgpackWriter) writeMapHeader(n int) {
+	switch {
+	case n < 16:
+		w.buf.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		w.writeHead(0xde, uint64(n), 2)
+	default:
+		w.writeHead(0xdf, uint64(n), 4)
+	}
+}
+
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *msgpackReader) format() string {
+	return msgpackFormat
+}
+
+func (r *msgpackReader) offset() int64 {
+	return int64(r.pos)
+}
+
+func (r *msgpackReader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+func (r *msgpackReader) read(n uint64) ([]byte, error) {
+	if n > uint64(r.remaining()) {
+		return nil, binaryErrorf(r, "unexpected end of data")
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+func (r *msgpackReader) readUint(size int) (uint64, error) {
+	b, err := r.read(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (r *msgpackReader) readToken() (binaryToken, error) {
+	b, err := r.read(1)
+	if err != nil {
+		return binaryToken{}, err
+	}
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return binaryToken{kind: binaryInt, i: int64(c)}, nil
+	case c >= 0xe0:
+		return binaryToken{kind: binaryInt, i: int64(int8(c))}, nil
+	case c&0xf0 == 0x80:
+		return binaryToken{kind: binaryMap, n: int(c & 0x0f)}, nil
+	case c&0xf0 == 0x90:
+		return binaryToken{kind: binaryArray, n: int(c & 0x0f)}, nil
+	case c&0xe0 == 0xa0:
+		return r.readString(uint64(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return binaryToken{kind: binaryNil}, nil
+	case 0xc2:
+		return binaryToken{kind: binaryBool, b: false}, nil
+	case 0xc3:
+		return binaryToken{kind: binaryBool, b: true}, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.readUint(1 << (c - 0xc4))
+		if err != nil {
+			return binaryToken{}, err
+		}
+		bs, err := r.read(n)
+		if err != nil {
+			return binaryToken{}, err
+		}
+		return binaryToken{kind: binaryBytes, bs: append([]byte{}, bs...)}, nil
+	case 0xca:
+		n, err := r.readUint(4)
+		if err != nil {
+			return binaryToken{}, err
+		}
+		return binaryToken{kind: binaryFloat32, f: float64(math.Float32frombits(uint32(n)))}, nil
+	case 0xcb:
+		n, err := r.readUint(8)
+		if err != nil {
+			return binaryToken{}, err
+		}
+		return binaryToken{kind: binaryFloat64, f: math.Float64frombits(n)}, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := r.readUint(1 << (c - 0xcc))
+		if err != nil {
+			return binaryToken{}, err
+		}
+		return binaryToken{kind: binaryUint, u: n}, nil
+	case 0xd0:
+		n, err := r.readUint(1)
+		return binaryToken{kind: binaryInt, i: int64(int8(n))}, err
+	case 0xd1:
+		n, err := r.readUint(2)
+		return binaryToken{kind: binaryInt, i: int64(int16(n))}, err
+	case 0xd2:
+		n, err := r.readUint(4)
+		return binaryToken{kind: binaryInt, i: int64(int32(n))}, err
+	case 0xd3:
+		n, err := r.readUint(8)
+		return binaryToken{kind: binaryInt, i: int64(n)}, err
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.readUint(1 << (c - 0xd9))
+		if err != nil {
+			return binaryToken{}, err
+		}
+		return r.readString(n)
+	case 0xdc, 0xdd:
+		n, err := r.readUint(2 << (c - 0xdc))
+		return binaryToken{kind: binaryArray, n: int(n)}, err
+	case 0xde, 0xdf:
+		n, err := r.readUint(2 << (c - 0xde))
+		return binaryToken{kind: binaryMap, n: int(n)}, err
+	}
+
+	r.pos--
+	return binaryToken{}, binaryErrorf(r, "unsupported format code 0x"+
+		strconv.FormatUint(uint64(c), 16))
+}
+
+func (r *msgpackReader) readString(n uint64) (binaryToken, error) {
+	b, err := r.read(n)
+	if err != nil {
+		return binaryToken{}, err
+	}
+	return binaryToken{kind: binaryString, s: string(b)}, nil
+}
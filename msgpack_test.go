@@ -0,0 +1,297 @@
+package orderedmap_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func TestMap_MarshalMsgpack_empty(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	b, err := om.MarshalMsgpack()
+	assert.Nil(t, err)
+	assert.Equal(t, b, []byte{0x80})
+}
+
+func TestMap_MarshalMsgpack_order(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("z", 1)
+	om.Store("a", -1)
+	om.Store("m", 200)
+
+	b, err := om.MarshalMsgpack()
+	assert.Nil(t, err)
+	assert.Equal(t, b, []byte{
+		0x83,
+		0xa1, 'z', 0x01,
+		0xa1, 'a', 0xff,
+		0xa1, 'm', 0xcc, 0xc8,
+	})
+}
+
+func TestMap_MarshalMsgpack_nonStringKeys(t *testing.T) {
+	om := orderedmap.New[int, string]()
+	om.Store(3, "c")
+	om.Store(-100, "a")
+	om.Store(70000, "b")
+
+	b, err := om.MarshalMsgpack()
+	assert.Nil(t, err)
+	assert.Equal(t, b, []byte{
+		0x83,
+		0x03, 0xa1, 'c',
+		0xd0, 0x9c, 0xa1, 'a',
+		0xce, 0x00, 0x01, 0x11, 0x70, 0xa1, 'b',
+	})
+
+	om2 := orderedmap.New[int, string]()
+	assert.Nil(t, om2.UnmarshalMsgpack(b))
+	assert.Equal(t, om2.String(), "Map[3:c -100:a 70000:b]")
+}
+
+func TestMap_MarshalMsgpack_ldeletedEntriesAreSkipped(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Ldelete("a")
+
+	b, err := om.MarshalMsgpack()
+	assert.Nil(t, err)
+	assert.Equal(t, b, []byte{0x81, 0xa1, 'b', 0x02})
+}
+
+func TestMap_MsgpackRoundTrip_nested(t *testing.T) {
+	inner := orderedmap.New[string, any]()
+	inner.Store("y", 1.5)
+	inner.Store("x", []any{"s", true, nil})
+
+	intKeys := orderedmap.New[int, string]()
+	intKeys.Store(2, "two")
+	intKeys.Store(1, "one")
+
+	om := orderedmap.New[string, any]()
+	om.Store("name", "foo")
+	om.Store("inner", &inner)
+	om.Store("ints", intKeys)
+	om.Store("bin", []byte{1, 2, 3})
+	om.Store("f32", float32(0.5))
+	om.Store("big", uint64(math.MaxUint64))
+	om.Store("neg", int64(math.MinInt64))
+
+	b, err := om.MarshalMsgpack()
+	assert.Nil(t, err)
+
+	om2 := orderedmap.New[string, any]()
+	assert.Nil(t, om2.UnmarshalMsgpack(b))
+	assert.Equal(t, om2.String(),
+		"Map[name:foo inner:Map[y:1.5 x:[s true <nil>]] ints:Map[2:two 1:one] "+
+			"bin:[1 2 3] f32:0.5 big:18446744073709551615 neg:-9223372036854775808]")
+
+	v, _ := om2.Load("inner")
+	_, ok := v.(*orderedmap.Map[string, any])
+	assert.True(t, ok)
+	v, _ = om2.Load("ints")
+	_, ok = v.(*orderedmap.Map[int64, any])
+	assert.True(t, ok)
+	v, _ = om2.Load("f32")
+	_, ok = v.(float32)
+	assert.True(t, ok)
+}
+
+func TestMap_MarshalMsgpack_goMapIsSortedByKeys(t *testing.T) {
+	om := orderedmap.New[string, map[string]int]()
+	om.Store("m", map[string]int{"b": 2, "a": 1, "c": 3})
+
+	b, err := om.MarshalMsgpack()
+	assert.Nil(t, err)
+	assert.Equal(t, b, []byte{
+		0x81, 0xa1, 'm',
+		0x83, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02, 0xa1, 'c', 0x03,
+	})
+}
+
+func TestMap_MarshalMsgpack_longValues(t *testing.T) {
+	s := string(make([]byte, 300))
+	arr := make([]int, 20)
+
+	om := orderedmap.New[string, any]()
+	om.Store("s", s)
+	om.Store("a", arr)
+
+	b, err := om.MarshalMsgpack()
+	assert.Nil(t, err)
+	assert.Equal(t, b[3:6], []byte{0xda, 0x01, 0x2c})
+	assert.Equal(t, b[306+2:306+5], []byte{0xdc, 0x00, 0x14})
+
+	om2 := orderedmap.New[string, any]()
+	assert.Nil(t, om2.UnmarshalMsgpack(b))
+	v, _ := om2.Load("s")
+	assert.Equal(t, v, s)
+	v, _ = om2.Load("a")
+	assert.Equal(t, len(v.([]any)), 20)
+}
+
+func TestMap_MarshalMsgpack_unsupportedValueType(t *testing.T) {
+	om := orderedmap.New[string, any]()
+	om.Store("ch", make(chan int))
+
+	_, err := om.MarshalMsgpack()
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "msgpack: unsupported value type: chan int")
+	var e orderedmap.UnsupportedValueTypeError
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, e.Format, "msgpack")
+}
+
+func TestMap_UnmarshalMsgpack_typedValues(t *testing.T) {
+	b := []byte{
+		0x82,
+		0xa1, 'a', 0x92, 0x01, 0x02,
+		0xa1, 'b', 0x90,
+	}
+
+	om := orderedmap.New[string, []int]()
+	assert.Nil(t, om.UnmarshalMsgpack(b))
+	assert.Equal(t, om.String(), "Map[a:[1 2] b:[]]")
+}
+
+func TestMap_UnmarshalMsgpack_nestedTypedMap(t *testing.T) {
+	inner := orderedmap.New[string, int]()
+	inner.Store("z", 1)
+	inner.Store("a", 2)
+
+	om := orderedmap.New[string, *orderedmap.Map[string, int]]()
+	om.Store("x", &inner)
+
+	b, err := om.MarshalMsgpack()
+	assert.Nil(t, err)
+
+	om2 := orderedmap.New[string, *orderedmap.Map[string, int]]()
+	assert.Nil(t, om2.UnmarshalMsgpack(b))
+	v, _ := om2.Load("x")
+	assert.Equal(t, v.String(), "Map[z:1 a:2]")
+}
+
+func TestMap_UnmarshalMsgpack_typeMismatch(t *testing.T) {
+	b := []byte{0x81, 0xa1, 'a', 0xa1, 'x'}
+
+	om := orderedmap.New[string, int]()
+	err := om.UnmarshalMsgpack(b)
+	assert.Equal(t, err.Error(),
+		"msgpack: cannot decode string into Go value of type int")
+	var e orderedmap.DecodeTypeError
+	assert.True(t, errors.As(err, &e))
+}
+
+func TestMap_UnmarshalMsgpack_overflow(t *testing.T) {
+	b := []byte{0x81, 0xa1, 'a', 0xcd, 0x01, 0x00}
+
+	om := orderedmap.New[string, uint8]()
+	err := om.UnmarshalMsgpack(b)
+	assert.Equal(t, err.Error(),
+		"msgpack: cannot decode int64 into Go value of type uint8")
+}
+
+func TestMap_UnmarshalMsgpack_nestedMapWithMixedKeys(t *testing.T) {
+	b := []byte{0x81, 0xa1, 'a', 0x82, 0x01, 0xc0, 0xa1, 'x', 0xc0}
+
+	om := orderedmap.New[string, any]()
+	assert.Nil(t, om.UnmarshalMsgpack(b))
+	v, _ := om.Load("a")
+	hm, ok := v.(*orderedmap.HashMap[any, any])
+	assert.True(t, ok)
+	assert.Equal(t, hm.String(), "HashMap[1:<nil> x:<nil>]")
+	b2, err := om.MarshalMsgpack()
+	assert.Nil(t, err)
+	assert.Equal(t, b2, b)
+
+	b = []byte{0x81, 0xa1, 'a', 0x84,
+		0xc0, 0x01,
+		0xc3, 0x02,
+		0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0, 0x03,
+		0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x04,
+	}
+	om = orderedmap.New[string, any]()
+	assert.Nil(t, om.UnmarshalMsgpack(b))
+	v, _ = om.Load("a")
+	hm = v.(*orderedmap.HashMap[any, any])
+	assert.Equal(t, hm.String(),
+		"HashMap[<nil>:1 true:2 1.5:3 18446744073709551615:4]")
+	v, _ = hm.Load(true)
+	assert.Equal(t, v, int64(2))
+	v, _ = hm.Load(uint64(math.MaxUint64))
+	assert.Equal(t, v, int64(4))
+	b2, err = om.MarshalMsgpack()
+	assert.Nil(t, err)
+	assert.Equal(t, b2, b)
+
+	b = []byte{0x81, 0xa1, 'a', 0x81, 0xc4, 0x01, 0xff, 0xc0}
+	err = om.UnmarshalMsgpack(b)
+	assert.Equal(t, err.Error(),
+		"msgpack: cannot decode map key of []uint8 into Go value of type interface {}")
+}
+
+func TestMap_UnmarshalMsgpack_malformed(t *testing.T) {
+	testCases := []struct {
+		data []byte
+		msg  string
+	}{
+		{[]byte{}, "msgpack: unexpected end of data (offset:0)"},
+		{[]byte{0x91, 0x01}, "msgpack: the input data is not a map but array (offset:1)"},
+		{[]byte{0x81, 0xa1}, "msgpack: unexpected end of data (offset:1)"},
+		{[]byte{0x81, 0xa1, 'a', 0xc1}, "msgpack: unsupported format code 0xc1 (offset:3)"},
+		{[]byte{0x81, 0xa1, 'a', 0xd4, 0x00, 0x00}, "msgpack: unsupported format code 0xd4 (offset:3)"},
+		{[]byte{0x80, 0x00}, "msgpack: invalid data after the top-level map (offset:1)"},
+		{[]byte{0xdf, 0xff, 0xff, 0xff, 0xff}, "msgpack: unexpected end of data (offset:5)"},
+		{[]byte{0x81, 0xa1, 'a', 0xdd, 0xff, 0xff, 0xff, 0xff}, "msgpack: unexpected end of data (offset:8)"},
+	}
+
+	for _, tc := range testCases {
+		om := orderedmap.New[string, any]()
+		err := om.UnmarshalMsgpack(tc.data)
+		assert.NotNil(t, err)
+		assert.Equal(t, err.Error(), tc.msg)
+		var e orderedmap.DecodeError
+		assert.True(t, errors.As(err, &e))
+	}
+}
+
+func TestMap_UnmarshalMsgpack_tooDeep(t *testing.T) {
+	b := []byte{0x81, 0xa1, 'a'}
+	for i := 0; i < 2000; i++ {
+		b = append(b, 0x91)
+	}
+	b = append(b, 0xc0)
+
+	om := orderedmap.New[string, any]()
+	err := om.UnmarshalMsgpack(b)
+	assert.Equal(t, err.Error(), "msgpack: too deeply nested (offset:1004)")
+}
+
+func FuzzUnmarshalMsgpack(f *testing.F) {
+	f.Add([]byte{0x80})
+	f.Add([]byte{0x82, 0xa1, 'a', 0x01, 0x02, 0x92, 0xc3, 0xcb, 0, 0, 0, 0, 0, 0, 0, 0})
+	f.Add([]byte{0x81, 0xa1, 'a', 0x81, 0x01, 0xc4, 0x01, 0xff})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		om := orderedmap.New[string, any]()
+		if om.UnmarshalMsgpack(data) != nil {
+			return
+		}
+		b, err := om.MarshalMsgpack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		om2 := orderedmap.New[string, any]()
+		err = om2.UnmarshalMsgpack(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if om.String() != om2.String() {
+			t.Fatalf("%s != %s", om.String(), om2.String())
+		}
+	})
+}
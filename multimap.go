@@ -0,0 +1,231 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MultiMap is a struct which represents a map which can hold multiple values
+// for a key, and preserves the order in which the values were added.
+//
+// All values in this map are linked with Entry in the order of additions
+// across keys, so Front and Back iterate the values of different keys
+// interleaved. And the values of a key can be iterated with RangeKey in the
+// order of additions.
+type MultiMap[K comparable, V any] struct {
+	m    map[K]([]*Entry[K, V])
+	head *Entry[K, V]
+	last *Entry[K, V]
+	len  int
+}
+
+// NewMultiMap is a function which creates a new MultiMap, which is empty.
+func NewMultiMap[K comparable, V any]() MultiMap[K, V] {
+	return MultiMap[K, V]{m: make(map[K]([]*Entry[K, V]))}
+}
+
+// Len is a method which returns the number of values in this map.
+func (mm *MultiMap[K, V]) Len() int {
+	return mm.len
+}
+
+// KeyLen is a method which returns the number of keys in this map.
+func (mm *MultiMap[K, V]) KeyLen() int {
+	return len(mm.m)
+}
+
+// Add is a method which appends a value for a key at the end of this map.
+func (mm *MultiMap[K, V]) Add(key K, value V) {
+	if mm.m == nil {
+		mm.m = make(map[K]([]*Entry[K, V]))
+	}
+
+	ent := &Entry[K, V]{key: key, value: value}
+	mm.m[key] = append(mm.m[key], ent)
+
+	if mm.len == 0 {
+		mm.head = ent
+		mm.last = ent
+		mm.len = 1
+		return
+	}
+
+	ent.prev = mm.last
+	mm.last.next = ent
+	mm.last = ent
+	mm.len++
+}
+
+// Has is a method which checks whether this map has a value for a key.
+func (mm *MultiMap[K, V]) Has(key K) bool {
+	_, exists := mm.m[key]
+	return exists
+}
+
+// GetAll is a method which returns the values for a key in the order of
+// additions. If there is no value for the key, this method returns nil.
+func (mm *MultiMap[K, V]) GetAll(key K) []V {
+	ents := mm.m[key]
+	if len(ents) == 0 {
+		return nil
+	}
+	values := make([]V, len(ents))
+	for i, ent := range ents {
+		values[i] = ent.value
+	}
+	return values
+}
+
+// First is a method which returns the value which was added first for a key.
+// If there is no value for the key, the ok result is false.
+func (mm *MultiMap[K, V]) First(key K) (value V, ok bool) {
+	ents := mm.m[key]
+	if len(ents) > 0 {
+		value = ents[0].value
+		ok = true
+	}
+	return
+}
+
+// Last is a method which returns the value which was added last for a key.
+// If there is no value for the key, the ok result is false.
+func (mm *MultiMap[K, V]) Last(key K) (value V, ok bool) {
+	ents := mm.m[key]
+	if len(ents) > 0 {
+		value = ents[len(ents)-1].value
+		ok = true
+	}
+	return
+}
+
+// DeleteAll is a method which deletes all values for a key, and returns the
+// number of the deleted values.
+func (mm *MultiMap[K, V]) DeleteAll(key K) int {
+	ents, exists := mm.m[key]
+	if !exists {
+		return 0
+	}
+
+	delete(mm.m, key)
+
+	for _, ent := range ents {
+		mm.unlink(ent)
+	}
+	return len(ents)
+}
+
+// DeleteValue is a method which deletes the values for a key which are equal
+// to the specified value, and returns the number of the deleted values.
+// Values are compared with reflect.DeepEqual.
+func (mm *MultiMap[K, V]) DeleteValue(key K, value V) int {
+	ents, exists := mm.m[key]
+	if !exists {
+		return 0
+	}
+
+	kept := ents[:0]
+	n := 0
+	for _, ent := range ents {
+		if reflect.DeepEqual(ent.value, value) {
+			mm.unlink(ent)
+			n++
+		} else {
+			kept = append(kept, ent)
+		}
+	}
+	for i := len(kept); i < len(ents); i++ {
+		ents[i] = nil
+	}
+
+	if len(kept) == 0 {
+		delete(mm.m, key)
+	} else {
+		mm.m[key] = kept
+	}
+	return n
+}
+
+func (mm *MultiMap[K, V]) unlink(ent *Entry[K, V]) {
+	mm.len--
+
+	if ent.prev != nil {
+		ent.prev.next = ent.next
+	} else {
+		mm.head = ent.next
+	}
+
+	if ent.next != nil {
+		ent.next.prev = ent.prev
+	} else {
+		mm.last = ent.prev
+	}
+
+	ent.next = nil
+	ent.prev = nil
+}
+
+// Keys is a method which returns the keys of this map in the order of their
+// first values.
+func (mm *MultiMap[K, V]) Keys() []K {
+	keys := make([]K, 0, len(mm.m))
+	seen := make(map[K]bool, len(mm.m))
+	for ent := mm.head; ent != nil; ent = ent.next {
+		if !seen[ent.key] {
+			seen[ent.key] = true
+			keys = append(keys, ent.key)
+		}
+	}
+	return keys
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in this map in the order of additions.
+// If fn returns false, this method stops the iteration.
+func (mm *MultiMap[K, V]) Range(fn func(key K, value V) bool) {
+	for ent := mm.head; ent != nil; ent = ent.next {
+		if !fn(ent.key, ent.value) {
+			break
+		}
+	}
+}
+
+// RangeKey is a method which calls the specified function: fn sequentially
+// for each value for a key in the order of additions.
+// If fn returns false, this method stops the iteration.
+func (mm *MultiMap[K, V]) RangeKey(key K, fn func(value V) bool) {
+	for _, ent := range mm.m[key] {
+		if !fn(ent.value) {
+			break
+		}
+	}
+}
+
+// Front is a method which returns the head entry of this map.
+func (mm *MultiMap[K, V]) Front() *Entry[K, V] {
+	return mm.head
+}
+
+// Back is a method which returns the last entry of this map.
+func (mm *MultiMap[K, V]) Back() *Entry[K, V] {
+	return mm.last
+}
+
+// String is a method which returns a string of the content of this map.
+func (mm MultiMap[K, V]) String() string {
+	var buf strings.Builder
+	buf.WriteString("MultiMap[")
+	ent := mm.Front()
+	if ent != nil {
+		buf.WriteString(fmt.Sprintf("%v:%v", ent.Key(), ent.Value()))
+		for ent = ent.Next(); ent != nil; ent = ent.Next() {
+			buf.WriteString(fmt.Sprintf(" %v:%v", ent.Key(), ent.Value()))
+		}
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
@@ -0,0 +1,189 @@
+package orderedmap_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func newHeaders() orderedmap.MultiMap[string, string] {
+	mm := orderedmap.NewMultiMap[string, string]()
+	mm.Add("Received", "from a")
+	mm.Add("Subject", "hello")
+	mm.Add("Received", "from b")
+	mm.Add("To", "x@example.com")
+	mm.Add("Received", "from c")
+	return mm
+}
+
+func TestNewMultiMap(t *testing.T) {
+	mm := orderedmap.NewMultiMap[string, int]()
+	assert.Equal(t, mm.Len(), 0)
+	assert.Equal(t, mm.KeyLen(), 0)
+	assert.Nil(t, mm.Front())
+	assert.Nil(t, mm.Back())
+	assert.Equal(t, mm.String(), "MultiMap[]")
+}
+
+func TestMultiMap_Add(t *testing.T) {
+	mm := newHeaders()
+	assert.Equal(t, mm.Len(), 5)
+	assert.Equal(t, mm.KeyLen(), 3)
+	assert.Equal(t, mm.String(),
+		"MultiMap[Received:from a Subject:hello Received:from b To:x@example.com Received:from c]")
+
+	var zero orderedmap.MultiMap[string, int]
+	zero.Add("a", 1)
+	zero.Add("a", 1)
+	assert.Equal(t, zero.String(), "MultiMap[a:1 a:1]")
+}
+
+func TestMultiMap_GetAll(t *testing.T) {
+	mm := newHeaders()
+	assert.Equal(t, mm.GetAll("Received"), []string{"from a", "from b", "from c"})
+	assert.Equal(t, mm.GetAll("Subject"), []string{"hello"})
+	assert.Nil(t, mm.GetAll("Cc"))
+	assert.True(t, mm.Has("To"))
+	assert.False(t, mm.Has("Cc"))
+}
+
+func TestMultiMap_FirstAndLast(t *testing.T) {
+	mm := newHeaders()
+
+	v, ok := mm.First("Received")
+	assert.True(t, ok)
+	assert.Equal(t, v, "from a")
+
+	v, ok = mm.Last("Received")
+	assert.True(t, ok)
+	assert.Equal(t, v, "from c")
+
+	v, ok = mm.First("Cc")
+	assert.False(t, ok)
+	assert.Equal(t, v, "")
+
+	v, ok = mm.Last("Cc")
+	assert.False(t, ok)
+	assert.Equal(t, v, "")
+}
+
+func TestMultiMap_DeleteAll(t *testing.T) {
+	mm := newHeaders()
+
+	assert.Equal(t, mm.DeleteAll("Received"), 3)
+	assert.Equal(t, mm.Len(), 2)
+	assert.Equal(t, mm.KeyLen(), 2)
+	assert.Equal(t, mm.String(), "MultiMap[Subject:hello To:x@example.com]")
+	assert.False(t, mm.Has("Received"))
+
+	assert.Equal(t, mm.DeleteAll("Received"), 0)
+
+	assert.Equal(t, mm.DeleteAll("Subject"), 1)
+	assert.Equal(t, mm.DeleteAll("To"), 1)
+	assert.Equal(t, mm.Len(), 0)
+	assert.Nil(t, mm.Front())
+	assert.Nil(t, mm.Back())
+
+	mm.Add("Received", "from d")
+	assert.Equal(t, mm.String(), "MultiMap[Received:from d]")
+}
+
+func TestMultiMap_DeleteValue(t *testing.T) {
+	mm := newHeaders()
+	mm.Add("Received", "from b")
+
+	assert.Equal(t, mm.DeleteValue("Received", "from b"), 2)
+	assert.Equal(t, mm.Len(), 4)
+	assert.Equal(t, mm.GetAll("Received"), []string{"from a", "from c"})
+	assert.Equal(t, mm.String(),
+		"MultiMap[Received:from a Subject:hello To:x@example.com Received:from c]")
+
+	assert.Equal(t, mm.DeleteValue("Received", "from z"), 0)
+	assert.Equal(t, mm.DeleteValue("Cc", "from a"), 0)
+
+	assert.Equal(t, mm.DeleteValue("Subject", "hello"), 1)
+	assert.False(t, mm.Has("Subject"))
+	assert.Equal(t, mm.KeyLen(), 2)
+	assert.Equal(t, mm.Front().Value(), "from a")
+	assert.Equal(t, mm.Back().Value(), "from c")
+}
+
+func TestMultiMap_DeleteValue_nonComparableValues(t *testing.T) {
+	mm := orderedmap.NewMultiMap[string, []int]()
+	mm.Add("a", []int{1})
+	mm.Add("a", []int{2})
+
+	assert.Equal(t, mm.DeleteValue("a", []int{1}), 1)
+	assert.Equal(t, mm.GetAll("a"), [][]int{{2}})
+}
+
+func TestMultiMap_Keys(t *testing.T) {
+	mm := newHeaders()
+	assert.Equal(t, mm.Keys(), []string{"Received", "Subject", "To"})
+
+	mm.DeleteValue("Received", "from a")
+	assert.Equal(t, mm.Keys(), []string{"Subject", "Received", "To"})
+}
+
+func TestMultiMap_Range(t *testing.T) {
+	mm := newHeaders()
+
+	var got []string
+	mm.Range(func(key, value string) bool {
+		got = append(got, key+"="+value)
+		return true
+	})
+	assert.Equal(t, got, []string{
+		"Received=from a", "Subject=hello", "Received=from b",
+		"To=x@example.com", "Received=from c",
+	})
+
+	got = nil
+	mm.Range(func(key, value string) bool {
+		got = append(got, key+"="+value)
+		return len(got) < 2
+	})
+	assert.Equal(t, got, []string{"Received=from a", "Subject=hello"})
+}
+
+func TestMultiMap_RangeKey(t *testing.T) {
+	mm := newHeaders()
+
+	var got []string
+	mm.RangeKey("Received", func(value string) bool {
+		got = append(got, value)
+		return true
+	})
+	assert.Equal(t, got, []string{"from a", "from b", "from c"})
+
+	got = nil
+	mm.RangeKey("Received", func(value string) bool {
+		got = append(got, value)
+		return false
+	})
+	assert.Equal(t, got, []string{"from a"})
+
+	mm.RangeKey("Cc", func(value string) bool {
+		assert.Fail(t, "must not be called")
+		return true
+	})
+}
+
+func TestMultiMap_FrontAndBack(t *testing.T) {
+	mm := newHeaders()
+
+	var keys []string
+	for ent := mm.Front(); ent != nil; ent = ent.Next() {
+		keys = append(keys, ent.Key())
+	}
+	assert.Equal(t, keys, []string{"Received", "Subject", "Received", "To", "Received"})
+
+	var values []string
+	for ent := mm.Back(); ent != nil; ent = ent.Prev() {
+		values = append(values, ent.Value())
+	}
+	assert.Equal(t, values, []string{
+		"from c", "x@example.com", "from b", "hello", "from a",
+	})
+}
@@ -0,0 +1,31 @@
+package normalize_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+	"github.com/sttk/orderedmap/normalize"
+)
+
+func ExampleFoldCase() {
+	headers := orderedmap.NewNormalized[string](normalize.FoldCase)
+	headers.Store("Content-Type", "text/plain")
+	headers.Store("content-type", "application/json")
+
+	v, _ := headers.Load("CONTENT-TYPE")
+	fmt.Println(v)
+	fmt.Println(headers.String())
+	// Output:
+	// application/json
+	// NormalizedMap[Content-Type:application/json]
+}
+
+func ExampleNFC() {
+	nm := orderedmap.NewNormalized[int](normalize.NFC)
+	nm.Store("cafe\u0301", 1)
+
+	_, ok := nm.Load("caf\u00e9")
+	fmt.Println(ok)
+	// Output:
+	// true
+}
@@ -0,0 +1,34 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+// Package normalize provides normalize functions of string keys for
+// orderedmap.NewNormalized.
+//
+// This package is separated from orderedmap package so that orderedmap
+// package does not depend on any Unicode text library.
+//
+// # Usage
+//
+// To create a NormalizedMap of which keys are case insensitive is as follows:
+//
+//	nm := orderedmap.NewNormalized[string](normalize.FoldCase)
+package normalize
+
+import (
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// FoldCase is a normalize function for orderedmap.NewNormalized, which makes
+// keys case insensitive with Unicode case folding.
+func FoldCase(s string) string {
+	return cases.Fold().String(s)
+}
+
+// NFC is a normalize function for orderedmap.NewNormalized, which converts
+// keys to Unicode Normalization Form C, so that canonically equivalent keys
+// are same keys.
+func NFC(s string) string {
+	return norm.NFC.String(s)
+}
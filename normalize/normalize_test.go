@@ -0,0 +1,46 @@
+package normalize_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+	"github.com/sttk/orderedmap/normalize"
+)
+
+func TestFoldCase(t *testing.T) {
+	assert.Equal(t, normalize.FoldCase("Content-Type"), "content-type")
+	assert.Equal(t, normalize.FoldCase("Straße"), normalize.FoldCase("STRASSE"))
+
+	nm := orderedmap.NewNormalized[string](normalize.FoldCase)
+	nm.Store("Content-Type", "text/plain")
+	nm.Store("content-type", "application/json")
+	assert.Equal(t, nm.String(), "NormalizedMap[Content-Type:application/json]")
+}
+
+func TestNFC(t *testing.T) {
+	nm := orderedmap.NewNormalized[int](normalize.NFC)
+	composed := "caf\u00e9"
+	decomposed := "cafe\u0301"
+	nm.Store(decomposed, 1)
+	nm.Store(composed, 2)
+
+	assert.Equal(t, nm.Len(), 1)
+	k, _ := nm.Key(composed)
+	assert.Equal(t, k, decomposed)
+	v, _ := nm.Load(composed)
+	assert.Equal(t, v, 2)
+
+	nm.Store("Café", 3)
+	assert.Equal(t, nm.Len(), 2)
+
+	both := orderedmap.NewNormalized[int](func(s string) string {
+		return normalize.FoldCase(normalize.NFC(s))
+	})
+	both.Store(decomposed, 1)
+	both.Store(strings.ToUpper(composed), 2)
+	assert.Equal(t, both.Len(), 1)
+	v, _ = both.Load(decomposed)
+	assert.Equal(t, v, 2)
+}
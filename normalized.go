@@ -0,0 +1,257 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NormalizedMap is a struct which represents an ordered map of which string
+// keys are compared after being normalized, so that keys which are spelled
+// differently, such as "Content-Type" and "content-type", are same keys.
+//
+// This map keeps the spelling of a key at its first insertion, and uses it
+// for iteration, String and MarshalJSON. The spelling is not changed when the
+// value for the key is updated with a differently spelled key, but a key
+// which is stored again after its deletion takes the new spelling.
+//
+// A NormalizedMap has to be created by NewNormalized.
+type NormalizedMap[V any] struct {
+	m         Map[string, normalizedItem[V]]
+	normalize func(string) string
+}
+
+// NormalizedEntry is a struct which is an element of a NormalizedMap and
+// holds a pair of key and value.
+type NormalizedEntry[V any] Entry[string, normalizedItem[V]]
+
+type normalizedItem[V any] struct {
+	key   string
+	value V
+}
+
+// NewNormalized is a function which creates a new NormalizedMap, which is
+// empty. The normalize function converts a key to the form which is used to
+// compare keys. The normalize sub-package provides the functions for case
+// folding and Unicode normalization, and strings.ToLower is also usable.
+func NewNormalized[V any](normalize func(string) string) NormalizedMap[V] {
+	return NormalizedMap[V]{
+		m:         New[string, normalizedItem[V]](),
+		normalize: normalize,
+	}
+}
+
+// Len is a method which returns the number of entries in this map.
+func (nm *NormalizedMap[V]) Len() int {
+	return nm.m.Len()
+}
+
+// Store is a method which sets a value for a key.
+// If the key is present, its position and its spelling are not changed.
+func (nm *NormalizedMap[V]) Store(key string, value V) {
+	nm.Swap(key, value)
+}
+
+// Swap is a method which sets a value for a key. If the key was present, this
+// map returns the previous value and the loaded flag which is set to true.
+func (nm *NormalizedMap[V]) Swap(key string, value V) (previous V, loaded bool) {
+	nm.m.Compute(nm.normalize(key),
+		func(item normalizedItem[V], ok bool) (normalizedItem[V], bool) {
+			if ok {
+				previous, loaded = item.value, true
+				key = item.key
+			}
+			return normalizedItem[V]{key: key, value: value}, true
+		})
+	return
+}
+
+// Load is a method which returns a value stored in this map for a key.
+// If no value was found for a key, the ok result is false.
+func (nm *NormalizedMap[V]) Load(key string) (value V, ok bool) {
+	item, ok := nm.m.Load(nm.normalize(key))
+	return item.value, ok
+}
+
+// Key is a method which returns the spelling of a key in this map.
+// If the key is not present, the ok result is false.
+func (nm *NormalizedMap[V]) Key(key string) (original string, ok bool) {
+	item, ok := nm.m.Load(nm.normalize(key))
+	return item.key, ok
+}
+
+// LoadOrStore is a method which returns a value for a key if presents,
+// otherwise stores and returns a given value.
+// The loaded flag is true if the value was loaded, false if stored.
+func (nm *NormalizedMap[V]) LoadOrStore(key string, value V) (actual V, loaded bool) {
+	item, loaded := nm.m.LoadOrStore(
+		nm.normalize(key), normalizedItem[V]{key: key, value: value})
+	return item.value, loaded
+}
+
+// Delete is a method which deletes a value for a key.
+func (nm *NormalizedMap[V]) Delete(key string) {
+	nm.m.Delete(nm.normalize(key))
+}
+
+// LoadAndDelete is a method which deletes a value for a key, and returns the
+// previous value if any.
+// The loaded flag is true if the key was present.
+func (nm *NormalizedMap[V]) LoadAndDelete(key string) (value V, loaded bool) {
+	item, loaded := nm.m.LoadAndDelete(nm.normalize(key))
+	return item.value, loaded
+}
+
+// Ldelete is a method which logically deletes a value for a key.
+func (nm *NormalizedMap[V]) Ldelete(key string) {
+	nm.m.Ldelete(nm.normalize(key))
+}
+
+// LoadAndLdelete is a method which logically deletes a value for a key, and
+// returns the previous value if any.
+// The loaded flag is true if the key was present.
+func (nm *NormalizedMap[V]) LoadAndLdelete(key string) (value V, loaded bool) {
+	item, loaded := nm.m.LoadAndLdelete(nm.normalize(key))
+	return item.value, loaded
+}
+
+// Clear is a method which deletes all entries of this map.
+func (nm *NormalizedMap[V]) Clear() {
+	nm.m.Clear()
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in this map in the order of key insertions. The key is
+// passed in its spelling at the first insertion.
+// If fn returns false, this method stops the iteration.
+func (nm *NormalizedMap[V]) Range(fn func(key string, value V) bool) {
+	nm.m.Range(func(_ string, item normalizedItem[V]) bool {
+		return fn(item.key, item.value)
+	})
+}
+
+// Front is a method which returns the head entry of this map.
+func (nm *NormalizedMap[V]) Front() *NormalizedEntry[V] {
+	return (*NormalizedEntry[V])(nm.m.Front())
+}
+
+// Back is a method which returns the last entry of this map.
+func (nm *NormalizedMap[V]) Back() *NormalizedEntry[V] {
+	return (*NormalizedEntry[V])(nm.m.Back())
+}
+
+// Prev is a method which returns the previous entry of this entry.
+// If this entry is a head entry of a map, the returned value is nil.
+func (ent *NormalizedEntry[V]) Prev() *NormalizedEntry[V] {
+	return (*NormalizedEntry[V])(ent.prev)
+}
+
+// Next is a method which returns the next entry of this entry.
+// If this entry is a last entry of a map, the returned value is nil.
+func (ent *NormalizedEntry[V]) Next() *NormalizedEntry[V] {
+	return (*NormalizedEntry[V])(ent.next)
+}
+
+// Key is a method which returns the key of this entry in its spelling at the
+// first insertion.
+func (ent *NormalizedEntry[V]) Key() string {
+	return ent.value.key
+}
+
+// Value is a method which returns the value of this entry.
+func (ent *NormalizedEntry[V]) Value() V {
+	return ent.value.value
+}
+
+// String is a method which returns a string of the content of this map.
+func (nm NormalizedMap[V]) String() string {
+	var buf strings.Builder
+	buf.WriteString("NormalizedMap[")
+	first := true
+	nm.Range(func(key string, value V) bool {
+		if !first {
+			buf.WriteString(" ")
+		}
+		first = false
+		buf.WriteString(fmt.Sprintf("%v:%v", key, value))
+		return true
+	})
+	buf.WriteString("]")
+	return buf.String()
+}
+
+// MarshalJSON returns a byte array of JSON string which expresses the content
+// of this map. The keys are written in their spellings at the first
+// insertions.
+func (nm NormalizedMap[V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+
+	var err error
+	first := true
+	nm.Range(func(key string, value V) bool {
+		if !first {
+			buf.WriteString(",")
+		}
+		first = false
+		addJsonString(&buf, key)
+		buf.WriteString(":")
+		err = addJsonValue(&buf, value)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON sets the content of this map from a JSON data.
+// If the JSON object has keys which are same after normalization, the value
+// of the last one is stored with the spelling of the first one.
+func (nm *NormalizedMap[V]) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return SyntaxError{
+			Offset: 0,
+			msg:    "The input JSON does not start with '{'",
+		}
+	}
+
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		var val V
+		err = dec.Decode(&val)
+		if err != nil {
+			return err
+		}
+		nm.Store(tok.(string), val)
+	}
+
+	tok, err = dec.Token()
+	if err != nil || tok != json.Delim('}') {
+		return SyntaxError{
+			Offset: dec.InputOffset(),
+			msg:    "The input JSON does not end with '}'",
+		}
+	}
+	return nil
+}
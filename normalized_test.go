@@ -0,0 +1,170 @@
+package orderedmap_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func TestNewNormalized(t *testing.T) {
+	nm := orderedmap.NewNormalized[int](strings.ToLower)
+	assert.Equal(t, nm.Len(), 0)
+	assert.Equal(t, nm.String(), "NormalizedMap[]")
+	bs, err := nm.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), "{}")
+}
+
+func TestNormalizedMap_caseInsensitive(t *testing.T) {
+	nm := orderedmap.NewNormalized[string](strings.ToLower)
+	nm.Store("Content-Type", "text/plain")
+	nm.Store("Accept", "*/*")
+	nm.Store("content-type", "application/json")
+
+	assert.Equal(t, nm.Len(), 2)
+	assert.Equal(t, nm.String(),
+		"NormalizedMap[Content-Type:application/json Accept:*/*]")
+
+	v, ok := nm.Load("CONTENT-TYPE")
+	assert.True(t, ok)
+	assert.Equal(t, v, "application/json")
+	k, ok := nm.Key("content-TYPE")
+	assert.True(t, ok)
+	assert.Equal(t, k, "Content-Type")
+	k, ok = nm.Key("x")
+	assert.False(t, ok)
+	assert.Equal(t, k, "")
+
+	prev, loaded := nm.Swap("ACCEPT", "text/html")
+	assert.True(t, loaded)
+	assert.Equal(t, prev, "*/*")
+	prev, loaded = nm.Swap("Host", "example.com")
+	assert.False(t, loaded)
+	assert.Equal(t, prev, "")
+
+	actual, loaded := nm.LoadOrStore("host", "x")
+	assert.True(t, loaded)
+	assert.Equal(t, actual, "example.com")
+	actual, loaded = nm.LoadOrStore("X-Id", "1")
+	assert.False(t, loaded)
+	assert.Equal(t, actual, "1")
+
+	assert.Equal(t, nm.String(), "NormalizedMap[Content-Type:application/json "+
+		"Accept:text/html Host:example.com X-Id:1]")
+}
+
+func TestNormalizedMap_delete(t *testing.T) {
+	nm := orderedmap.NewNormalized[int](strings.ToLower)
+	nm.Store("A", 1)
+	nm.Store("B", 2)
+	nm.Store("C", 3)
+
+	nm.Delete("b")
+	nm.Delete("x")
+	assert.Equal(t, nm.String(), "NormalizedMap[A:1 C:3]")
+
+	v, loaded := nm.LoadAndDelete("a")
+	assert.True(t, loaded)
+	assert.Equal(t, v, 1)
+	v, loaded = nm.LoadAndDelete("a")
+	assert.False(t, loaded)
+	assert.Equal(t, v, 0)
+
+	nm.Store("a", 4)
+	assert.Equal(t, nm.String(), "NormalizedMap[C:3 a:4]")
+
+	var keys []string
+	nm.Range(func(k string, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	assert.Equal(t, keys, []string{"C", "a"})
+
+	nm.Clear()
+	assert.Equal(t, nm.Len(), 0)
+}
+
+func TestNormalizedMap_MarshalJSON(t *testing.T) {
+	nm := orderedmap.NewNormalized[any](strings.ToLower)
+	nm.Store("Content-Type", "text/plain")
+	nm.Store(`X-"Quoted"`, 1)
+	nm.Store("content-type", []int{1, 2})
+
+	bs, err := nm.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), `{"Content-Type":[1,2],"X-\"Quoted\"":1}`)
+
+	nm.Store("bad", func() {})
+	_, err = nm.MarshalJSON()
+	assert.NotNil(t, err)
+
+	nm2 := orderedmap.NewNormalized[int](strings.ToLower)
+	nm2.Store("<A&B>", 1)
+	bs, err = nm2.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), `{"<A&B>":1}`)
+}
+
+func TestNormalizedMap_ldelete(t *testing.T) {
+	nm := orderedmap.NewNormalized[int](strings.ToLower)
+	nm.Store("A", 1)
+	nm.Store("B", 2)
+	nm.Store("C", 3)
+
+	nm.Ldelete("b")
+	nm.Ldelete("x")
+	assert.Equal(t, nm.String(), "NormalizedMap[A:1 C:3]")
+	assert.Equal(t, nm.Len(), 2)
+
+	v, loaded := nm.LoadAndLdelete("a")
+	assert.True(t, loaded)
+	assert.Equal(t, v, 1)
+	v, loaded = nm.LoadAndLdelete("a")
+	assert.False(t, loaded)
+	assert.Equal(t, v, 0)
+
+	nm.Store("b", 4)
+	assert.Equal(t, nm.String(), "NormalizedMap[C:3 b:4]")
+}
+
+func TestNormalizedMap_FrontAndBack(t *testing.T) {
+	nm := orderedmap.NewNormalized[int](strings.ToLower)
+	assert.Nil(t, nm.Front())
+	assert.Nil(t, nm.Back())
+
+	nm.Store("A", 1)
+	nm.Store("B", 2)
+	nm.Store("C", 3)
+	nm.Store("a", 10)
+
+	var fwd, bwd []string
+	for ent := nm.Front(); ent != nil; ent = ent.Next() {
+		fwd = append(fwd, fmt.Sprintf("%s:%d", ent.Key(), ent.Value()))
+	}
+	for ent := nm.Back(); ent != nil; ent = ent.Prev() {
+		bwd = append(bwd, fmt.Sprintf("%s:%d", ent.Key(), ent.Value()))
+	}
+	assert.Equal(t, fwd, []string{"A:10", "B:2", "C:3"})
+	assert.Equal(t, bwd, []string{"C:3", "B:2", "A:10"})
+}
+
+func TestNormalizedMap_UnmarshalJSON(t *testing.T) {
+	nm := orderedmap.NewNormalized[int](strings.ToLower)
+	err := nm.UnmarshalJSON([]byte(`{"Accept":1, "Host":2, "ACCEPT":3}`))
+	assert.Nil(t, err)
+	assert.Equal(t, nm.String(), "NormalizedMap[Accept:3 Host:2]")
+
+	bs, err := nm.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), `{"Accept":3,"Host":2}`)
+
+	nm = orderedmap.NewNormalized[int](strings.ToLower)
+	assert.Nil(t, nm.UnmarshalJSON([]byte(``)))
+	err = nm.UnmarshalJSON([]byte(`[1]`))
+	assert.Equal(t, err.Error(), "The input JSON does not start with '{' (offset:0)")
+	err = nm.UnmarshalJSON([]byte(`{"a":"x"}`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,266 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+// EventType is a type which represents the kind of a change of a Map.
+type EventType int
+
+const (
+	// EventInsert is an EventType for an entry which is added, including an
+	// entry for a key which was logically deleted.
+	EventInsert EventType = iota
+
+	// EventUpdate is an EventType for a value of an entry which is replaced.
+	EventUpdate
+
+	// EventLdelete is an EventType for an entry which is logically deleted.
+	EventLdelete
+
+	// EventDelete is an EventType for an entry which is deleted, including an
+	// entry which was already logically deleted.
+	EventDelete
+
+	// EventMove is an EventType for an entry of which position is changed.
+	EventMove
+
+	// EventClear is an EventType for all entries which are deleted at once.
+	EventClear
+)
+
+// String is a method which returns the name of this event type.
+func (t EventType) String() string {
+	switch t {
+	case EventInsert:
+		return "insert"
+	case EventUpdate:
+		return "update"
+	case EventLdelete:
+		return "ldelete"
+	case EventDelete:
+		return "delete"
+	case EventMove:
+		return "move"
+	case EventClear:
+		return "clear"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a struct which represents a change of a Map or a HashMap and is
+// passed to the functions registered by Observe.
+//
+// Position is the index of the entry from the head after the change, or
+// before the change for a deletion. It is -1 for EventClear and for a
+// deletion of an entry which was already logically deleted.
+// OldPosition is the index before the change for EventMove, and -1 for other
+// events.
+// OldValue is the zero value for EventInsert, and NewValue is the zero value
+// for deletions.
+type Event[K any, V any] struct {
+	Type        EventType
+	Key         K
+	OldValue    V
+	NewValue    V
+	Position    int
+	OldPosition int
+}
+
+// observers is a struct which holds the functions registered to a map, and
+// the events which are pending while the map is in a batch.
+type observers[K comparable, V any] struct {
+	subs    []observer[K, V]
+	nextID  int
+	depth   int
+	pending []Event[K, V]
+	capture bool
+}
+
+type observer[K comparable, V any] struct {
+	id      int
+	fn      func(Event[K, V])
+	batchFn func([]Event[K, V])
+}
+
+// Observe is a method which registers the specified function: fn to be called
+// for each change of this map, and returns a function to unregister it.
+// The functions are called synchronously in the order of registrations, and
+// receive events in the order of changes.
+// While any function is registered, each change costs O(n) time to compute
+// the position of the entry.
+func (om *Map[K, V]) Observe(fn func(Event[K, V])) (unsubscribe func()) {
+	return om.subscribe(observer[K, V]{fn: fn})
+}
+
+// ObserveBatch is a method which registers the specified function: fn to be
+// called with events of changes of this map, and returns a function to
+// unregister it.
+// The events of changes in a Batch are passed to fn at once at the end of the
+// batch, and each event of other changes is passed alone.
+func (om *Map[K, V]) ObserveBatch(fn func([]Event[K, V])) (unsubscribe func()) {
+	return om.subscribe(observer[K, V]{batchFn: fn})
+}
+
+func (om *Map[K, V]) subscribe(o observer[K, V]) func() {
+	if om.obs == nil {
+		om.obs = &observers[K, V]{}
+	}
+	obs := om.obs
+	o.id = obs.nextID
+	obs.nextID++
+	obs.subs = append(obs.subs, o)
+
+	return func() {
+		for i, s := range obs.subs {
+			if s.id == o.id {
+				subs := make([]observer[K, V], 0, len(obs.subs)-1)
+				subs = append(subs, obs.subs[:i]...)
+				obs.subs = append(subs, obs.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Batch is a method which calls the specified function: fn, and defers the
+// delivery of events of changes in fn until fn returns.
+// Batches can be nested, and the events are delivered at the end of the
+// outermost batch.
+func (om *Map[K, V]) Batch(fn func()) {
+	if om.obs == nil {
+		om.obs = &observers[K, V]{}
+	}
+	obs := om.obs
+	obs.depth++
+	defer func() {
+		obs.depth--
+		if obs.depth == 0 && len(obs.pending) > 0 {
+			events := obs.pending
+			obs.pending = nil
+			obs.deliver(events)
+		}
+	}()
+	fn()
+}
+
+// Observe is a method which registers the specified function: fn to be called
+// for each change of this map like Map.Observe, and returns a function to
+// unregister it.
+func (hm *HashMap[K, V]) Observe(fn func(Event[K, V])) (unsubscribe func()) {
+	return hm.om.Observe(func(ev Event[*hashKey[K], V]) {
+		fn(hashEvent(ev))
+	})
+}
+
+// ObserveBatch is a method which registers the specified function: fn to be
+// called with events of changes of this map like Map.ObserveBatch, and
+// returns a function to unregister it.
+func (hm *HashMap[K, V]) ObserveBatch(fn func([]Event[K, V])) (unsubscribe func()) {
+	return hm.om.ObserveBatch(func(evs []Event[*hashKey[K], V]) {
+		events := make([]Event[K, V], len(evs))
+		for i, ev := range evs {
+			events[i] = hashEvent(ev)
+		}
+		fn(events)
+	})
+}
+
+// Batch is a method which calls the specified function: fn, and defers the
+// delivery of events of changes in fn until fn returns, like Map.Batch.
+func (hm *HashMap[K, V]) Batch(fn func()) {
+	hm.om.Batch(fn)
+}
+
+// hashEvent converts an event of the inner map of a HashMap to an event with
+// the original key. The key of EventClear is the zero value.
+func hashEvent[K any, V any](ev Event[*hashKey[K], V]) Event[K, V] {
+	var key K
+	if ev.Key != nil {
+		key = ev.Key.key
+	}
+	return Event[K, V]{
+		Type:        ev.Type,
+		Key:         key,
+		OldValue:    ev.OldValue,
+		NewValue:    ev.NewValue,
+		Position:    ev.Position,
+		OldPosition: ev.OldPosition,
+	}
+}
+
+func (obs *observers[K, V]) deliver(events []Event[K, V]) {
+	for _, s := range obs.subs {
+		if s.batchFn != nil {
+			s.batchFn(events)
+			continue
+		}
+		for _, ev := range events {
+			s.fn(ev)
+		}
+	}
+}
+
+// observed is a method which reports whether changes of this map have to be
+// notified, so that positions of entries are computed only if necessary.
+func (om *Map[K, V]) observed() bool {
+	return om.obs != nil && (len(om.obs.subs) > 0 || om.obs.capture)
+}
+
+// indexOf is a method which returns the index of an entry from the head if
+// this map is observed, otherwise returns -1.
+func (om *Map[K, V]) indexOf(ent *Entry[K, V]) int {
+	if !om.observed() {
+		return -1
+	}
+	i := 0
+	for e := om.head; e != nil; e = e.next {
+		if e == ent {
+			return i
+		}
+		i++
+	}
+	return -1
+}
+
+// emit is a method which notifies an event to the registered functions, or
+// holds it until the end of the current batch.
+func (om *Map[K, V]) emit(ev Event[K, V]) {
+	if !om.observed() {
+		return
+	}
+	obs := om.obs
+	if obs.depth > 0 || obs.capture {
+		obs.pending = append(obs.pending, ev)
+		return
+	}
+	obs.deliver([]Event[K, V]{ev})
+}
+
+func (om *Map[K, V]) emitInsert(key K, value V) {
+	om.emit(Event[K, V]{
+		Type: EventInsert, Key: key, NewValue: value,
+		Position: om.len - 1, OldPosition: -1,
+	})
+}
+
+func (om *Map[K, V]) emitUpdate(ent *Entry[K, V], old V) {
+	om.emit(Event[K, V]{
+		Type: EventUpdate, Key: ent.key, OldValue: old, NewValue: ent.value,
+		Position: om.indexOf(ent), OldPosition: -1,
+	})
+}
+
+func (om *Map[K, V]) emitDelete(typ EventType, key K, old V, pos int) {
+	om.emit(Event[K, V]{
+		Type: typ, Key: key, OldValue: old, Position: pos, OldPosition: -1,
+	})
+}
+
+func (om *Map[K, V]) emitMove(ent *Entry[K, V], oldPos int) {
+	om.emit(Event[K, V]{
+		Type: EventMove, Key: ent.key, OldValue: ent.value, NewValue: ent.value,
+		Position: om.indexOf(ent), OldPosition: oldPos,
+	})
+}
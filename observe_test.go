@@ -0,0 +1,224 @@
+package orderedmap_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func eventString(ev orderedmap.Event[string, int]) string {
+	return fmt.Sprintf("%v %s %d->%d @%d<-%d",
+		ev.Type, ev.Key, ev.OldValue, ev.NewValue, ev.Position, ev.OldPosition)
+}
+
+func recordEvents(om *orderedmap.Map[string, int]) (*[]string, func()) {
+	var events []string
+	unsubscribe := om.Observe(func(ev orderedmap.Event[string, int]) {
+		events = append(events, eventString(ev))
+	})
+	return &events, unsubscribe
+}
+
+func TestMap_Observe(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	events, unsubscribe := recordEvents(&om)
+
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+	om.Store("b", 20)
+	om.Swap("a", 10)
+	om.Swap("d", 4)
+	om.LoadOrStore("a", 100)
+	om.LoadOrStore("e", 5)
+	om.LoadOrStoreFunc("f", func() (int, error) { return 6, nil })
+	om.Ldelete("c")
+	om.Delete("c")
+	om.Delete("x")
+	om.LoadAndDelete("d")
+	om.LoadAndLdelete("e")
+	om.Store("e", 50)
+	om.MoveToFront("e")
+	om.MoveToFront("e")
+	om.MoveAfter("a", "f")
+	om.FrontAndDelete()
+	om.BackAndLdelete()
+	om.BackAndDelete()
+	om.FrontAndLdelete()
+	om.Clear()
+	om.Clear()
+
+	assert.Equal(t, *events, []string{
+		"insert a 0->1 @0<--1",
+		"insert b 0->2 @1<--1",
+		"insert c 0->3 @2<--1",
+		"update b 2->20 @1<--1",
+		"update a 1->10 @0<--1",
+		"insert d 0->4 @3<--1",
+		"insert e 0->5 @4<--1",
+		"insert f 0->6 @5<--1",
+		"ldelete c 3->0 @2<--1",
+		"delete c 3->0 @-1<--1",
+		"delete d 4->0 @2<--1",
+		"ldelete e 5->0 @2<--1",
+		"insert e 0->50 @3<--1",
+		"move e 50->50 @0<-3",
+		"move a 10->10 @3<-1",
+		"delete e 50->0 @0<--1",
+		"ldelete a 10->0 @2<--1",
+		"delete f 6->0 @1<--1",
+		"ldelete b 20->0 @0<--1",
+		"clear  0->0 @-1<--1",
+	})
+
+	unsubscribe()
+	om.Store("z", 26)
+	assert.Equal(t, len(*events), 20)
+	unsubscribe()
+}
+
+func TestMap_Observe_sort(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("c", 1)
+	om.Store("a", 2)
+	om.Store("b", 3)
+	events, _ := recordEvents(&om)
+
+	om.SortFunc(func(a, b *orderedmap.Entry[string, int]) bool {
+		return a.Key() < b.Key()
+	})
+	assert.Equal(t, *events, []string{
+		"move a 2->2 @0<-1",
+		"move b 3->3 @1<-2",
+		"move c 1->1 @2<-0",
+	})
+}
+
+func TestMap_Observe_order(t *testing.T) {
+	om := orderedmap.New[string, int]()
+
+	var log []string
+	unsub1 := om.Observe(func(ev orderedmap.Event[string, int]) {
+		log = append(log, "1:"+ev.Key)
+	})
+	om.Observe(func(ev orderedmap.Event[string, int]) {
+		log = append(log, "2:"+ev.Key)
+		if ev.Key == "a" {
+			unsub1()
+		}
+	})
+	om.Observe(func(ev orderedmap.Event[string, int]) {
+		log = append(log, "3:"+ev.Key)
+	})
+
+	om.Store("a", 1)
+	om.Store("b", 2)
+	assert.Equal(t, log, []string{"1:a", "2:a", "3:a", "2:b", "3:b"})
+}
+
+func TestMap_Batch(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	events, _ := recordEvents(&om)
+
+	var batches [][]string
+	om.ObserveBatch(func(evs []orderedmap.Event[string, int]) {
+		var batch []string
+		for _, ev := range evs {
+			batch = append(batch, eventString(ev))
+		}
+		batches = append(batches, batch)
+	})
+
+	om.Store("a", 1)
+	om.Batch(func() {
+		om.Store("b", 2)
+		om.Batch(func() {
+			om.Store("c", 3)
+		})
+		assert.Equal(t, len(*events), 1)
+		om.Delete("a")
+	})
+	om.Batch(func() {})
+
+	assert.Equal(t, batches, [][]string{
+		{"insert a 0->1 @0<--1"},
+		{
+			"insert b 0->2 @1<--1",
+			"insert c 0->3 @2<--1",
+			"delete a 1->0 @0<--1",
+		},
+	})
+	assert.Equal(t, *events, []string{
+		"insert a 0->1 @0<--1",
+		"insert b 0->2 @1<--1",
+		"insert c 0->3 @2<--1",
+		"delete a 1->0 @0<--1",
+	})
+}
+
+func TestMap_Observe_tx(t *testing.T) {
+	om := newABC()
+	events, _ := recordEvents(&om)
+
+	tx := om.Begin()
+	tx.Store("d", 4)
+	tx.Delete("a")
+	assert.Nil(t, tx.Rollback())
+	assert.Equal(t, len(*events), 0)
+
+	tx = om.Begin()
+	tx.Store("d", 4)
+	tx.Delete("a")
+	tx.MoveToFront("c")
+	assert.Equal(t, len(*events), 0)
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, *events, []string{
+		"insert d 0->4 @3<--1",
+		"delete a 1->0 @0<--1",
+		"move c 3->3 @0<-1",
+	})
+}
+
+func TestMap_Observe_history(t *testing.T) {
+	om := newABC()
+	events, _ := recordEvents(&om)
+	h := orderedmap.NewHistory(&om, 0)
+
+	h.Delete("b")
+	h.Undo()
+	assert.Equal(t, *events, []string{
+		"delete b 2->0 @1<--1",
+		"insert b 0->2 @2<--1",
+		"move b 2->2 @1<-2",
+	})
+}
+
+func TestMap_Observe_binary(t *testing.T) {
+	om := newABC()
+	b, err := om.MarshalBinary()
+	assert.Nil(t, err)
+
+	om2 := orderedmap.New[string, int]()
+	om2.Store("x", 0)
+	events, _ := recordEvents(&om2)
+	assert.Nil(t, om2.UnmarshalBinary(b))
+	assert.Equal(t, len(*events), 0)
+	assert.Equal(t, om2.String(), "Map[a:1 b:2 c:3]")
+
+	om2.Store("d", 4)
+	assert.Equal(t, *events, []string{
+		"insert d 0->4 @3<--1",
+	})
+}
+
+func TestEventType_String(t *testing.T) {
+	assert.Equal(t, orderedmap.EventInsert.String(), "insert")
+	assert.Equal(t, orderedmap.EventUpdate.String(), "update")
+	assert.Equal(t, orderedmap.EventLdelete.String(), "ldelete")
+	assert.Equal(t, orderedmap.EventDelete.String(), "delete")
+	assert.Equal(t, orderedmap.EventMove.String(), "move")
+	assert.Equal(t, orderedmap.EventClear.String(), "clear")
+	assert.Equal(t, orderedmap.EventType(99).String(), "unknown")
+}
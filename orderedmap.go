@@ -34,6 +34,45 @@
 //	om.Ldelete("bar")
 //	v, deleted := om.LoadAndLdelete("baz")
 //
+// To update or delete map entries with a function or in bulk is as follows:
+//
+//	om.Compute("foo", func(v string, loaded bool) (string, bool) {
+//	    return v + "!", true  // or return "", false to delete
+//	})
+//	om.Merge("bar", "fuga", func(old, v string) (string, bool) {
+//	    return old + v, true
+//	})
+//	om.StoreAll(other.Range)
+//	n := om.DeleteIf(func(k, v string) bool { return v == "" })
+//
+// To change the position of a map entry is as follows:
+//
+//	om.MoveToFront("foo")
+//	om.MoveAfter("foo", "bar")
+//	om.SortFunc(func(a, b *orderedmap.Entry[string, string]) bool {
+//	    return a.Key() < b.Key()
+//	})
+//
+// To apply multiple updates atomically is as follows:
+//
+//	tx := om.Begin()
+//	tx.Store("foo", "hoge")
+//	tx.Delete("bar")
+//	e := tx.Commit()  // or tx.Rollback()
+//
+// To undo and redo mutations is as follows:
+//
+//	h := orderedmap.NewHistory(&om, 100)
+//	h.Store("foo", "hoge")
+//	h.Undo()
+//	h.Redo()
+//
+// To be notified of changes of this map is as follows:
+//
+//	unsubscribe := om.Observe(func(ev orderedmap.Event[string, string]) {
+//	    ...
+//	})
+//
 // To iterate map entries is as follows. The order is same with key insertions:
 //
 //	om.Range(func(k, v) bool {
@@ -53,10 +92,50 @@
 // To deserialize a JSON string into an ordered map is as follows:
 //
 //	e := om.UnmarshalJSON(byteSeq)
+//
+// To write a JSON string of this map with indentations is as follows:
+//
+//	enc := orderedmap.NewEncoder(w)
+//	enc.SetIndent("", "  ")
+//	e := enc.Encode(om)
+//
+// To read and write ordered maps in the format of JSON Lines is as follows:
+//
+//	orderedmap.ReadJSONLines(r)(func(om *orderedmap.Map[string, any], e error) bool {
+//	    ...
+//	})
+//	e := orderedmap.NewJSONLinesWriter(w).Write(om)
+//
+// To serialize and deserialize this map in MessagePack or CBOR is as follows:
+//
+//	byteSeq, e := om.MarshalMsgpack()
+//	e := om.UnmarshalMsgpack(byteSeq)
+//	byteSeq, e := om.MarshalCBOR()
+//	e := om.UnmarshalCBOR(byteSeq)
+//
+// To serialize and deserialize this map in a binary layout, which is also used
+// by encoding/gob, is as follows:
+//
+//	byteSeq, e := om.MarshalBinary()
+//	e := om.UnmarshalBinary(byteSeq)
+//
+// To scan rows of a SQL query into ordered maps keyed by column names is as
+// follows:
+//
+//	rows, e := db.Query("SELECT ...")
+//	oms, e := orderedmap.ScanAll(rows)
+//
+// To parse and encode a URL query string preserving the order of parameters
+// is as follows:
+//
+//	q, e := orderedmap.ParseQuery("b=1&a=2&b=3")
+//	q.Add("c", "4")
+//	s := q.Encode()  // => "b=1&a=2&b=3&c=4"
 package orderedmap
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -74,6 +153,9 @@ type Map[K comparable, V any] struct {
 	head *Entry[K, V]
 	last *Entry[K, V]
 	len  int
+	mod  uint64
+	cow  *cowToken
+	obs  *observers[K, V]
 }
 
 // Entry is a struct which is a map element and holds a pair of key and value.
@@ -99,10 +181,14 @@ func (om *Map[K, V]) Len() int {
 
 // Store is a method which sets a value for a key
 func (om *Map[K, V]) Store(key K, value V) {
+	om.beforeWrite()
+
 	ent, exists := om.m[key]
 	if exists {
 		if !ent.deleted {
+			old := ent.value
 			ent.value = value
+			om.emitUpdate(ent, old)
 			return
 		}
 		ent.value = value
@@ -116,6 +202,7 @@ func (om *Map[K, V]) Store(key K, value V) {
 		om.last = ent
 		om.m[key] = ent
 		om.len = 1
+		om.emitInsert(key, ent.value)
 		return
 	}
 
@@ -124,18 +211,22 @@ func (om *Map[K, V]) Store(key K, value V) {
 	om.last = ent
 	om.m[key] = ent
 	om.len++
+	om.emitInsert(key, ent.value)
 	return
 }
 
 // Swap is a method which sets a value for a key. If the key was present, this
 // map returns the previous value and the loaded flag which is set to true.
 func (om *Map[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	om.beforeWrite()
+
 	ent, exists := om.m[key]
 	if exists {
 		if !ent.deleted {
 			loaded = true
 			previous = ent.value
 			ent.value = value
+			om.emitUpdate(ent, previous)
 			return
 		}
 		ent.deleted = false
@@ -149,6 +240,7 @@ func (om *Map[K, V]) Swap(key K, value V) (previous V, loaded bool) {
 		om.last = ent
 		om.m[key] = ent
 		om.len = 1
+		om.emitInsert(key, ent.value)
 		return
 	}
 
@@ -157,6 +249,7 @@ func (om *Map[K, V]) Swap(key K, value V) (previous V, loaded bool) {
 	om.last = ent
 	om.m[key] = ent
 	om.len++
+	om.emitInsert(key, ent.value)
 	return
 }
 
@@ -178,12 +271,16 @@ func (om *Map[K, V]) Load(key K) (value V, ok bool) {
 // The loaded flag is true if the value was loaded, false if stored.
 func (om *Map[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
 	ent, exists := om.m[key]
+	if exists && !ent.deleted {
+		actual = ent.value
+		loaded = true
+		return
+	}
+	if om.beforeWrite() {
+		ent = om.m[key]
+	}
+
 	if exists {
-		if !ent.deleted {
-			actual = ent.value
-			loaded = true
-			return
-		}
 		ent.deleted = false
 		ent.value = value
 	} else {
@@ -197,6 +294,7 @@ func (om *Map[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
 		om.last = ent
 		om.m[key] = ent
 		om.len = 1
+		om.emitInsert(key, ent.value)
 		return
 	}
 
@@ -205,6 +303,7 @@ func (om *Map[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
 	om.last = ent
 	om.m[key] = ent
 	om.len++
+	om.emitInsert(key, ent.value)
 
 	return
 }
@@ -218,27 +317,26 @@ func (om *Map[K, V]) LoadOrStoreFunc(
 	fn func() (V, error),
 ) (actual V, loaded bool, err error) {
 	ent, exists := om.m[key]
+	if exists && !ent.deleted {
+		actual = ent.value
+		loaded = true
+		return
+	}
+
+	v, e := fn()
+	if e != nil {
+		err = e
+		return
+	}
+	actual = v
+	if om.beforeWrite() {
+		ent = om.m[key]
+	}
+
 	if exists {
-		if !ent.deleted {
-			actual = ent.value
-			loaded = true
-			return
-		}
 		ent.deleted = false
-		v, e := fn()
-		if e != nil {
-			err = e
-			return
-		}
-		actual = v
 		ent.value = actual
 	} else {
-		v, e := fn()
-		if e != nil {
-			err = e
-			return
-		}
-		actual = v
 		ent = &Entry[K, V]{key: key, value: actual}
 	}
 
@@ -247,6 +345,7 @@ func (om *Map[K, V]) LoadOrStoreFunc(
 		om.last = ent
 		om.m[key] = ent
 		om.len = 1
+		om.emitInsert(key, ent.value)
 		return
 	}
 
@@ -255,6 +354,7 @@ func (om *Map[K, V]) LoadOrStoreFunc(
 	om.last = ent
 	om.m[key] = ent
 	om.len++
+	om.emitInsert(key, ent.value)
 
 	return
 }
@@ -265,12 +365,17 @@ func (om *Map[K, V]) Delete(key K) {
 	if !exists {
 		return
 	}
+	if om.beforeWrite() {
+		ent = om.m[key]
+	}
 
 	delete(om.m, key)
 
 	if ent.deleted {
+		om.emitDelete(EventDelete, key, ent.value, -1)
 		return
 	}
+	pos := om.indexOf(ent)
 	om.len--
 
 	if ent.prev != nil {
@@ -287,18 +392,19 @@ func (om *Map[K, V]) Delete(key K) {
 
 	ent.next = nil
 	ent.prev = nil
+	om.emitDelete(EventDelete, key, ent.value, pos)
 }
 
 // Ldelete is a method which logically deletes a value for a key.
 func (om *Map[K, V]) Ldelete(key K) {
 	ent, exists := om.m[key]
-	if !exists {
+	if !exists || ent.deleted {
 		return
 	}
-
-	if ent.deleted {
-		return
+	if om.beforeWrite() {
+		ent = om.m[key]
 	}
+	pos := om.indexOf(ent)
 	ent.deleted = true
 	om.len--
 
@@ -316,6 +422,7 @@ func (om *Map[K, V]) Ldelete(key K) {
 
 	ent.next = nil
 	ent.prev = nil
+	om.emitDelete(EventLdelete, key, ent.value, pos)
 }
 
 // LoadAndDelete is a method which deletes a value for a key, and returns the
@@ -326,12 +433,17 @@ func (om *Map[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
 	if !exists {
 		return
 	}
+	if om.beforeWrite() {
+		ent = om.m[key]
+	}
 
 	delete(om.m, key)
 
 	if ent.deleted {
+		om.emitDelete(EventDelete, key, ent.value, -1)
 		return
 	}
+	pos := om.indexOf(ent)
 	om.len--
 
 	if ent.prev != nil {
@@ -348,6 +460,7 @@ func (om *Map[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
 
 	ent.next = nil
 	ent.prev = nil
+	om.emitDelete(EventDelete, key, ent.value, pos)
 
 	value = ent.value
 	loaded = true
@@ -359,13 +472,13 @@ func (om *Map[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
 // The loaded flag is true if the key was present.
 func (om *Map[K, V]) LoadAndLdelete(key K) (value V, loaded bool) {
 	ent, exists := om.m[key]
-	if !exists {
+	if !exists || ent.deleted {
 		return
 	}
-
-	if ent.deleted {
-		return
+	if om.beforeWrite() {
+		ent = om.m[key]
 	}
+	pos := om.indexOf(ent)
 	ent.deleted = true
 	om.len--
 
@@ -383,6 +496,7 @@ func (om *Map[K, V]) LoadAndLdelete(key K) (value V, loaded bool) {
 
 	ent.next = nil
 	ent.prev = nil
+	om.emitDelete(EventLdelete, key, ent.value, pos)
 
 	value = ent.value
 	loaded = true
@@ -396,6 +510,9 @@ func (om *Map[K, V]) FrontAndDelete() *Entry[K, V] {
 	if ent == nil {
 		return nil
 	}
+	if om.beforeWrite() {
+		ent = om.head
+	}
 
 	delete(om.m, ent.Key())
 	om.len--
@@ -410,6 +527,7 @@ func (om *Map[K, V]) FrontAndDelete() *Entry[K, V] {
 
 	ent.next = nil
 	ent.prev = nil
+	om.emitDelete(EventDelete, ent.key, ent.value, 0)
 
 	return ent
 }
@@ -422,6 +540,9 @@ func (om *Map[K, V]) FrontAndLdelete() *Entry[K, V] {
 	if ent == nil {
 		return nil
 	}
+	if om.beforeWrite() {
+		ent = om.head
+	}
 
 	ent.deleted = true
 	om.len--
@@ -436,6 +557,7 @@ func (om *Map[K, V]) FrontAndLdelete() *Entry[K, V] {
 
 	ent.next = nil
 	ent.prev = nil
+	om.emitDelete(EventLdelete, ent.key, ent.value, 0)
 
 	return ent
 }
@@ -447,6 +569,9 @@ func (om *Map[K, V]) BackAndDelete() *Entry[K, V] {
 	if ent == nil {
 		return nil
 	}
+	if om.beforeWrite() {
+		ent = om.last
+	}
 
 	delete(om.m, ent.Key())
 	om.len--
@@ -461,6 +586,7 @@ func (om *Map[K, V]) BackAndDelete() *Entry[K, V] {
 
 	ent.next = nil
 	ent.prev = nil
+	om.emitDelete(EventDelete, ent.key, ent.value, om.len)
 
 	return ent
 }
@@ -473,6 +599,9 @@ func (om *Map[K, V]) BackAndLdelete() *Entry[K, V] {
 	if ent == nil {
 		return nil
 	}
+	if om.beforeWrite() {
+		ent = om.last
+	}
 
 	ent.deleted = true
 	om.len--
@@ -487,10 +616,235 @@ func (om *Map[K, V]) BackAndLdelete() *Entry[K, V] {
 
 	ent.next = nil
 	ent.prev = nil
+	om.emitDelete(EventLdelete, ent.key, ent.value, om.len)
 
 	return ent
 }
 
+// MoveToFront is a method which moves an entry for a key to the head of this
+// map.
+// If the key is not present, this method does nothing and returns false.
+func (om *Map[K, V]) MoveToFront(key K) bool {
+	ent, exists := om.m[key]
+	if !exists || ent.deleted {
+		return false
+	}
+	if ent == om.head {
+		return true
+	}
+	oldPos := om.indexOf(ent)
+	if om.beforeWrite() {
+		ent = om.m[key]
+	}
+	om.unlink(ent)
+	om.linkBefore(ent, om.head)
+	om.emitMove(ent, oldPos)
+	return true
+}
+
+// MoveToBack is a method which moves an entry for a key to the end of this
+// map.
+// If the key is not present, this method does nothing and returns false.
+func (om *Map[K, V]) MoveToBack(key K) bool {
+	ent, exists := om.m[key]
+	if !exists || ent.deleted {
+		return false
+	}
+	if ent == om.last {
+		return true
+	}
+	oldPos := om.indexOf(ent)
+	if om.beforeWrite() {
+		ent = om.m[key]
+	}
+	om.unlink(ent)
+	om.linkAfter(ent, om.last)
+	om.emitMove(ent, oldPos)
+	return true
+}
+
+// MoveBefore is a method which moves an entry for a key to the position just
+// before an entry for a mark key.
+// If either key is not present or both keys are same, this method does
+// nothing and returns false.
+func (om *Map[K, V]) MoveBefore(key, mark K) bool {
+	ent, markEnt, ok := om.entriesToMove(key, mark)
+	if !ok {
+		return false
+	}
+	if ent.next == markEnt {
+		return true
+	}
+	oldPos := om.indexOf(ent)
+	if om.beforeWrite() {
+		ent, markEnt = om.m[key], om.m[mark]
+	}
+	om.unlink(ent)
+	om.linkBefore(ent, markEnt)
+	om.emitMove(ent, oldPos)
+	return true
+}
+
+// MoveAfter is a method which moves an entry for a key to the position just
+// after an entry for a mark key.
+// If either key is not present or both keys are same, this method does
+// nothing and returns false.
+func (om *Map[K, V]) MoveAfter(key, mark K) bool {
+	ent, markEnt, ok := om.entriesToMove(key, mark)
+	if !ok {
+		return false
+	}
+	if ent.prev == markEnt {
+		return true
+	}
+	oldPos := om.indexOf(ent)
+	if om.beforeWrite() {
+		ent, markEnt = om.m[key], om.m[mark]
+	}
+	om.unlink(ent)
+	om.linkAfter(ent, markEnt)
+	om.emitMove(ent, oldPos)
+	return true
+}
+
+// SortFunc is a method which sorts the entries of this map with the specified
+// function: less, which reports whether the entry a should be placed before
+// the entry b.
+// The sort is stable, so the entries which are equal keep their order.
+func (om *Map[K, V]) SortFunc(less func(a, b *Entry[K, V]) bool) {
+	if om.len < 2 {
+		return
+	}
+
+	ents := make([]*Entry[K, V], 0, om.len)
+	for ent := om.head; ent != nil; ent = ent.next {
+		ents = append(ents, ent)
+	}
+	order := make([]int, len(ents))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return less(ents[order[i]], ents[order[j]])
+	})
+
+	moved := false
+	for i, o := range order {
+		if o != i {
+			moved = true
+			break
+		}
+	}
+	if !moved {
+		return
+	}
+	if om.beforeWrite() {
+		for i, ent := range ents {
+			ents[i] = om.m[ent.key]
+		}
+	}
+
+	var prev *Entry[K, V]
+	for _, o := range order {
+		ent := ents[o]
+		ent.prev = prev
+		if prev != nil {
+			prev.next = ent
+		}
+		prev = ent
+	}
+	prev.next = nil
+	om.head = ents[order[0]]
+	om.last = prev
+
+	if om.observed() {
+		for i, o := range order {
+			if o != i {
+				ent := ents[o]
+				om.emit(Event[K, V]{
+					Type: EventMove, Key: ent.key,
+					OldValue: ent.value, NewValue: ent.value,
+					Position: i, OldPosition: o,
+				})
+			}
+		}
+	}
+}
+
+// Clear is a method which deletes all entries of this map, including
+// logically deleted entries.
+func (om *Map[K, V]) Clear() {
+	if om.m != nil && len(om.m) == 0 {
+		return
+	}
+	n := len(om.m)
+	om.m = make(map[K](*Entry[K, V]))
+	om.head = nil
+	om.last = nil
+	om.len = 0
+	om.mod++
+	om.cow = nil
+
+	if n > 0 {
+		om.emit(Event[K, V]{Type: EventClear, Position: -1, OldPosition: -1})
+	}
+}
+
+func (om *Map[K, V]) entriesToMove(key, mark K) (ent, markEnt *Entry[K, V], ok bool) {
+	if key == mark {
+		return
+	}
+	ent, exists := om.m[key]
+	if !exists || ent.deleted {
+		return
+	}
+	markEnt, exists = om.m[mark]
+	if !exists || markEnt.deleted {
+		return
+	}
+	ok = true
+	return
+}
+
+func (om *Map[K, V]) unlink(ent *Entry[K, V]) {
+	if ent.prev != nil {
+		ent.prev.next = ent.next
+	} else {
+		om.head = ent.next
+	}
+
+	if ent.next != nil {
+		ent.next.prev = ent.prev
+	} else {
+		om.last = ent.prev
+	}
+
+	ent.next = nil
+	ent.prev = nil
+}
+
+func (om *Map[K, V]) linkBefore(ent, mark *Entry[K, V]) {
+	ent.next = mark
+	ent.prev = mark.prev
+	if mark.prev != nil {
+		mark.prev.next = ent
+	} else {
+		om.head = ent
+	}
+	mark.prev = ent
+}
+
+func (om *Map[K, V]) linkAfter(ent, mark *Entry[K, V]) {
+	ent.prev = mark
+	ent.next = mark.next
+	if mark.next != nil {
+		mark.next.prev = ent
+	} else {
+		om.last = ent
+	}
+	mark.next = ent
+}
+
 // Range is a method which calls the specified function: fn sequentially for
 // each key and value in this map.
 // If fn returns false, this method stops the iteration.
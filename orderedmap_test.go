@@ -1731,3 +1731,170 @@ func TestBackAndLdelete(t *testing.T) {
 	ent = om.BackAndLdelete()
 	assert.Nil(t, ent)
 }
+
+func keysOf(om *orderedmap.Map[string, int]) string {
+	s := ""
+	for ent := om.Front(); ent != nil; ent = ent.Next() {
+		s += ent.Key()
+	}
+	s += "/"
+	for ent := om.Back(); ent != nil; ent = ent.Prev() {
+		s += ent.Key()
+	}
+	return s
+}
+
+func TestMoveToFront(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	assert.False(t, om.MoveToFront("a"))
+
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+	om.Store("d", 4)
+	om.Ldelete("d")
+
+	assert.True(t, om.MoveToFront("c"))
+	assert.Equal(t, keysOf(&om), "cab/bac")
+	assert.True(t, om.MoveToFront("c"))
+	assert.Equal(t, keysOf(&om), "cab/bac")
+	assert.True(t, om.MoveToFront("a"))
+	assert.Equal(t, keysOf(&om), "acb/bca")
+	assert.False(t, om.MoveToFront("d"))
+	assert.False(t, om.MoveToFront("x"))
+	assert.Equal(t, om.Len(), 3)
+}
+
+func TestMoveToBack(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	assert.False(t, om.MoveToBack("a"))
+
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+
+	assert.True(t, om.MoveToBack("a"))
+	assert.Equal(t, keysOf(&om), "bca/acb")
+	assert.True(t, om.MoveToBack("a"))
+	assert.Equal(t, keysOf(&om), "bca/acb")
+	assert.True(t, om.MoveToBack("c"))
+	assert.Equal(t, keysOf(&om), "bac/cab")
+	assert.False(t, om.MoveToBack("x"))
+
+	om.Store("d", 4)
+	assert.Equal(t, keysOf(&om), "bacd/dcab")
+}
+
+func TestMoveBefore(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+	om.Store("d", 4)
+
+	assert.True(t, om.MoveBefore("d", "b"))
+	assert.Equal(t, keysOf(&om), "adbc/cbda")
+	assert.True(t, om.MoveBefore("c", "a"))
+	assert.Equal(t, keysOf(&om), "cadb/bdac")
+	assert.True(t, om.MoveBefore("c", "b"))
+	assert.Equal(t, keysOf(&om), "adcb/bcda")
+	assert.True(t, om.MoveBefore("c", "b"))
+	assert.Equal(t, keysOf(&om), "adcb/bcda")
+	assert.False(t, om.MoveBefore("c", "c"))
+	assert.False(t, om.MoveBefore("c", "x"))
+	assert.False(t, om.MoveBefore("x", "c"))
+}
+
+func TestMoveAfter(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+	om.Store("d", 4)
+
+	assert.True(t, om.MoveAfter("a", "c"))
+	assert.Equal(t, keysOf(&om), "bcad/dacb")
+	assert.True(t, om.MoveAfter("b", "d"))
+	assert.Equal(t, keysOf(&om), "cadb/bdac")
+	assert.True(t, om.MoveAfter("b", "d"))
+	assert.Equal(t, keysOf(&om), "cadb/bdac")
+	assert.True(t, om.MoveAfter("c", "a"))
+	assert.Equal(t, keysOf(&om), "acdb/bdca")
+	assert.False(t, om.MoveAfter("a", "a"))
+	assert.False(t, om.MoveAfter("a", "x"))
+
+	om.Ldelete("d")
+	assert.False(t, om.MoveAfter("d", "a"))
+	assert.False(t, om.MoveAfter("a", "d"))
+	assert.Equal(t, keysOf(&om), "acb/bca")
+}
+
+func TestMove_snapshot(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+
+	s := om.Snapshot()
+	om.MoveToFront("c")
+	om.MoveAfter("a", "b")
+	assert.Equal(t, keysOf(&om), "cba/abc")
+	assert.Equal(t, s.String(), "Snapshot[a:1 b:2 c:3]")
+}
+
+func TestSortFunc(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.SortFunc(func(a, b *orderedmap.Entry[string, int]) bool {
+		return a.Key() < b.Key()
+	})
+	assert.Equal(t, om.Len(), 0)
+
+	om.Store("c", 1)
+	om.Store("a", 2)
+	om.Store("d", 1)
+	om.Store("b", 2)
+	om.Store("e", 0)
+	om.Ldelete("e")
+
+	om.SortFunc(func(a, b *orderedmap.Entry[string, int]) bool {
+		return a.Key() < b.Key()
+	})
+	assert.Equal(t, keysOf(&om), "abcd/dcba")
+
+	om.SortFunc(func(a, b *orderedmap.Entry[string, int]) bool {
+		return a.Value() > b.Value()
+	})
+	assert.Equal(t, keysOf(&om), "abcd/dcba")
+	assert.Equal(t, om.String(), "Map[a:2 b:2 c:1 d:1]")
+
+	om.Store("e", 3)
+	assert.Equal(t, om.String(), "Map[a:2 b:2 c:1 d:1 e:3]")
+	om.SortFunc(func(a, b *orderedmap.Entry[string, int]) bool {
+		return a.Value() < b.Value()
+	})
+	assert.Equal(t, keysOf(&om), "cdabe/ebadc")
+}
+
+func TestClear(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Ldelete("b")
+	s := om.Snapshot()
+
+	om.Clear()
+	assert.Equal(t, om.Len(), 0)
+	assert.Nil(t, om.Front())
+	assert.Nil(t, om.Back())
+	_, ok := om.Load("a")
+	assert.False(t, ok)
+	assert.Equal(t, s.String(), "Snapshot[a:1]")
+
+	om.Store("b", 20)
+	assert.Equal(t, om.String(), "Map[b:20]")
+
+	var zero orderedmap.Map[string, int]
+	zero.Clear()
+	zero.Store("a", 1)
+	assert.Equal(t, zero.String(), "Map[a:1]")
+}
@@ -0,0 +1,27 @@
+package persist_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sttk/orderedmap/persist"
+)
+
+func ExampleOpen() {
+	dir, _ := os.MkdirTemp("", "persist")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "data.log")
+
+	dm, _ := persist.Open[string, int](path, nil)
+	dm.Store("b", 2)
+	dm.Store("a", 1)
+	dm.MoveToFront("a")
+	dm.Close()
+
+	dm, _ = persist.Open[string, int](path, nil)
+	defer dm.Close()
+	fmt.Printf("dm = %v\n", dm)
+	// Output:
+	// dm = DurableMap[a:1 b:2]
+}
@@ -0,0 +1,140 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package persist
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// logHeader is the bytes at the beginning of a log file.
+var logHeader = []byte("omwal\x00\x00\x01")
+
+// The operation codes of log records.
+const (
+	opStore      byte = 's'
+	opDelete     byte = 'd'
+	opMoveFront  byte = 'f'
+	opMoveBack   byte = 'b'
+	opMoveBefore byte = 'B'
+	opMoveAfter  byte = 'A'
+	opClear      byte = 'c'
+)
+
+// recordHeaderSize is the size of the length and the checksum of a record.
+const recordHeaderSize = 8
+
+// maxRecordSize is the limit of a record payload, which prevents a corrupted
+// length from causing a huge allocation.
+const maxRecordSize = 1 << 30
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrCorruptRecord is an error which is held by a RecordError when a record
+// in the middle of a log is corrupted.
+var ErrCorruptRecord = errors.New("corrupted record")
+
+// errTornRecord is an error which is returned by readRecord when a record is
+// cut off by the end of a log.
+var errTornRecord = errors.New("torn record")
+
+// record is a mutation in a log. Its args are JSON texts of keys and values.
+type record struct {
+	op   byte
+	args [][]byte
+}
+
+// appendRecord is a function which appends the encoded bytes of a record to
+// buf. A record is laid out as follows:
+//
+//	payload length (uint32, little endian)
+//	CRC-32C of payload (uint32, little endian)
+//	payload: op code, and for each argument, its length (uvarint) and bytes
+func appendRecord(buf []byte, rec record) []byte {
+	start := len(buf)
+	buf = append(buf, make([]byte, recordHeaderSize)...)
+	buf = append(buf, rec.op)
+	var lenBuf [binary.MaxVarintLen64]byte
+	for _, arg := range rec.args {
+		n := binary.PutUvarint(lenBuf[:], uint64(len(arg)))
+		buf = append(buf, lenBuf[:n]...)
+		buf = append(buf, arg...)
+	}
+	payload := buf[start+recordHeaderSize:]
+	binary.LittleEndian.PutUint32(buf[start:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[start+4:], crc32.Checksum(payload, crcTable))
+	return buf
+}
+
+// readRecord is a function which reads a record from r and returns it with
+// its size in bytes.
+// If r is at its end, this function returns io.EOF. If a record is cut off by
+// the end of r, this function returns errTornRecord, and if a record is
+// corrupted, this function returns ErrCorruptRecord.
+func readRecord(r *bufio.Reader) (record, int, error) {
+	var head [recordHeaderSize]byte
+	n, err := io.ReadFull(r, head[:])
+	if err == io.EOF {
+		return record{}, 0, io.EOF
+	}
+	if err == io.ErrUnexpectedEOF {
+		return record{}, 0, errTornRecord
+	}
+	if err != nil {
+		return record{}, 0, err
+	}
+
+	size := binary.LittleEndian.Uint32(head[:4])
+	if size == 0 || size > maxRecordSize {
+		return record{}, 0, ErrCorruptRecord
+	}
+	payload := make([]byte, size)
+	m, err := io.ReadFull(r, payload)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return record{}, 0, errTornRecord
+	}
+	if err != nil {
+		return record{}, 0, err
+	}
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(head[4:]) {
+		return record{}, 0, ErrCorruptRecord
+	}
+
+	rec, ok := decodePayload(payload)
+	if !ok {
+		return record{}, 0, ErrCorruptRecord
+	}
+	return rec, n + m, nil
+}
+
+func decodePayload(payload []byte) (record, bool) {
+	rec := record{op: payload[0]}
+	var nargs int
+	switch rec.op {
+	case opStore, opMoveBefore, opMoveAfter:
+		nargs = 2
+	case opDelete, opMoveFront, opMoveBack:
+		nargs = 1
+	case opClear:
+		nargs = 0
+	default:
+		return rec, false
+	}
+
+	rest := payload[1:]
+	for i := 0; i < nargs; i++ {
+		l, n := binary.Uvarint(rest)
+		if n <= 0 || l > uint64(len(rest)-n) {
+			return rec, false
+		}
+		rest = rest[n:]
+		rec.args = append(rec.args, rest[:l])
+		rest = rest[l:]
+	}
+	return rec, len(rest) == 0
+}
@@ -0,0 +1,671 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+// Package persist provides DurableMap which is an ordered map of which
+// content survives process restarts without a database.
+//
+// A DurableMap appends every mutation to a write-ahead log file before
+// applying it to the map in memory, and replays the log when it is opened.
+// When the log grows past a threshold, it is compacted into a snapshot which
+// has one record for each entry.
+//
+// Each record of a log has its length and its checksum, so an incomplete
+// record at the end of a log, which is left by a crash in the middle of a
+// write, is detected and truncated on open. A corrupted record in the middle
+// of a log is reported as an error instead, unless Options.TruncateCorrupted
+// is set. Keys and values are encoded in JSON in records, so they must be
+// serializable with encoding/json.
+//
+// # Usage
+//
+// To open a durable map is as follows:
+//
+//	dm, e := persist.Open[string, int]("data.log", nil)
+//	defer dm.Close()
+//
+// To update the map is as follows:
+//
+//	e := dm.Store("foo", 1)
+//	e := dm.Delete("bar")
+//	moved, e := dm.MoveToFront("foo")
+//
+// To specify the policy of fsync and the threshold of compaction is as
+// follows:
+//
+//	dm, e := persist.Open[string, int]("data.log", &persist.Options{
+//	    Sync:             persist.SyncInterval,
+//	    SyncInterval:     time.Second,
+//	    CompactThreshold: 1 << 20,
+//	})
+package persist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/sttk/orderedmap"
+)
+
+// SyncPolicy is a type which specifies when a DurableMap flushes its log file
+// to a storage device with fsync.
+type SyncPolicy int
+
+const (
+	// SyncAlways is a SyncPolicy which flushes the log after each mutation.
+	// No mutation is lost by a crash of the process or the operating system.
+	SyncAlways SyncPolicy = iota
+
+	// SyncInterval is a SyncPolicy which flushes the log at a mutation if
+	// Options.SyncInterval has passed since the last flush. Mutations after
+	// the last flush can be lost by a crash of the operating system.
+	// A DurableMap has no background timer, so the log is not flushed while
+	// no mutation is made, even after the interval has passed. To flush
+	// the last mutations of an idle map, call Sync or Close.
+	SyncInterval
+
+	// SyncNever is a SyncPolicy which flushes the log only by Sync, Compact
+	// and Close, and leaves it to the operating system otherwise.
+	SyncNever
+)
+
+const (
+	defaultSyncInterval     = time.Second
+	defaultCompactThreshold = 4 << 20
+)
+
+// Options is a struct which holds options of a DurableMap.
+type Options struct {
+	// Sync is the policy of fsync. The default is SyncAlways.
+	Sync SyncPolicy
+
+	// SyncInterval is the interval of fsync for SyncInterval policy.
+	// The default is one second.
+	SyncInterval time.Duration
+
+	// CompactThreshold is the size of a log file in bytes over which the log
+	// is compacted. A log is compacted only if it is also larger than twice
+	// the size after the last compaction. The default is 4 MiB, and a
+	// negative value disables automatic compaction.
+	// An error of an automatic compaction is not returned by the mutation
+	// which triggered it, because the mutation itself has succeeded. It is
+	// returned by Close as a CompactError unless a later compaction succeeds.
+	CompactThreshold int64
+
+	// TruncateCorrupted makes Open truncate a corrupted record in the middle
+	// of a log and all records after it, instead of returning a RecordError.
+	// The valid records after the corrupted one are lost.
+	TruncateCorrupted bool
+}
+
+// FormatError is an error type which is returned by Open when a file is not a
+// log file of a DurableMap.
+type FormatError struct {
+	Path string
+}
+
+func (err FormatError) Error() string {
+	return "persist: not a log file of an ordered map: " + err.Path
+}
+
+// RecordError is an error type which is returned by Open when a record in the
+// middle of a log is corrupted, or when a record is intact but cannot be
+// decoded into keys or values of a DurableMap.
+// For a corrupted record, Err is ErrCorruptRecord.
+type RecordError struct {
+	Offset int64
+	Err    error
+}
+
+func (err RecordError) Error() string {
+	return "persist: invalid record (offset:" +
+		strconv.FormatInt(err.Offset, 10) + "): " + err.Err.Error()
+}
+
+// Unwrap is a method which returns the error which caused this error.
+func (err RecordError) Unwrap() error {
+	return err.Err
+}
+
+// CompactError is an error type which is returned by Close when the last
+// automatic compaction of the log failed.
+// The log is still valid because a failed compaction leaves it unchanged.
+type CompactError struct {
+	Err error
+}
+
+func (err CompactError) Error() string {
+	return "persist: automatic compaction failed: " + err.Err.Error()
+}
+
+// Unwrap is a method which returns the error which caused this error.
+func (err CompactError) Unwrap() error {
+	return err.Err
+}
+
+// DurableMap is a struct which represents an ordered map of which mutations
+// are written to a log file.
+// A DurableMap is not safe for concurrent use, and a log file must not be
+// opened by multiple DurableMaps at the same time.
+type DurableMap[K comparable, V any] struct {
+	om         orderedmap.Map[K, V]
+	path       string
+	file       *os.File
+	size       int64
+	base       int64
+	opts       Options
+	lastSync   time.Time
+	buf        []byte
+	compactErr error
+}
+
+// Open is a function which opens a log file and returns a DurableMap which
+// has the content replayed from the log. If the file does not exist, it is
+// created.
+// An incomplete or corrupted record at the end of the log is truncated,
+// because it is regarded as a write interrupted by a crash. A corrupted
+// record followed by other records causes a RecordError, unless
+// Options.TruncateCorrupted is set.
+// If opts is nil, the default options are used.
+func Open[K comparable, V any](path string, opts *Options) (*DurableMap[K, V], error) {
+	dm := &DurableMap[K, V]{
+		om:   orderedmap.New[K, V](),
+		path: path,
+	}
+	if opts != nil {
+		dm.opts = *opts
+	}
+	if dm.opts.SyncInterval <= 0 {
+		dm.opts.SyncInterval = defaultSyncInterval
+	}
+	if dm.opts.CompactThreshold == 0 {
+		dm.opts.CompactThreshold = defaultCompactThreshold
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	dm.file = f
+
+	err = dm.replay()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	dm.base = dm.size
+	dm.lastSync = time.Now()
+	return dm, nil
+}
+
+func (dm *DurableMap[K, V]) replay() error {
+	r := bufio.NewReader(dm.file)
+
+	head := make([]byte, len(logHeader))
+	n, err := io.ReadFull(r, head)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		if !bytes.Equal(head[:n], logHeader[:n]) {
+			return FormatError{Path: dm.path}
+		}
+		return dm.reset()
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(head, logHeader) {
+		return FormatError{Path: dm.path}
+	}
+
+	offset := int64(n)
+	for {
+		rec, size, err := readRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err == ErrCorruptRecord && !atEOF(r) && !dm.opts.TruncateCorrupted {
+			return RecordError{Offset: offset, Err: err}
+		}
+		if err == errTornRecord || err == ErrCorruptRecord {
+			err = dm.file.Truncate(offset)
+			if err != nil {
+				return err
+			}
+			err = dm.file.Sync()
+			if err != nil {
+				return err
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		err = dm.apply(rec)
+		if err != nil {
+			return RecordError{Offset: offset, Err: err}
+		}
+		offset += int64(size)
+	}
+
+	dm.size = offset
+	_, err = dm.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+func atEOF(r *bufio.Reader) bool {
+	_, err := r.Peek(1)
+	return err == io.EOF
+}
+
+// reset is a method which makes the log file have only the header.
+func (dm *DurableMap[K, V]) reset() error {
+	err := dm.file.Truncate(0)
+	if err != nil {
+		return err
+	}
+	_, err = dm.file.WriteAt(logHeader, 0)
+	if err != nil {
+		return err
+	}
+	err = dm.file.Sync()
+	if err != nil {
+		return err
+	}
+	dm.size = int64(len(logHeader))
+	_, err = dm.file.Seek(dm.size, io.SeekStart)
+	return err
+}
+
+func (dm *DurableMap[K, V]) apply(rec record) error {
+	var keys [2]K
+	var value V
+
+	switch rec.op {
+	case opStore:
+		err := json.Unmarshal(rec.args[0], &keys[0])
+		if err != nil {
+			return err
+		}
+		err = json.Unmarshal(rec.args[1], &value)
+		if err != nil {
+			return err
+		}
+		dm.om.Store(keys[0], value)
+		return nil
+	case opClear:
+		dm.om.Clear()
+		return nil
+	}
+
+	for i, arg := range rec.args {
+		err := json.Unmarshal(arg, &keys[i])
+		if err != nil {
+			return err
+		}
+	}
+	switch rec.op {
+	case opDelete:
+		dm.om.Delete(keys[0])
+	case opMoveFront:
+		dm.om.MoveToFront(keys[0])
+	case opMoveBack:
+		dm.om.MoveToBack(keys[0])
+	case opMoveBefore:
+		dm.om.MoveBefore(keys[0], keys[1])
+	case opMoveAfter:
+		dm.om.MoveAfter(keys[0], keys[1])
+	}
+	return nil
+}
+
+// write is a method which appends a record to the log file, and flushes the
+// log according to the sync policy.
+// If the write or the flush fails, the written part is truncated so that
+// later records are not placed after an incomplete record, and the mutation
+// must not be applied to the map.
+func (dm *DurableMap[K, V]) write(op byte, args ...[]byte) error {
+	if dm.file == nil {
+		return os.ErrClosed
+	}
+
+	dm.buf = appendRecord(dm.buf[:0], record{op: op, args: args})
+	n, err := dm.file.Write(dm.buf)
+	if err == nil {
+		switch dm.opts.Sync {
+		case SyncAlways:
+			err = dm.Sync()
+		case SyncInterval:
+			if time.Since(dm.lastSync) >= dm.opts.SyncInterval {
+				err = dm.Sync()
+			}
+		}
+	}
+	if err != nil {
+		if n > 0 {
+			if dm.file.Truncate(dm.size) == nil {
+				dm.file.Seek(dm.size, io.SeekStart)
+			}
+		}
+		return err
+	}
+	dm.size += int64(n)
+	return nil
+}
+
+// afterWrite is a method which is called after a written mutation is applied
+// to the map, and compacts the log if it has grown past the threshold. An
+// error of the compaction is kept for Close instead of being returned.
+func (dm *DurableMap[K, V]) afterWrite() {
+	t := dm.opts.CompactThreshold
+	if t > 0 && dm.size > t && dm.size > 2*dm.base {
+		err := dm.Compact()
+		if err != nil {
+			dm.compactErr = CompactError{Err: err}
+		}
+	}
+}
+
+func (dm *DurableMap[K, V]) encodeKeys(keys ...K) ([][]byte, error) {
+	args := make([][]byte, len(keys))
+	for i, key := range keys {
+		b, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = b
+	}
+	return args, nil
+}
+
+// Len is a method which returns the number of entries in this map.
+func (dm *DurableMap[K, V]) Len() int {
+	return dm.om.Len()
+}
+
+// Load is a method which returns a value stored in this map for a key.
+// If no value was found for a key, the ok result is false.
+func (dm *DurableMap[K, V]) Load(key K) (value V, ok bool) {
+	return dm.om.Load(key)
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in this map in the order of entries.
+// If fn returns false, this method stops the iteration.
+func (dm *DurableMap[K, V]) Range(fn func(key K, value V) bool) {
+	dm.om.Range(fn)
+}
+
+// Front is a method which returns the head entry of this map.
+func (dm *DurableMap[K, V]) Front() *orderedmap.Entry[K, V] {
+	return dm.om.Front()
+}
+
+// Back is a method which returns the last entry of this map.
+func (dm *DurableMap[K, V]) Back() *orderedmap.Entry[K, V] {
+	return dm.om.Back()
+}
+
+// String is a method which returns a string of the content of this map.
+func (dm *DurableMap[K, V]) String() string {
+	return "DurableMap" + dm.om.String()[len("Map"):]
+}
+
+// Store is a method which sets a value for a key, and writes it to the log.
+// If the key is present, its position is not changed.
+// The log is written and flushed according to the sync policy before the
+// map is changed, so if this method returns an error, the map is unchanged.
+func (dm *DurableMap[K, V]) Store(key K, value V) error {
+	args, err := dm.encodeKeys(key)
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	err = dm.write(opStore, args[0], v)
+	if err != nil {
+		return err
+	}
+	dm.om.Store(key, value)
+	dm.afterWrite()
+	return nil
+}
+
+// Delete is a method which deletes a value for a key, and writes it to the
+// log.
+func (dm *DurableMap[K, V]) Delete(key K) error {
+	if _, ok := dm.om.Load(key); !ok {
+		return nil
+	}
+	args, err := dm.encodeKeys(key)
+	if err != nil {
+		return err
+	}
+
+	err = dm.write(opDelete, args...)
+	if err != nil {
+		return err
+	}
+	dm.om.Delete(key)
+	dm.afterWrite()
+	return nil
+}
+
+// Clear is a method which deletes all entries of this map, and writes it to
+// the log.
+func (dm *DurableMap[K, V]) Clear() error {
+	if dm.om.Len() == 0 {
+		return nil
+	}
+	err := dm.write(opClear)
+	if err != nil {
+		return err
+	}
+	dm.om.Clear()
+	dm.afterWrite()
+	return nil
+}
+
+func (dm *DurableMap[K, V]) move(op byte, keys ...K) (bool, error) {
+	for _, key := range keys {
+		if _, ok := dm.om.Load(key); !ok {
+			return false, nil
+		}
+	}
+	if len(keys) == 2 && keys[0] == keys[1] {
+		return false, nil
+	}
+	args, err := dm.encodeKeys(keys...)
+	if err != nil {
+		return false, err
+	}
+
+	err = dm.write(op, args...)
+	if err != nil {
+		return false, err
+	}
+	err = dm.apply(record{op: op, args: args})
+	if err != nil {
+		return false, err
+	}
+	dm.afterWrite()
+	return true, nil
+}
+
+// MoveToFront is a method which moves an entry for a key to the head of this
+// map, and writes it to the log.
+// If the key is not present, this method returns false.
+func (dm *DurableMap[K, V]) MoveToFront(key K) (bool, error) {
+	return dm.move(opMoveFront, key)
+}
+
+// MoveToBack is a method which moves an entry for a key to the end of this
+// map, and writes it to the log.
+// If the key is not present, this method returns false.
+func (dm *DurableMap[K, V]) MoveToBack(key K) (bool, error) {
+	return dm.move(opMoveBack, key)
+}
+
+// MoveBefore is a method which moves an entry for a key to the position just
+// before an entry for a mark key, and writes it to the log.
+// If either key is not present or both keys are same, this method returns
+// false.
+func (dm *DurableMap[K, V]) MoveBefore(key, mark K) (bool, error) {
+	return dm.move(opMoveBefore, key, mark)
+}
+
+// MoveAfter is a method which moves an entry for a key to the position just
+// after an entry for a mark key, and writes it to the log.
+// If either key is not present or both keys are same, this method returns
+// false.
+func (dm *DurableMap[K, V]) MoveAfter(key, mark K) (bool, error) {
+	return dm.move(opMoveAfter, key, mark)
+}
+
+// Sync is a method which flushes the log file to a storage device.
+func (dm *DurableMap[K, V]) Sync() error {
+	if dm.file == nil {
+		return os.ErrClosed
+	}
+	err := dm.file.Sync()
+	if err != nil {
+		return err
+	}
+	dm.lastSync = time.Now()
+	return nil
+}
+
+// Compact is a method which rewrites the log file into a snapshot which has
+// one record for each entry of this map.
+// The snapshot is written to a temporary file and replaces the log file
+// atomically, so the log is not broken even if a crash occurs during this
+// method.
+func (dm *DurableMap[K, V]) Compact() error {
+	if dm.file == nil {
+		return os.ErrClosed
+	}
+
+	tmpPath := dm.path + ".tmp"
+	size, err := dm.writeSnapshot(tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	err = os.Rename(tmpPath, dm.path)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	syncDir(filepath.Dir(dm.path))
+
+	dm.file.Close()
+	dm.file = nil
+
+	f, err := os.OpenFile(dm.path, os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Seek(size, io.SeekStart)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	dm.file = f
+	dm.size = size
+	dm.base = size
+	dm.lastSync = time.Now()
+	dm.compactErr = nil
+	return nil
+}
+
+func (dm *DurableMap[K, V]) writeSnapshot(path string) (int64, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	size := int64(len(logHeader))
+	_, err = w.Write(logHeader)
+	if err != nil {
+		return 0, err
+	}
+
+	for ent := dm.om.Front(); ent != nil; ent = ent.Next() {
+		k, err := json.Marshal(ent.Key())
+		if err != nil {
+			return 0, err
+		}
+		v, err := json.Marshal(ent.Value())
+		if err != nil {
+			return 0, err
+		}
+		dm.buf = appendRecord(dm.buf[:0], record{op: opStore, args: [][]byte{k, v}})
+		n, err := w.Write(dm.buf)
+		if err != nil {
+			return 0, err
+		}
+		size += int64(n)
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return 0, err
+	}
+	err = f.Sync()
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// syncDir is a function which flushes a directory so that a renamed file in
+// it is persisted. Errors are ignored because some platforms do not support
+// fsync on directories.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
+
+// Close is a method which flushes and closes the log file.
+// After this method, mutation methods return os.ErrClosed, but the content
+// of this map can still be read.
+// If closing succeeds but the last automatic compaction failed, this method
+// returns a CompactError.
+func (dm *DurableMap[K, V]) Close() error {
+	if dm.file == nil {
+		// A failed compaction can leave the log file closed.
+		if err := dm.compactErr; err != nil {
+			dm.compactErr = nil
+			return err
+		}
+		return os.ErrClosed
+	}
+	err := dm.file.Sync()
+	if err != nil {
+		dm.file.Close()
+		dm.file = nil
+		return err
+	}
+	err = dm.file.Close()
+	dm.file = nil
+	if err != nil {
+		return err
+	}
+	return dm.compactErr
+}
@@ -0,0 +1,387 @@
+package persist_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap/persist"
+)
+
+func logPath(t *testing.T) string {
+	return filepath.Join(t.TempDir(), "map.log")
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	fi, err := os.Stat(path)
+	assert.Nil(t, err)
+	return fi.Size()
+}
+
+func TestOpen_new(t *testing.T) {
+	path := logPath(t)
+
+	dm, err := persist.Open[string, int](path, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, dm.Len(), 0)
+	assert.Nil(t, dm.Front())
+	assert.Nil(t, dm.Back())
+	assert.Equal(t, dm.String(), "DurableMap[]")
+	assert.Nil(t, dm.Close())
+	assert.Equal(t, fileSize(t, path), int64(8))
+
+	dm, err = persist.Open[string, int](path, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, dm.Len(), 0)
+	assert.Nil(t, dm.Close())
+}
+
+func TestDurableMap_replay(t *testing.T) {
+	path := logPath(t)
+
+	dm, err := persist.Open[string, int](path, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, dm.Store("a", 1))
+	assert.Nil(t, dm.Store("b", 2))
+	assert.Nil(t, dm.Store("c", 3))
+	assert.Nil(t, dm.Store("a", 10))
+	assert.Nil(t, dm.Delete("b"))
+	assert.Nil(t, dm.Delete("x"))
+	assert.Nil(t, dm.Store("d", 4))
+	assert.Nil(t, dm.Store("b", 20))
+
+	moved, err := dm.MoveToFront("d")
+	assert.True(t, moved)
+	assert.Nil(t, err)
+	moved, err = dm.MoveToBack("c")
+	assert.True(t, moved)
+	assert.Nil(t, err)
+	moved, err = dm.MoveBefore("b", "a")
+	assert.True(t, moved)
+	assert.Nil(t, err)
+	moved, err = dm.MoveAfter("d", "a")
+	assert.True(t, moved)
+	assert.Nil(t, err)
+	moved, err = dm.MoveAfter("d", "d")
+	assert.False(t, moved)
+	assert.Nil(t, err)
+	moved, err = dm.MoveToFront("x")
+	assert.False(t, moved)
+	assert.Nil(t, err)
+
+	assert.Equal(t, dm.String(), "DurableMap[b:20 a:10 d:4 c:3]")
+	assert.Nil(t, dm.Close())
+
+	dm, err = persist.Open[string, int](path, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, dm.String(), "DurableMap[b:20 a:10 d:4 c:3]")
+	assert.Equal(t, dm.Len(), 4)
+	v, ok := dm.Load("a")
+	assert.True(t, ok)
+	assert.Equal(t, v, 10)
+	assert.Equal(t, dm.Front().Key(), "b")
+	assert.Equal(t, dm.Back().Key(), "c")
+
+	var keys []string
+	dm.Range(func(k string, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	assert.Equal(t, keys, []string{"b", "a", "d", "c"})
+
+	assert.Nil(t, dm.Clear())
+	assert.Nil(t, dm.Store("e", 5))
+	assert.Nil(t, dm.Close())
+
+	dm, err = persist.Open[string, int](path, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, dm.String(), "DurableMap[e:5]")
+	assert.Nil(t, dm.Close())
+}
+
+type point struct {
+	X, Y int
+}
+
+func TestDurableMap_structValues(t *testing.T) {
+	path := logPath(t)
+
+	dm, err := persist.Open[int, point](path, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, dm.Store(3, point{1, 2}))
+	assert.Nil(t, dm.Store(1, point{3, 4}))
+	assert.Nil(t, dm.Close())
+
+	dm, err = persist.Open[int, point](path, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, dm.String(), "DurableMap[3:{1 2} 1:{3 4}]")
+	assert.Nil(t, dm.Close())
+
+	_, err = persist.Open[int, string](path, nil)
+	var recErr persist.RecordError
+	assert.True(t, errors.As(err, &recErr))
+	assert.Equal(t, recErr.Offset, int64(8))
+	assert.Equal(t, fileSize(t, path) > 8, true)
+}
+
+func TestDurableMap_encodeError(t *testing.T) {
+	dm, err := persist.Open[string, any](logPath(t), nil)
+	assert.Nil(t, err)
+
+	assert.NotNil(t, dm.Store("a", func() {}))
+	assert.Equal(t, dm.Len(), 0)
+	assert.Nil(t, dm.Store("b", 1))
+	assert.Nil(t, dm.Close())
+}
+
+func TestDurableMap_closed(t *testing.T) {
+	dm, err := persist.Open[string, int](logPath(t), nil)
+	assert.Nil(t, err)
+	assert.Nil(t, dm.Store("a", 1))
+	assert.Nil(t, dm.Close())
+
+	assert.Equal(t, dm.Close(), os.ErrClosed)
+	assert.Equal(t, dm.Store("b", 2), os.ErrClosed)
+	assert.Equal(t, dm.Delete("a"), os.ErrClosed)
+	assert.Equal(t, dm.Clear(), os.ErrClosed)
+	_, err = dm.MoveToBack("a")
+	assert.Equal(t, err, os.ErrClosed)
+	assert.Equal(t, dm.Sync(), os.ErrClosed)
+	assert.Equal(t, dm.Compact(), os.ErrClosed)
+	assert.Equal(t, dm.String(), "DurableMap[a:1]")
+}
+
+func TestOpen_formatError(t *testing.T) {
+	path := logPath(t)
+	assert.Nil(t, os.WriteFile(path, []byte("not a log file"), 0o644))
+
+	_, err := persist.Open[string, int](path, nil)
+	assert.Equal(t, err, persist.FormatError{Path: path})
+	assert.Equal(t, err.Error(), "persist: not a log file of an ordered map: "+path)
+
+	assert.Nil(t, os.WriteFile(path, []byte("xy"), 0o644))
+	_, err = persist.Open[string, int](path, nil)
+	assert.Equal(t, err, persist.FormatError{Path: path})
+}
+
+func TestDurableMap_compact(t *testing.T) {
+	path := logPath(t)
+
+	dm, err := persist.Open[string, int](path, &persist.Options{
+		CompactThreshold: -1,
+	})
+	assert.Nil(t, err)
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, dm.Store("a", i))
+		assert.Nil(t, dm.Store("b", i))
+	}
+	assert.Nil(t, dm.Delete("a"))
+	assert.Nil(t, dm.Store("c", 1))
+	assert.Nil(t, dm.Store("a", 2))
+	before := fileSize(t, path)
+
+	assert.Nil(t, dm.Compact())
+	after := fileSize(t, path)
+	assert.True(t, after < before/10)
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Nil(t, dm.Store("d", 3))
+	assert.Nil(t, dm.Close())
+
+	dm, err = persist.Open[string, int](path, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, dm.String(), "DurableMap[b:99 c:1 a:2 d:3]")
+	assert.Nil(t, dm.Close())
+}
+
+func TestDurableMap_autoCompact(t *testing.T) {
+	path := logPath(t)
+
+	dm, err := persist.Open[int, int](path, &persist.Options{
+		Sync:             persist.SyncNever,
+		CompactThreshold: 1024,
+	})
+	assert.Nil(t, err)
+	for i := 0; i < 1000; i++ {
+		assert.Nil(t, dm.Store(i%10, i))
+		assert.True(t, fileSize(t, path) <= 1024+32)
+	}
+	assert.Nil(t, dm.Close())
+
+	dm, err = persist.Open[int, int](path, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, dm.Len(), 10)
+	v, _ := dm.Load(9)
+	assert.Equal(t, v, 999)
+	assert.Nil(t, dm.Close())
+}
+
+func TestDurableMap_autoCompactError(t *testing.T) {
+	path := logPath(t)
+	assert.Nil(t, os.MkdirAll(filepath.Join(path+".tmp", "x"), 0o755))
+
+	dm, err := persist.Open[int, int](path, &persist.Options{
+		Sync:             persist.SyncNever,
+		CompactThreshold: 256,
+	})
+	assert.Nil(t, err)
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, dm.Store(i%10, i))
+	}
+	assert.True(t, fileSize(t, path) > 256)
+
+	err = dm.Close()
+	var e persist.CompactError
+	assert.True(t, errors.As(err, &e))
+	assert.NotNil(t, e.Unwrap())
+	assert.Equal(t, err.Error(), "persist: automatic compaction failed: "+e.Err.Error())
+
+	dm, err = persist.Open[int, int](path, &persist.Options{
+		Sync:             persist.SyncNever,
+		CompactThreshold: 256,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, dm.Len(), 10)
+	assert.Nil(t, dm.Store(0, 0))
+	assert.Nil(t, os.RemoveAll(path+".tmp"))
+	assert.Nil(t, dm.Compact())
+	assert.Nil(t, dm.Close())
+}
+
+func TestDurableMap_syncPolicies(t *testing.T) {
+	for _, opts := range []persist.Options{
+		{Sync: persist.SyncAlways},
+		{Sync: persist.SyncInterval},
+		{Sync: persist.SyncInterval, SyncInterval: 1},
+		{Sync: persist.SyncNever},
+	} {
+		path := logPath(t)
+		opts := opts
+
+		dm, err := persist.Open[string, int](path, &opts)
+		assert.Nil(t, err)
+		assert.Nil(t, dm.Store("a", 1))
+		assert.Nil(t, dm.Store("b", 2))
+		assert.Nil(t, dm.Sync())
+		assert.Nil(t, dm.Store("c", 3))
+		assert.Nil(t, dm.Close())
+
+		dm, err = persist.Open[string, int](path, &opts)
+		assert.Nil(t, err)
+		assert.Equal(t, dm.String(), "DurableMap[a:1 b:2 c:3]")
+		assert.Nil(t, dm.Close())
+	}
+}
+
+// TestDurableMap_crashRecovery writes mutations, then truncates a copy of the
+// log at every byte offset and checks that the reopened map has the state
+// after the last complete record, and that the map can be written again.
+func TestDurableMap_crashRecovery(t *testing.T) {
+	path := logPath(t)
+
+	dm, err := persist.Open[string, string](path, nil)
+	assert.Nil(t, err)
+
+	sizes := []int64{fileSize(t, path)}
+	states := []string{dm.String()}
+	record := func(err error) {
+		assert.Nil(t, err)
+		sizes = append(sizes, fileSize(t, path))
+		states = append(states, dm.String())
+	}
+	record(dm.Store("a", "x"))
+	record(dm.Store("b", "hello, world"))
+	record(dm.Store("a", "y"))
+	_, err = dm.MoveAfter("a", "b")
+	record(err)
+	record(dm.Delete("b"))
+	record(dm.Store("c", ""))
+	record(dm.Clear())
+	record(dm.Store("d", "z"))
+	assert.Nil(t, dm.Close())
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(data)), sizes[len(sizes)-1])
+
+	dir := t.TempDir()
+	for cut := 0; cut <= len(data); cut++ {
+		p := filepath.Join(dir, "cut.log")
+		assert.Nil(t, os.WriteFile(p, data[:cut], 0o644))
+
+		expected := states[0]
+		for i, size := range sizes {
+			if int64(cut) >= size {
+				expected = states[i]
+			}
+		}
+
+		dm, err := persist.Open[string, string](p, nil)
+		if !assert.Nil(t, err, "cut=%d", cut) {
+			continue
+		}
+		assert.Equal(t, dm.String(), expected, "cut=%d", cut)
+		assert.Nil(t, dm.Store("e", "w"))
+		assert.Nil(t, dm.Close())
+
+		dm, err = persist.Open[string, string](p, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, dm.Back().Key(), "e", "cut=%d", cut)
+		assert.Nil(t, dm.Close())
+	}
+}
+
+func TestDurableMap_corruptedRecord(t *testing.T) {
+	path := logPath(t)
+
+	dm, err := persist.Open[string, int](path, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, dm.Store("a", 1))
+	size := fileSize(t, path)
+	assert.Nil(t, dm.Store("b", 2))
+	assert.Nil(t, dm.Store("c", 3))
+	assert.Nil(t, dm.Close())
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	data[size+10] ^= 0xff
+	assert.Nil(t, os.WriteFile(path, data, 0o644))
+
+	_, err = persist.Open[string, int](path, nil)
+	assert.Equal(t, err, persist.RecordError{Offset: size, Err: persist.ErrCorruptRecord})
+	assert.True(t, errors.Is(err, persist.ErrCorruptRecord))
+	assert.Equal(t, err.Error(), "persist: invalid record (offset:23): corrupted record")
+	assert.Equal(t, fileSize(t, path), int64(len(data)))
+
+	dm, err = persist.Open[string, int](path, &persist.Options{TruncateCorrupted: true})
+	assert.Nil(t, err)
+	assert.Equal(t, dm.String(), "DurableMap[a:1]")
+	assert.Equal(t, fileSize(t, path), size)
+	assert.Nil(t, dm.Close())
+}
+
+func TestDurableMap_corruptedLastRecord(t *testing.T) {
+	path := logPath(t)
+
+	dm, err := persist.Open[string, int](path, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, dm.Store("a", 1))
+	assert.Nil(t, dm.Store("b", 2))
+	size := fileSize(t, path)
+	assert.Nil(t, dm.Store("c", 3))
+	assert.Nil(t, dm.Close())
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	data[len(data)-1] ^= 0xff
+	assert.Nil(t, os.WriteFile(path, data, 0o644))
+
+	dm, err = persist.Open[string, int](path, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, dm.String(), "DurableMap[a:1 b:2]")
+	assert.Equal(t, fileSize(t, path), size)
+	assert.Nil(t, dm.Close())
+}
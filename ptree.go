@@ -0,0 +1,176 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+// This file provides a persistent AVL tree keyed by uint64, which is used by
+// Frozen. Every update copies the nodes on the path from the root and shares
+// the other nodes with the previous version.
+
+type pnode[P any] struct {
+	key    uint64
+	item   P
+	left   *pnode[P]
+	right  *pnode[P]
+	height int8
+}
+
+func pheight[P any](n *pnode[P]) int8 {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func pmake[P any](key uint64, item P, left, right *pnode[P]) *pnode[P] {
+	h := pheight(left)
+	if hr := pheight(right); hr > h {
+		h = hr
+	}
+	return &pnode[P]{key: key, item: item, left: left, right: right, height: h + 1}
+}
+
+func pbalance[P any](key uint64, item P, left, right *pnode[P]) *pnode[P] {
+	hl, hr := pheight(left), pheight(right)
+	switch {
+	case hl > hr+1:
+		if pheight(left.left) >= pheight(left.right) {
+			return pmake(left.key, left.item, left.left,
+				pmake(key, item, left.right, right))
+		}
+		lr := left.right
+		return pmake(lr.key, lr.item,
+			pmake(left.key, left.item, left.left, lr.left),
+			pmake(key, item, lr.right, right))
+	case hr > hl+1:
+		if pheight(right.right) >= pheight(right.left) {
+			return pmake(right.key, right.item,
+				pmake(key, item, left, right.left), right.right)
+		}
+		rl := right.left
+		return pmake(rl.key, rl.item,
+			pmake(key, item, left, rl.left),
+			pmake(right.key, right.item, rl.right, right.right))
+	}
+	return pmake(key, item, left, right)
+}
+
+func pget[P any](n *pnode[P], key uint64) (P, bool) {
+	for n != nil {
+		switch {
+		case key < n.key:
+			n = n.left
+		case key > n.key:
+			n = n.right
+		default:
+			return n.item, true
+		}
+	}
+	var zero P
+	return zero, false
+}
+
+func pput[P any](n *pnode[P], key uint64, item P) *pnode[P] {
+	if n == nil {
+		return pmake[P](key, item, nil, nil)
+	}
+	switch {
+	case key < n.key:
+		return pbalance(n.key, n.item, pput(n.left, key, item), n.right)
+	case key > n.key:
+		return pbalance(n.key, n.item, n.left, pput(n.right, key, item))
+	}
+	return pmake(key, item, n.left, n.right)
+}
+
+func pdelete[P any](n *pnode[P], key uint64) *pnode[P] {
+	if n == nil {
+		return nil
+	}
+	switch {
+	case key < n.key:
+		return pbalance(n.key, n.item, pdelete(n.left, key), n.right)
+	case key > n.key:
+		return pbalance(n.key, n.item, n.left, pdelete(n.right, key))
+	}
+	if n.left == nil {
+		return n.right
+	}
+	if n.right == nil {
+		return n.left
+	}
+	min := n.right
+	for min.left != nil {
+		min = min.left
+	}
+	return pbalance(min.key, min.item, n.left, pdelete(n.right, min.key))
+}
+
+// pbuild builds a balanced tree from keys in ascending order and their items.
+func pbuild[P any](keys []uint64, items []P) *pnode[P] {
+	if len(keys) == 0 {
+		return nil
+	}
+	mid := len(keys) / 2
+	return pmake(keys[mid], items[mid],
+		pbuild(keys[:mid], items[:mid]),
+		pbuild(keys[mid+1:], items[mid+1:]))
+}
+
+// ppath is a path from the root to a node, which is used as an iteration
+// cursor because nodes have no parent pointers.
+type ppath[P any] []*pnode[P]
+
+func pfirst[P any](n *pnode[P], path ppath[P]) ppath[P] {
+	for ; n != nil; n = n.left {
+		path = append(path, n)
+	}
+	return path
+}
+
+func plast[P any](n *pnode[P], path ppath[P]) ppath[P] {
+	for ; n != nil; n = n.right {
+		path = append(path, n)
+	}
+	return path
+}
+
+func (path ppath[P]) next() ppath[P] {
+	cur := path[len(path)-1]
+	if cur.right != nil {
+		return pfirst(cur.right, path[:len(path):len(path)])
+	}
+	for i := len(path) - 1; i > 0; i-- {
+		if path[i-1].left == path[i] {
+			return path[:i:i]
+		}
+	}
+	return nil
+}
+
+func (path ppath[P]) prev() ppath[P] {
+	cur := path[len(path)-1]
+	if cur.left != nil {
+		return plast(cur.left, path[:len(path):len(path)])
+	}
+	for i := len(path) - 1; i > 0; i-- {
+		if path[i-1].right == path[i] {
+			return path[:i:i]
+		}
+	}
+	return nil
+}
+
+func prange[P any](n *pnode[P], fn func(item P) bool) bool {
+	for n != nil {
+		if !prange(n.left, fn) {
+			return false
+		}
+		if !fn(n.item) {
+			return false
+		}
+		n = n.right
+	}
+	return true
+}
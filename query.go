@@ -0,0 +1,245 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Query is a struct which represents parameters of a URL query string or
+// form values, like url.Values, but preserves the order of the parameters.
+// Each occurrence of a parameter is kept at its own position even if its key
+// is same with other parameters.
+//
+// The parameters parsed by ParseQuery keep their text in the query string,
+// so that Encode writes them back unchanged unless their values are set.
+//
+// The zero value of Query is an empty Query ready to use. A Query must not be
+// copied after first use, because a copy shares its parameters with the
+// original. NewQuery, ParseQuery and QueryFromValues return a pointer to a
+// Query for this reason.
+type Query struct {
+	params Map[uint64, queryParam]
+	index  map[string][]uint64
+	seq    uint64
+}
+
+// queryParam is a parameter of a Query. The rawKey and the rawValue are the
+// escaped texts of the key and the value, and bare is true if the parameter
+// has no '=' in the query string.
+type queryParam struct {
+	key      string
+	value    string
+	rawKey   string
+	rawValue string
+	bare     bool
+}
+
+// NewQuery is a function which creates a new Query, which is empty.
+func NewQuery() *Query {
+	return &Query{
+		params: New[uint64, queryParam](),
+		index:  make(map[string][]uint64),
+	}
+}
+
+// ParseQuery is a function which parses a URL-encoded query string and
+// returns a Query which has the parameters in the order in the string.
+// Like url.ParseQuery, this function continues parsing when it encounters an
+// invalid parameter, and returns the Query and the first error.
+func ParseQuery(query string) (*Query, error) {
+	q := NewQuery()
+	var err error
+	for query != "" {
+		var param string
+		param, query, _ = strings.Cut(query, "&")
+		if strings.Contains(param, ";") {
+			if err == nil {
+				err = errors.New("invalid semicolon separator in query")
+			}
+			continue
+		}
+		if param == "" {
+			continue
+		}
+		rawKey, rawValue, hasEq := strings.Cut(param, "=")
+		key, err1 := url.QueryUnescape(rawKey)
+		if err1 != nil {
+			if err == nil {
+				err = err1
+			}
+			continue
+		}
+		value, err1 := url.QueryUnescape(rawValue)
+		if err1 != nil {
+			if err == nil {
+				err = err1
+			}
+			continue
+		}
+		q.add(queryParam{
+			key: key, value: value,
+			rawKey: rawKey, rawValue: rawValue, bare: !hasEq,
+		})
+	}
+	return q, err
+}
+
+// QueryFromValues is a function which creates a Query from url.Values.
+// Because url.Values has no order, the parameters are sorted by keys like
+// url.Values.Encode, and values of a same key are kept in their order.
+func QueryFromValues(values url.Values) *Query {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	q := NewQuery()
+	for _, k := range keys {
+		for _, v := range values[k] {
+			q.Add(k, v)
+		}
+	}
+	return q
+}
+
+// Len is a method which returns the number of parameters in this Query.
+func (q *Query) Len() int {
+	return q.params.Len()
+}
+
+// Add is a method which appends a parameter at the end of this Query.
+func (q *Query) Add(key, value string) {
+	q.add(queryParam{
+		key: key, value: value,
+		rawKey: url.QueryEscape(key), rawValue: url.QueryEscape(value),
+	})
+}
+
+func (q *Query) add(p queryParam) {
+	if q.params.m == nil {
+		*q = *NewQuery()
+	}
+	q.params.Store(q.seq, p)
+	q.index[p.key] = append(q.index[p.key], q.seq)
+	q.seq++
+}
+
+// Get is a method which returns the value of the first parameter with the
+// specified key. If there is no such parameter, this method returns an empty
+// string.
+func (q *Query) Get(key string) string {
+	seqs := q.index[key]
+	if len(seqs) == 0 {
+		return ""
+	}
+	p, _ := q.params.Load(seqs[0])
+	return p.value
+}
+
+// GetAll is a method which returns the values of all parameters with the
+// specified key in their order.
+func (q *Query) GetAll(key string) []string {
+	seqs := q.index[key]
+	if len(seqs) == 0 {
+		return nil
+	}
+	values := make([]string, len(seqs))
+	for i, seq := range seqs {
+		p, _ := q.params.Load(seq)
+		values[i] = p.value
+	}
+	return values
+}
+
+// Has is a method which checks whether this Query has a parameter with the
+// specified key.
+func (q *Query) Has(key string) bool {
+	return len(q.index[key]) > 0
+}
+
+// Set is a method which sets the value of the first parameter with the
+// specified key and removes the other parameters with the key.
+// If there is no such parameter, this method appends a parameter at the end.
+func (q *Query) Set(key, value string) {
+	seqs := q.index[key]
+	if len(seqs) == 0 {
+		q.Add(key, value)
+		return
+	}
+
+	p, _ := q.params.Load(seqs[0])
+	p.value = value
+	p.rawValue = url.QueryEscape(value)
+	p.bare = false
+	q.params.Store(seqs[0], p)
+
+	for _, seq := range seqs[1:] {
+		q.params.Delete(seq)
+	}
+	q.index[key] = seqs[:1]
+}
+
+// Del is a method which removes all parameters with the specified key.
+func (q *Query) Del(key string) {
+	for _, seq := range q.index[key] {
+		q.params.Delete(seq)
+	}
+	delete(q.index, key)
+}
+
+// Range is a method which calls the specified function with the key and the
+// value of each parameter in order. If the function returns false, this
+// method stops the iteration.
+func (q *Query) Range(fn func(key, value string) bool) {
+	for ent := q.params.Front(); ent != nil; ent = ent.Next() {
+		if !fn(ent.value.key, ent.value.value) {
+			break
+		}
+	}
+}
+
+// Encode is a method which encodes the parameters into a URL-encoded query
+// string in their order. Unlike url.Values.Encode, the parameters are not
+// sorted.
+// The parameters parsed by ParseQuery are written in their text in the parsed
+// string, including their escapes and the keys without '='. The other
+// parameters are escaped with url.QueryEscape.
+func (q *Query) Encode() string {
+	var buf strings.Builder
+	first := true
+	for ent := q.params.Front(); ent != nil; ent = ent.Next() {
+		if !first {
+			buf.WriteByte('&')
+		}
+		first = false
+		buf.WriteString(ent.value.rawKey)
+		if !ent.value.bare {
+			buf.WriteByte('=')
+			buf.WriteString(ent.value.rawValue)
+		}
+	}
+	return buf.String()
+}
+
+// Values is a method which converts this Query to url.Values. Values of a
+// same key are kept in their order, but the order among keys is lost.
+func (q *Query) Values() url.Values {
+	values := make(url.Values)
+	for ent := q.params.Front(); ent != nil; ent = ent.Next() {
+		values[ent.value.key] = append(values[ent.value.key], ent.value.value)
+	}
+	return values
+}
+
+// String is a method which returns the URL-encoded query string of this
+// Query.
+func (q *Query) String() string {
+	return q.Encode()
+}
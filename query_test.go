@@ -0,0 +1,143 @@
+package orderedmap_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := orderedmap.ParseQuery("z=1&a=2&z=3&b=x+y&c=%26&d&&e=")
+	assert.Nil(t, err)
+	assert.Equal(t, q.Len(), 7)
+	assert.Equal(t, q.Encode(), "z=1&a=2&z=3&b=x+y&c=%26&d&e=")
+	assert.Equal(t, q.Get("z"), "1")
+	assert.Equal(t, q.GetAll("z"), []string{"1", "3"})
+	assert.Equal(t, q.Get("b"), "x y")
+	assert.Equal(t, q.Get("c"), "&")
+	assert.True(t, q.Has("d"))
+	assert.Equal(t, q.Get("d"), "")
+	assert.False(t, q.Has("nothing"))
+	assert.Nil(t, q.GetAll("nothing"))
+}
+
+func TestParseQuery_empty(t *testing.T) {
+	q, err := orderedmap.ParseQuery("")
+	assert.Nil(t, err)
+	assert.Equal(t, q.Len(), 0)
+	assert.Equal(t, q.Encode(), "")
+}
+
+func TestParseQuery_errors(t *testing.T) {
+	q, err := orderedmap.ParseQuery("a=1&b=%zz&c=3;d=4&e=5")
+	assert.NotNil(t, err)
+	_, ok := err.(url.EscapeError)
+	assert.True(t, ok)
+	assert.Equal(t, q.Encode(), "a=1&e=5")
+
+	_, err = orderedmap.ParseQuery("a=1;b=2")
+	assert.Equal(t, err.Error(), "invalid semicolon separator in query")
+}
+
+func TestQuery_Add(t *testing.T) {
+	var q orderedmap.Query
+	q.Add("oauth_nonce", "abc")
+	q.Add("oauth_consumer_key", "key")
+	q.Add("oauth_nonce", "def")
+	assert.Equal(t, q.Encode(), "oauth_nonce=abc&oauth_consumer_key=key&oauth_nonce=def")
+	assert.Equal(t, q.String(), "oauth_nonce=abc&oauth_consumer_key=key&oauth_nonce=def")
+}
+
+func TestQuery_Set(t *testing.T) {
+	q, _ := orderedmap.ParseQuery("a=1&b=2&a=3&c=4&a=5")
+
+	q.Set("a", "x")
+	assert.Equal(t, q.Encode(), "a=x&b=2&c=4")
+
+	q.Set("d", "y")
+	assert.Equal(t, q.Encode(), "a=x&b=2&c=4&d=y")
+	assert.Equal(t, q.Len(), 4)
+}
+
+func TestQuery_Set_keepsOtherParams(t *testing.T) {
+	q, _ := orderedmap.ParseQuery("flag&a=x%20y&b=%7e&flag")
+
+	q.Set("flag", "on")
+	assert.Equal(t, q.Encode(), "flag=on&a=x%20y&b=%7e")
+
+	q.Set("a", "p q")
+	assert.Equal(t, q.Encode(), "flag=on&a=p+q&b=%7e")
+	assert.Equal(t, q.GetAll("flag"), []string{"on"})
+}
+
+func TestQuery_Del(t *testing.T) {
+	q, _ := orderedmap.ParseQuery("a=1&b=2&a=3&c=4")
+
+	q.Del("a")
+	assert.Equal(t, q.Encode(), "b=2&c=4")
+
+	q.Del("nothing")
+	assert.Equal(t, q.Encode(), "b=2&c=4")
+
+	q.Add("a", "5")
+	assert.Equal(t, q.Encode(), "b=2&c=4&a=5")
+}
+
+func TestQuery_Range(t *testing.T) {
+	q, _ := orderedmap.ParseQuery("b=1&a=2&b=3")
+
+	var params []string
+	q.Range(func(key, value string) bool {
+		params = append(params, key+":"+value)
+		return true
+	})
+	assert.Equal(t, params, []string{"b:1", "a:2", "b:3"})
+
+	params = nil
+	q.Range(func(key, value string) bool {
+		params = append(params, key+":"+value)
+		return false
+	})
+	assert.Equal(t, params, []string{"b:1"})
+}
+
+func TestQuery_Values(t *testing.T) {
+	q, _ := orderedmap.ParseQuery("b=1&a=2&b=3")
+
+	values := q.Values()
+	assert.Equal(t, values, url.Values{"a": {"2"}, "b": {"1", "3"}})
+}
+
+func TestQueryFromValues(t *testing.T) {
+	values := url.Values{"b": {"1", "3"}, "a": {"2"}, "c": {}}
+
+	q := orderedmap.QueryFromValues(values)
+	assert.Equal(t, q.Encode(), "a=2&b=1&b=3")
+	assert.Equal(t, q.Encode(), values.Encode())
+}
+
+func TestQuery_roundTrip(t *testing.T) {
+	s := "x-amz-date=20230405T000000Z&x-amz-algorithm=AWS4-HMAC-SHA256&" +
+		"list=1&list=2&empty=&q=a%2Fb%3Fc&sp=a%20b&tilde=%7Ex&bare&u=%c3%a9"
+	q, err := orderedmap.ParseQuery(s)
+	assert.Nil(t, err)
+	assert.Equal(t, q.Encode(), s)
+}
+
+func TestQuery_independent(t *testing.T) {
+	q1, _ := orderedmap.ParseQuery("a=1&b=2")
+	q2, _ := orderedmap.ParseQuery("a=1&b=2")
+
+	q1.Set("a", "x")
+	q1.Del("b")
+	q2.Add("c", "3")
+	assert.Equal(t, q1.Encode(), "a=x")
+	assert.Equal(t, q2.Encode(), "a=1&b=2&c=3")
+
+	q3 := orderedmap.NewQuery()
+	q3.Add("a", "1")
+	assert.Equal(t, orderedmap.NewQuery().Len(), 0)
+	assert.Equal(t, q3.String(), "a=1")
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// RCUMap is a struct which represents an ordered map for read-mostly use by
+// multiple goroutines.
+//
+// The content of this map is an immutable Frozen which is published
+// atomically, so Load and Range never lock and always read a consistent
+// version of this map. Writers are serialized through a mutex, and each write
+// publishes a new version which shares its structure with the previous one.
+//
+// The zero value of RCUMap is an empty map ready to use.
+// An RCUMap must not be copied after first use.
+type RCUMap[K comparable, V any] struct {
+	mu  sync.Mutex
+	cur atomic.Value
+}
+
+// NewRCUMap is a function which creates a new RCUMap, which is empty.
+func NewRCUMap[K comparable, V any]() *RCUMap[K, V] {
+	return &RCUMap[K, V]{}
+}
+
+// Snapshot is a method which returns the current version of this map.
+// The returned Frozen is not changed by later writes to this map.
+func (rm *RCUMap[K, V]) Snapshot() Frozen[K, V] {
+	f, _ := rm.cur.Load().(Frozen[K, V])
+	return f
+}
+
+// Len is a method which returns the number of entries in this map.
+func (rm *RCUMap[K, V]) Len() int {
+	return rm.Snapshot().Len()
+}
+
+// Load is a method which returns a value stored in this map for a key.
+// If no value was found for a key, the ok result is false.
+func (rm *RCUMap[K, V]) Load(key K) (value V, ok bool) {
+	return rm.Snapshot().Load(key)
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in this map in the order of key insertions.
+// If fn returns false, this method stops the iteration.
+//
+// This method iterates the version of this map at the call, so writes during
+// the iteration are not seen by fn.
+func (rm *RCUMap[K, V]) Range(fn func(key K, value V) bool) {
+	rm.Snapshot().Range(fn)
+}
+
+// Update is a method which calls the specified function: fn with the current
+// version of this map and publishes the returned Frozen as the new version.
+// Other writers wait until this method returns, so fn can make multiple
+// changes which are published at once.
+func (rm *RCUMap[K, V]) Update(fn func(f Frozen[K, V]) Frozen[K, V]) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.cur.Store(fn(rm.Snapshot()))
+}
+
+// Store is a method which sets a value for a key.
+// If the key is present, its position is not changed.
+func (rm *RCUMap[K, V]) Store(key K, value V) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.cur.Store(rm.Snapshot().With(key, value))
+}
+
+// Swap is a method which sets a value for a key, and returns the previous
+// value if any.
+// The loaded flag is true if the key was present.
+func (rm *RCUMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	f := rm.Snapshot()
+	previous, loaded = f.Load(key)
+	rm.cur.Store(f.With(key, value))
+	return
+}
+
+// LoadOrStore is a method which returns a value for a key if present,
+// otherwise stores and returns a given value.
+// The loaded flag is true if the value was loaded, false if stored.
+func (rm *RCUMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if actual, loaded = rm.Load(key); loaded {
+		return
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	f := rm.Snapshot()
+	if actual, loaded = f.Load(key); loaded {
+		return
+	}
+	rm.cur.Store(f.With(key, value))
+	return value, false
+}
+
+// Delete is a method which deletes a value for a key.
+func (rm *RCUMap[K, V]) Delete(key K) {
+	rm.LoadAndDelete(key)
+}
+
+// LoadAndDelete is a method which deletes a value for a key, and returns the
+// previous value if any.
+// The loaded flag is true if the key was present.
+func (rm *RCUMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	f := rm.Snapshot()
+	if value, loaded = f.Load(key); loaded {
+		rm.cur.Store(f.Without(key))
+	}
+	return
+}
+
+// Clear is a method which deletes all entries in this map.
+func (rm *RCUMap[K, V]) Clear() {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if rm.Snapshot().Len() > 0 {
+		rm.cur.Store(Frozen[K, V]{})
+	}
+}
+
+// String is a method which returns a string of the content of this map.
+func (rm *RCUMap[K, V]) String() string {
+	return "RCUMap" + rm.Snapshot().String()[len("Frozen"):]
+}
@@ -0,0 +1,216 @@
+package orderedmap_test
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func TestRCUMap_zeroValue(t *testing.T) {
+	var rm orderedmap.RCUMap[string, int]
+	assert.Equal(t, rm.Len(), 0)
+	assert.Equal(t, rm.String(), "RCUMap[]")
+	v, ok := rm.Load("a")
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+
+	rm.Store("a", 1)
+	assert.Equal(t, rm.String(), "RCUMap[a:1]")
+}
+
+func TestRCUMap_storeAndLoad(t *testing.T) {
+	rm := orderedmap.NewRCUMap[string, int]()
+	rm.Store("c", 3)
+	rm.Store("a", 1)
+	rm.Store("b", 2)
+	rm.Store("a", 10)
+
+	assert.Equal(t, rm.Len(), 3)
+	assert.Equal(t, rm.String(), "RCUMap[c:3 a:10 b:2]")
+	v, ok := rm.Load("a")
+	assert.True(t, ok)
+	assert.Equal(t, v, 10)
+
+	prev, loaded := rm.Swap("b", 20)
+	assert.True(t, loaded)
+	assert.Equal(t, prev, 2)
+	prev, loaded = rm.Swap("d", 4)
+	assert.False(t, loaded)
+	assert.Equal(t, prev, 0)
+
+	actual, loaded := rm.LoadOrStore("d", 40)
+	assert.True(t, loaded)
+	assert.Equal(t, actual, 4)
+	actual, loaded = rm.LoadOrStore("e", 5)
+	assert.False(t, loaded)
+	assert.Equal(t, actual, 5)
+
+	assert.Equal(t, rm.String(), "RCUMap[c:3 a:10 b:20 d:4 e:5]")
+}
+
+func TestRCUMap_delete(t *testing.T) {
+	rm := orderedmap.NewRCUMap[string, int]()
+	rm.Store("a", 1)
+	rm.Store("b", 2)
+	rm.Store("c", 3)
+
+	rm.Delete("b")
+	rm.Delete("x")
+	assert.Equal(t, rm.String(), "RCUMap[a:1 c:3]")
+
+	v, loaded := rm.LoadAndDelete("a")
+	assert.True(t, loaded)
+	assert.Equal(t, v, 1)
+	v, loaded = rm.LoadAndDelete("a")
+	assert.False(t, loaded)
+	assert.Equal(t, v, 0)
+
+	rm.Store("a", 4)
+	assert.Equal(t, rm.String(), "RCUMap[c:3 a:4]")
+
+	rm.Clear()
+	assert.Equal(t, rm.Len(), 0)
+	rm.Clear()
+	assert.Equal(t, rm.String(), "RCUMap[]")
+}
+
+func TestRCUMap_snapshotAndUpdate(t *testing.T) {
+	rm := orderedmap.NewRCUMap[string, int]()
+	rm.Store("a", 1)
+	snap := rm.Snapshot()
+
+	rm.Update(func(f orderedmap.Frozen[string, int]) orderedmap.Frozen[string, int] {
+		return f.With("b", 2).With("c", 3).Without("a")
+	})
+	assert.Equal(t, rm.String(), "RCUMap[b:2 c:3]")
+	assert.Equal(t, snap.String(), "Frozen[a:1]")
+
+	var keys []string
+	rm.Range(func(k string, v int) bool {
+		rm.Store(k+k, v)
+		keys = append(keys, k)
+		return true
+	})
+	assert.Equal(t, keys, []string{"b", "c"})
+	assert.Equal(t, rm.String(), "RCUMap[b:2 c:3 bb:2 cc:3]")
+}
+
+// TestRCUMap_stress runs readers concurrently with writers which replace the
+// whole content of the map at once, and checks that every reader sees a
+// consistent version: keys are in the order of insertions, and all values
+// and the length belong to a same generation.
+func TestRCUMap_stress(t *testing.T) {
+	const n = 64
+	const generations = 300
+	const readers = 8
+
+	rm := orderedmap.NewRCUMap[int, int]()
+	rm.Update(func(f orderedmap.Frozen[int, int]) orderedmap.Frozen[int, int] {
+		for i := 0; i < n; i++ {
+			f = f.With(i, 0)
+		}
+		return f
+	})
+
+	var done int32
+	var reads int64
+	var wg sync.WaitGroup
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&done) == 0 {
+				snap := rm.Snapshot()
+				gen, _ := snap.Load(0)
+				size := n + gen%n
+
+				i := 0
+				rm.Range(func(k, v int) bool {
+					if k != i {
+						t.Errorf("key at %d is %d", i, k)
+						return false
+					}
+					i++
+					return true
+				})
+				if rm.Len() < n || rm.Len() >= 2*n {
+					t.Errorf("length is %d", rm.Len())
+				}
+
+				i = 0
+				snap.Range(func(k, v int) bool {
+					if k != i || v != gen {
+						t.Errorf("entry at %d is %d:%d in generation %d", i, k, v, gen)
+						return false
+					}
+					i++
+					return true
+				})
+				if i != size || snap.Len() != size {
+					t.Errorf("length is %d (%d) in generation %d", i, snap.Len(), gen)
+				}
+				atomic.AddInt64(&reads, 1)
+			}
+		}()
+	}
+
+	var wwg sync.WaitGroup
+	var gen int64
+	for w := 0; w < 2; w++ {
+		wwg.Add(1)
+		go func() {
+			defer wwg.Done()
+			for atomic.LoadInt64(&gen) < generations {
+				rm.Update(func(f orderedmap.Frozen[int, int]) orderedmap.Frozen[int, int] {
+					g := int(atomic.AddInt64(&gen, 1))
+					size := n + g%n
+					for i := f.Len() - 1; i >= size; i-- {
+						f = f.Without(i)
+					}
+					for i := 0; i < size; i++ {
+						f = f.With(i, g)
+					}
+					return f
+				})
+			}
+		}()
+	}
+	wwg.Wait()
+	atomic.StoreInt32(&done, 1)
+	wg.Wait()
+
+	assert.True(t, atomic.LoadInt64(&reads) > 0)
+	v, _ := rm.Load(0)
+	assert.Equal(t, int64(v), atomic.LoadInt64(&gen))
+}
+
+func TestRCUMap_concurrentWriters(t *testing.T) {
+	rm := orderedmap.NewRCUMap[string, int]()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				k := strconv.Itoa(g) + "-" + strconv.Itoa(i)
+				rm.Store(k, i)
+				rm.LoadOrStore(k, -1)
+				if i%2 == 0 {
+					rm.Delete(k)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	assert.Equal(t, rm.Len(), 8*100)
+	rm.Range(func(k string, v int) bool {
+		assert.Equal(t, v%2, 1)
+		return true
+	})
+}
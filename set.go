@@ -0,0 +1,199 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Set is a struct which represents a set of elements which preserves the order
+// in which elements were added.
+// This set is built on Map, so it has same performance characteristics with
+// Map, and supports logical deletions with Lremove like Ldelete of Map.
+type Set[K comparable] struct {
+	om Map[K, struct{}]
+}
+
+// NewSet is a function which creates a new Set, which is empty.
+func NewSet[K comparable]() Set[K] {
+	return Set[K]{om: New[K, struct{}]()}
+}
+
+// SetOf is a function which creates a new Set which has the specified
+// elements in the order of the arguments.
+func SetOf[K comparable](elems ...K) Set[K] {
+	s := NewSet[K]()
+	for _, k := range elems {
+		s.Add(k)
+	}
+	return s
+}
+
+// Len is a method which returns the number of elements in this set.
+func (s *Set[K]) Len() int {
+	return s.om.Len()
+}
+
+// Add is a method which adds an element at the end of this set.
+// If the element is already present, its position is not changed.
+func (s *Set[K]) Add(elem K) {
+	if s.om.m == nil {
+		s.om = New[K, struct{}]()
+	}
+	s.om.LoadOrStore(elem, struct{}{})
+}
+
+// Has is a method which checks whether this set has the element.
+func (s *Set[K]) Has(elem K) bool {
+	_, ok := s.om.Load(elem)
+	return ok
+}
+
+// Remove is a method which removes an element from this set.
+func (s *Set[K]) Remove(elem K) {
+	s.om.Delete(elem)
+}
+
+// Lremove is a method which logically removes an element from this set.
+func (s *Set[K]) Lremove(elem K) {
+	s.om.Ldelete(elem)
+}
+
+// Front is a method which returns the head entry of this set.
+// The key of each entry is an element of this set.
+func (s *Set[K]) Front() *Entry[K, struct{}] {
+	return s.om.Front()
+}
+
+// Back is a method which returns the last entry of this set.
+// The key of each entry is an element of this set.
+func (s *Set[K]) Back() *Entry[K, struct{}] {
+	return s.om.Back()
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each element in this set in the order of additions.
+// If fn returns false, this method stops the iteration.
+func (s *Set[K]) Range(fn func(elem K) bool) {
+	s.om.Range(func(elem K, _ struct{}) bool {
+		return fn(elem)
+	})
+}
+
+// Elements is a method which returns a slice of the elements in this set in
+// the order of additions.
+func (s *Set[K]) Elements() []K {
+	elems := make([]K, 0, s.om.Len())
+	for ent := s.om.Front(); ent != nil; ent = ent.Next() {
+		elems = append(elems, ent.key)
+	}
+	return elems
+}
+
+// Union is a method which returns a new set which has the elements in this
+// set or the other set.
+// The elements of this set come first in their order, followed by the
+// elements only in the other set in their order.
+func (s *Set[K]) Union(other *Set[K]) Set[K] {
+	r := NewSet[K]()
+	for ent := s.om.Front(); ent != nil; ent = ent.Next() {
+		r.Add(ent.key)
+	}
+	for ent := other.om.Front(); ent != nil; ent = ent.Next() {
+		r.Add(ent.key)
+	}
+	return r
+}
+
+// Intersection is a method which returns a new set which has the elements in
+// both this set and the other set, in the order of this set.
+func (s *Set[K]) Intersection(other *Set[K]) Set[K] {
+	r := NewSet[K]()
+	for ent := s.om.Front(); ent != nil; ent = ent.Next() {
+		if other.Has(ent.key) {
+			r.Add(ent.key)
+		}
+	}
+	return r
+}
+
+// Difference is a method which returns a new set which has the elements in
+// this set but not in the other set, in the order of this set.
+func (s *Set[K]) Difference(other *Set[K]) Set[K] {
+	r := NewSet[K]()
+	for ent := s.om.Front(); ent != nil; ent = ent.Next() {
+		if !other.Has(ent.key) {
+			r.Add(ent.key)
+		}
+	}
+	return r
+}
+
+// SymmetricDifference is a method which returns a new set which has the
+// elements in either this set or the other set but not in both.
+// The elements only in this set come first in their order, followed by the
+// elements only in the other set in their order.
+func (s *Set[K]) SymmetricDifference(other *Set[K]) Set[K] {
+	r := s.Difference(other)
+	for ent := other.om.Front(); ent != nil; ent = ent.Next() {
+		if !s.Has(ent.key) {
+			r.Add(ent.key)
+		}
+	}
+	return r
+}
+
+// Subset is a method which checks whether all elements in this set are in
+// the other set. The order of elements is not considered.
+func (s *Set[K]) Subset(other *Set[K]) bool {
+	if s.om.Len() > other.om.Len() {
+		return false
+	}
+	for ent := s.om.Front(); ent != nil; ent = ent.Next() {
+		if !other.Has(ent.key) {
+			return false
+		}
+	}
+	return true
+}
+
+// String is a method which returns a string of the content of this set.
+func (s Set[K]) String() string {
+	var buf strings.Builder
+	buf.WriteString("Set[")
+	ent := s.om.Front()
+	if ent != nil {
+		buf.WriteString(fmt.Sprintf("%v", ent.Key()))
+		for ent = ent.Next(); ent != nil; ent = ent.Next() {
+			buf.WriteString(fmt.Sprintf(" %v", ent.Key()))
+		}
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
+
+// MarshalJSON is a method which returns a JSON array of the elements in this
+// set in the order of additions.
+// This method is an implementation of json.Marshaler interface.
+func (s Set[K]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Elements())
+}
+
+// UnmarshalJSON is a method which adds the elements in a JSON array to this
+// set in the order in the array.
+// This method is an implementation of json.Unmarshaler interface.
+func (s *Set[K]) UnmarshalJSON(data []byte) error {
+	var elems []K
+	err := json.Unmarshal(data, &elems)
+	if err != nil {
+		return err
+	}
+	for _, k := range elems {
+		s.Add(k)
+	}
+	return nil
+}
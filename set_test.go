@@ -0,0 +1,188 @@
+package orderedmap_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func TestNewSet(t *testing.T) {
+	s := orderedmap.NewSet[string]()
+	assert.Equal(t, s.Len(), 0)
+	assert.Nil(t, s.Front())
+	assert.Nil(t, s.Back())
+	assert.Equal(t, s.String(), "Set[]")
+}
+
+func TestSet_Add(t *testing.T) {
+	s := orderedmap.NewSet[string]()
+	s.Add("c")
+	s.Add("a")
+	s.Add("b")
+	s.Add("a")
+	assert.Equal(t, s.Len(), 3)
+	assert.Equal(t, s.String(), "Set[c a b]")
+	assert.Equal(t, s.Elements(), []string{"c", "a", "b"})
+	assert.True(t, s.Has("a"))
+	assert.False(t, s.Has("d"))
+
+	var zero orderedmap.Set[int]
+	zero.Add(2)
+	zero.Add(1)
+	assert.Equal(t, zero.String(), "Set[2 1]")
+}
+
+func TestSet_Remove(t *testing.T) {
+	s := orderedmap.SetOf("c", "a", "b")
+	s.Remove("a")
+	assert.Equal(t, s.String(), "Set[c b]")
+	assert.False(t, s.Has("a"))
+
+	s.Remove("x")
+	assert.Equal(t, s.Len(), 2)
+
+	s.Add("a")
+	assert.Equal(t, s.String(), "Set[c b a]")
+}
+
+func TestSet_Lremove(t *testing.T) {
+	s := orderedmap.SetOf("c", "a", "b")
+	s.Lremove("a")
+	assert.Equal(t, s.String(), "Set[c b]")
+	assert.Equal(t, s.Len(), 2)
+	assert.False(t, s.Has("a"))
+
+	s.Lremove("a")
+	assert.Equal(t, s.Len(), 2)
+
+	s.Add("a")
+	assert.Equal(t, s.String(), "Set[c b a]")
+	assert.True(t, s.Has("a"))
+}
+
+func TestSet_Range(t *testing.T) {
+	s := orderedmap.SetOf(3, 1, 2)
+
+	var elems []int
+	s.Range(func(elem int) bool {
+		elems = append(elems, elem)
+		return true
+	})
+	assert.Equal(t, elems, []int{3, 1, 2})
+
+	elems = nil
+	s.Range(func(elem int) bool {
+		elems = append(elems, elem)
+		return false
+	})
+	assert.Equal(t, elems, []int{3})
+
+	elems = nil
+	for ent := s.Back(); ent != nil; ent = ent.Prev() {
+		elems = append(elems, ent.Key())
+	}
+	assert.Equal(t, elems, []int{2, 1, 3})
+}
+
+func TestSet_Union(t *testing.T) {
+	a := orderedmap.SetOf("c", "a", "b")
+	b := orderedmap.SetOf("d", "b", "e", "c")
+
+	u := a.Union(&b)
+	assert.Equal(t, u.String(), "Set[c a b d e]")
+	assert.Equal(t, a.String(), "Set[c a b]")
+	assert.Equal(t, b.String(), "Set[d b e c]")
+
+	u = b.Union(&a)
+	assert.Equal(t, u.String(), "Set[d b e c a]")
+}
+
+func TestSet_Intersection(t *testing.T) {
+	a := orderedmap.SetOf("c", "a", "b")
+	b := orderedmap.SetOf("d", "b", "e", "c")
+
+	i := a.Intersection(&b)
+	assert.Equal(t, i.String(), "Set[c b]")
+
+	i = b.Intersection(&a)
+	assert.Equal(t, i.String(), "Set[b c]")
+
+	e := orderedmap.NewSet[string]()
+	i = a.Intersection(&e)
+	assert.Equal(t, i.Len(), 0)
+}
+
+func TestSet_Difference(t *testing.T) {
+	a := orderedmap.SetOf("c", "a", "b")
+	b := orderedmap.SetOf("d", "b", "e", "c")
+
+	d := a.Difference(&b)
+	assert.Equal(t, d.String(), "Set[a]")
+
+	d = b.Difference(&a)
+	assert.Equal(t, d.String(), "Set[d e]")
+}
+
+func TestSet_SymmetricDifference(t *testing.T) {
+	a := orderedmap.SetOf("c", "a", "b")
+	b := orderedmap.SetOf("d", "b", "e", "c")
+
+	d := a.SymmetricDifference(&b)
+	assert.Equal(t, d.String(), "Set[a d e]")
+
+	d = b.SymmetricDifference(&a)
+	assert.Equal(t, d.String(), "Set[d e a]")
+}
+
+func TestSet_Subset(t *testing.T) {
+	a := orderedmap.SetOf("c", "a")
+	b := orderedmap.SetOf("a", "b", "c")
+	e := orderedmap.NewSet[string]()
+
+	assert.True(t, a.Subset(&b))
+	assert.False(t, b.Subset(&a))
+	assert.True(t, a.Subset(&a))
+	assert.True(t, e.Subset(&a))
+	assert.False(t, a.Subset(&e))
+
+	b.Lremove("c")
+	assert.False(t, a.Subset(&b))
+}
+
+func TestSet_MarshalJSON(t *testing.T) {
+	s := orderedmap.SetOf("c", "a", "b")
+	s.Lremove("a")
+
+	b, err := json.Marshal(s)
+	assert.Nil(t, err)
+	assert.Equal(t, string(b), `["c","b"]`)
+
+	e := orderedmap.NewSet[int]()
+	b, err = e.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, string(b), `[]`)
+}
+
+func TestSet_UnmarshalJSON(t *testing.T) {
+	var s orderedmap.Set[int]
+	err := json.Unmarshal([]byte(`[3, 1, 2, 1]`), &s)
+	assert.Nil(t, err)
+	assert.Equal(t, s.String(), "Set[3 1 2]")
+
+	err = json.Unmarshal([]byte(`["x"]`), &s)
+	assert.NotNil(t, err)
+
+	type doc struct {
+		Tags orderedmap.Set[string] `json:"tags"`
+	}
+	var d doc
+	err = json.Unmarshal([]byte(`{"tags":["z","a"]}`), &d)
+	assert.Nil(t, err)
+	assert.Equal(t, d.Tags.String(), "Set[z a]")
+
+	b, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.Equal(t, string(b), `{"tags":["z","a"]}`)
+}
@@ -0,0 +1,300 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"container/heap"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ShardedMap is a struct which represents an ordered map which is safe for
+// concurrent use by multiple goroutines.
+//
+// Keys are spread across shards each of which has its own lock, so writes of
+// different keys rarely contend. The order of key insertions is kept by a
+// sequence number which is given to each entry at its insertion, and ordered
+// iteration merges the entries of the shards by the sequence numbers.
+// The sequence counter is the only state which is written by insertions into
+// all shards. It is placed on its own cache line, and updates of present keys
+// and deletions do not touch it.
+//
+// A ShardedMap has to be created by NewShardedMap.
+type ShardedMap[K comparable, V any] struct {
+	seq    *seqCounter
+	shards []mapShard[K, V]
+	mask   uint64
+}
+
+// seqCounter is allocated separately from ShardedMap and is padded to 128
+// bytes, so that the frequent increments of the counter do not invalidate the
+// cache line of the fields which every operation reads. An allocated struct
+// of 128 bytes is aligned to 128 bytes, and its first word is 64-bit aligned
+// for atomic operations.
+type seqCounter struct {
+	n uint64
+	_ [120]byte
+}
+
+// mapShard is padded to a multiple of 128 bytes, which covers the cache line
+// size and the adjacent line prefetch of common CPUs, against false sharing
+// between shards. The size of the fields does not depend on K and V because a
+// Go map is a pointer, so it is computed with shardFields.
+type mapShard[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]shardEntry[V]
+	_  [shardPadding]byte
+}
+
+type shardFields struct {
+	mu sync.RWMutex
+	m  map[int]int
+}
+
+const shardPadding = (128 - unsafe.Sizeof(shardFields{})%128) % 128
+
+type shardEntry[V any] struct {
+	value V
+	seq   uint64
+}
+
+// NewShardedMap is a function which creates a new ShardedMap, which is empty.
+// The number of shards is rounded up to a power of two. If it is zero or
+// negative, four times the number of CPUs is used.
+func NewShardedMap[K comparable, V any](shards int) *ShardedMap[K, V] {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0) * 4
+	}
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+
+	sm := &ShardedMap[K, V]{
+		seq:    &seqCounter{},
+		shards: make([]mapShard[K, V], n),
+		mask:   uint64(n - 1),
+	}
+	for i := range sm.shards {
+		sm.shards[i].m = make(map[K]shardEntry[V])
+	}
+	return sm
+}
+
+func (sm *ShardedMap[K, V]) shard(key K) *mapShard[K, V] {
+	return &sm.shards[hashComparable(key)&sm.mask]
+}
+
+func (sm *ShardedMap[K, V]) nextSeq() uint64 {
+	return atomic.AddUint64(&sm.seq.n, 1)
+}
+
+// Len is a method which returns the number of entries in this map.
+// If this map is modified concurrently, the result can be inaccurate.
+func (sm *ShardedMap[K, V]) Len() int {
+	n := 0
+	for i := range sm.shards {
+		s := &sm.shards[i]
+		s.mu.RLock()
+		n += len(s.m)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Load is a method which returns a value stored in this map for a key.
+// If no value was found for a key, the ok result is false.
+func (sm *ShardedMap[K, V]) Load(key K) (value V, ok bool) {
+	s := sm.shard(key)
+	s.mu.RLock()
+	ent, ok := s.m[key]
+	s.mu.RUnlock()
+	return ent.value, ok
+}
+
+// Store is a method which sets a value for a key.
+// If the key is present, its position is not changed.
+func (sm *ShardedMap[K, V]) Store(key K, value V) {
+	s := sm.shard(key)
+	s.mu.Lock()
+	ent, exists := s.m[key]
+	if !exists {
+		ent.seq = sm.nextSeq()
+	}
+	ent.value = value
+	s.m[key] = ent
+	s.mu.Unlock()
+}
+
+// Swap is a method which sets a value for a key, and returns the previous
+// value if any.
+// The loaded flag is true if the key was present.
+func (sm *ShardedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	s := sm.shard(key)
+	s.mu.Lock()
+	ent, loaded := s.m[key]
+	previous = ent.value
+	if !loaded {
+		ent.seq = sm.nextSeq()
+	}
+	ent.value = value
+	s.m[key] = ent
+	s.mu.Unlock()
+	return
+}
+
+// LoadOrStore is a method which returns a value for a key if present,
+// otherwise stores and returns a given value.
+// The loaded flag is true if the value was loaded, false if stored.
+func (sm *ShardedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	s := sm.shard(key)
+	s.mu.RLock()
+	ent, loaded := s.m[key]
+	s.mu.RUnlock()
+	if loaded {
+		return ent.value, true
+	}
+
+	s.mu.Lock()
+	ent, loaded = s.m[key]
+	if !loaded {
+		ent = shardEntry[V]{value: value, seq: sm.nextSeq()}
+		s.m[key] = ent
+	}
+	s.mu.Unlock()
+	return ent.value, loaded
+}
+
+// Delete is a method which deletes a value for a key.
+func (sm *ShardedMap[K, V]) Delete(key K) {
+	s := sm.shard(key)
+	s.mu.Lock()
+	delete(s.m, key)
+	s.mu.Unlock()
+}
+
+// LoadAndDelete is a method which deletes a value for a key, and returns the
+// previous value if any.
+// The loaded flag is true if the key was present.
+func (sm *ShardedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	s := sm.shard(key)
+	s.mu.Lock()
+	ent, loaded := s.m[key]
+	if loaded {
+		delete(s.m, key)
+	}
+	s.mu.Unlock()
+	return ent.value, loaded
+}
+
+type seqEntry[K comparable, V any] struct {
+	key   K
+	value V
+	seq   uint64
+}
+
+// shardCursor is a cursor on sorted entries of a shard, and cursorHeap is a
+// min-heap of cursors by the sequence numbers of their current entries, which
+// is used to merge the entries of shards.
+type shardCursor[K comparable, V any] struct {
+	ents []seqEntry[K, V]
+	pos  int
+}
+
+type cursorHeap[K comparable, V any] []*shardCursor[K, V]
+
+func (h cursorHeap[K, V]) Len() int { return len(h) }
+func (h cursorHeap[K, V]) Less(i, j int) bool {
+	return h[i].ents[h[i].pos].seq < h[j].ents[h[j].pos].seq
+}
+func (h cursorHeap[K, V]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *cursorHeap[K, V]) Push(x any)   { *h = append(*h, x.(*shardCursor[K, V])) }
+func (h *cursorHeap[K, V]) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in this map in the order of key insertions.
+// If fn returns false, this method stops the iteration.
+//
+// This method copies the entries of each shard under its lock, and then
+// merges them without locks, so fn can modify this map. The entries of each
+// shard are consistent, but the copies of different shards are taken at
+// slightly different times if this map is modified concurrently.
+func (sm *ShardedMap[K, V]) Range(fn func(key K, value V) bool) {
+	h := make(cursorHeap[K, V], 0, len(sm.shards))
+	for i := range sm.shards {
+		ents := sm.shards[i].sortedEntries()
+		if len(ents) > 0 {
+			h = append(h, &shardCursor[K, V]{ents: ents})
+		}
+	}
+	heap.Init(&h)
+
+	for h.Len() > 0 {
+		c := h[0]
+		ent := c.ents[c.pos]
+		if !fn(ent.key, ent.value) {
+			return
+		}
+		c.pos++
+		if c.pos < len(c.ents) {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+}
+
+func (s *mapShard[K, V]) sortedEntries() []seqEntry[K, V] {
+	s.mu.RLock()
+	ents := make([]seqEntry[K, V], 0, len(s.m))
+	for k, e := range s.m {
+		ents = append(ents, seqEntry[K, V]{key: k, value: e.value, seq: e.seq})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(ents, func(i, j int) bool {
+		return ents[i].seq < ents[j].seq
+	})
+	return ents
+}
+
+// ToMap is a method which returns a Map which has the entries of this map in
+// the order of key insertions.
+func (sm *ShardedMap[K, V]) ToMap() Map[K, V] {
+	om := New[K, V]()
+	sm.Range(func(key K, value V) bool {
+		om.Store(key, value)
+		return true
+	})
+	return om
+}
+
+// String is a method which returns a string of the content of this map.
+func (sm *ShardedMap[K, V]) String() string {
+	var buf strings.Builder
+	buf.WriteString("ShardedMap[")
+	first := true
+	sm.Range(func(key K, value V) bool {
+		if !first {
+			buf.WriteString(" ")
+		}
+		first = false
+		buf.WriteString(fmt.Sprintf("%v:%v", key, value))
+		return true
+	})
+	buf.WriteString("]")
+	return buf.String()
+}
@@ -0,0 +1,298 @@
+package orderedmap_test
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func shardedKeys(sm *orderedmap.ShardedMap[string, int]) []string {
+	var keys []string
+	sm.Range(func(k string, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	return keys
+}
+
+func TestNewShardedMap(t *testing.T) {
+	sm := orderedmap.NewShardedMap[string, int](0)
+	assert.Equal(t, sm.Len(), 0)
+	assert.Equal(t, sm.String(), "ShardedMap[]")
+
+	sm = orderedmap.NewShardedMap[string, int](3)
+	assert.Equal(t, sm.Len(), 0)
+}
+
+func TestShardedMap_storeAndLoad(t *testing.T) {
+	sm := orderedmap.NewShardedMap[string, int](4)
+	sm.Store("c", 3)
+	sm.Store("a", 1)
+	sm.Store("b", 2)
+	sm.Store("a", 10)
+
+	assert.Equal(t, sm.Len(), 3)
+	assert.Equal(t, sm.String(), "ShardedMap[c:3 a:10 b:2]")
+
+	v, ok := sm.Load("a")
+	assert.True(t, ok)
+	assert.Equal(t, v, 10)
+	v, ok = sm.Load("x")
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+}
+
+func TestShardedMap_swapAndLoadOrStore(t *testing.T) {
+	sm := orderedmap.NewShardedMap[string, int](4)
+
+	prev, loaded := sm.Swap("a", 1)
+	assert.False(t, loaded)
+	assert.Equal(t, prev, 0)
+	prev, loaded = sm.Swap("a", 2)
+	assert.True(t, loaded)
+	assert.Equal(t, prev, 1)
+
+	actual, loaded := sm.LoadOrStore("b", 3)
+	assert.False(t, loaded)
+	assert.Equal(t, actual, 3)
+	actual, loaded = sm.LoadOrStore("b", 4)
+	assert.True(t, loaded)
+	assert.Equal(t, actual, 3)
+
+	assert.Equal(t, sm.String(), "ShardedMap[a:2 b:3]")
+}
+
+func TestShardedMap_delete(t *testing.T) {
+	sm := orderedmap.NewShardedMap[string, int](4)
+	sm.Store("a", 1)
+	sm.Store("b", 2)
+	sm.Store("c", 3)
+
+	sm.Delete("a")
+	sm.Delete("x")
+	assert.Equal(t, sm.String(), "ShardedMap[b:2 c:3]")
+
+	v, loaded := sm.LoadAndDelete("b")
+	assert.True(t, loaded)
+	assert.Equal(t, v, 2)
+	v, loaded = sm.LoadAndDelete("b")
+	assert.False(t, loaded)
+	assert.Equal(t, v, 0)
+
+	sm.Store("a", 4)
+	assert.Equal(t, sm.String(), "ShardedMap[c:3 a:4]")
+	assert.Equal(t, sm.Len(), 2)
+}
+
+func TestShardedMap_Range(t *testing.T) {
+	sm := orderedmap.NewShardedMap[string, int](8)
+	var expected []string
+	for i := 0; i < 100; i++ {
+		k := strconv.Itoa(99 - i)
+		sm.Store(k, i)
+		expected = append(expected, k)
+	}
+	assert.Equal(t, shardedKeys(sm), expected)
+
+	var keys []string
+	sm.Range(func(k string, v int) bool {
+		keys = append(keys, k)
+		return len(keys) < 3
+	})
+	assert.Equal(t, keys, []string{"99", "98", "97"})
+
+	sm.Range(func(k string, v int) bool {
+		sm.Delete(k)
+		return true
+	})
+	assert.Equal(t, sm.Len(), 0)
+}
+
+func TestShardedMap_ToMap(t *testing.T) {
+	sm := orderedmap.NewShardedMap[string, int](2)
+	sm.Store("b", 2)
+	sm.Store("a", 1)
+	sm.Store("c", 3)
+
+	om := sm.ToMap()
+	assert.Equal(t, om.String(), "Map[b:2 a:1 c:3]")
+}
+
+func TestShardedMap_concurrent(t *testing.T) {
+	sm := orderedmap.NewShardedMap[string, int](16)
+
+	const goroutines = 8
+	const perG = 500
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perG; i++ {
+				k := fmt.Sprintf("%d-%d", g, i)
+				sm.Store(k, i)
+				sm.Load(k)
+				if i%3 == 0 {
+					sm.Delete(k)
+				}
+				if i%50 == 0 {
+					sm.Len()
+					sm.Range(func(string, int) bool { return true })
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	expectedLen := goroutines * (perG - (perG+2)/3)
+	assert.Equal(t, sm.Len(), expectedLen)
+
+	// The keys stored by each goroutine keep their order of insertions.
+	last := make(map[string]int)
+	sm.Range(func(k string, v int) bool {
+		var g, i int
+		fmt.Sscanf(k, "%d-%d", &g, &i)
+		gk := strconv.Itoa(g)
+		if prev, ok := last[gk]; ok {
+			assert.True(t, prev < i, "key=%s", k)
+		}
+		last[gk] = i
+		return true
+	})
+	assert.Equal(t, len(last), goroutines)
+}
+
+func TestShardedMap_concurrentOrder(t *testing.T) {
+	sm := orderedmap.NewShardedMap[int, int](4)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			<-start
+			for i := 0; i < 250; i++ {
+				sm.Store(g*1000+i, i)
+			}
+		}(g)
+	}
+	close(start)
+	wg.Wait()
+
+	var keys []int
+	sm.Range(func(k, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	assert.Equal(t, len(keys), 1000)
+
+	sorted := append([]int(nil), keys...)
+	sort.Ints(sorted)
+	for i, k := range sorted {
+		assert.Equal(t, k, (i/250)*1000+i%250)
+	}
+}
+
+const benchKeys = 1024
+
+func benchKeyStrings() []string {
+	keys := make([]string, benchKeys)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	return keys
+}
+
+// mutexMap is a Map wrapped with a mutex, which is compared with ShardedMap in
+// the benchmarks.
+type mutexMap struct {
+	mu sync.RWMutex
+	om orderedmap.Map[string, int]
+}
+
+func (m *mutexMap) Load(key string) (int, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.om.Load(key)
+}
+
+func (m *mutexMap) Store(key string, value int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.om.Store(key, value)
+}
+
+type benchMap interface {
+	Load(key string) (int, bool)
+	Store(key string, value int)
+}
+
+type syncMap struct {
+	m sync.Map
+}
+
+func (m *syncMap) Load(key string) (int, bool) {
+	v, ok := m.m.Load(key)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+
+func (m *syncMap) Store(key string, value int) {
+	m.m.Store(key, value)
+}
+
+func benchmarkMixed(b *testing.B, newMap func() benchMap, writePercent int) {
+	keys := benchKeyStrings()
+	m := newMap()
+	for i, k := range keys {
+		m.Store(k, i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			k := keys[i%benchKeys]
+			if i%100 < writePercent {
+				m.Store(k, i)
+			} else {
+				m.Load(k)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkMixed(b *testing.B) {
+	maps := []struct {
+		name string
+		new  func() benchMap
+	}{
+		{"ShardedMap", func() benchMap {
+			return orderedmap.NewShardedMap[string, int](0)
+		}},
+		{"SyncMap", func() benchMap {
+			return &syncMap{}
+		}},
+		{"MutexMap", func() benchMap {
+			return &mutexMap{om: orderedmap.New[string, int]()}
+		}},
+	}
+	for _, writePercent := range []int{10, 50, 90} {
+		for _, m := range maps {
+			name := fmt.Sprintf("%s/write%d", m.name, writePercent)
+			b.Run(name, func(b *testing.B) {
+				benchmarkMixed(b, m.new, writePercent)
+			})
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cowToken is a mark which indicates that the entries of a map are shared
+// with snapshots. Each snapshot sets a new token, so that SafeRange can know
+// whether other snapshots were taken during its iteration.
+type cowToken struct {
+	_ byte
+}
+
+// Snapshot is a struct which represents a read-only view of the content of a
+// Map at the time when it was taken by Map.Snapshot.
+// The content of a snapshot is not affected by any later writes to the map.
+type Snapshot[K comparable, V any] struct {
+	m    map[K](*Entry[K, V])
+	head *Entry[K, V]
+	last *Entry[K, V]
+	len  int
+}
+
+// Snapshot is a method which returns a read-only view of the current content
+// of this map.
+// This method does not copy entries. Instead, the first write to this map
+// after this method copies the entries, so that the snapshot is unaffected.
+// Therefore, taking a snapshot of a map which is not changed afterwards costs
+// nothing, and writes which change nothing, such as deleting an absent key,
+// do not copy the entries.
+//
+// Because of the copy, an entry which was got from this map by Front, Back,
+// Prev or Next before taking a snapshot is detached from this map by the
+// first write after it. A detached entry keeps the content at the time of
+// the snapshot, so the entries should be got from this map again after
+// writing.
+func (om *Map[K, V]) Snapshot() Snapshot[K, V] {
+	om.cow = &cowToken{}
+	return Snapshot[K, V]{m: om.m, head: om.head, last: om.last, len: om.len}
+}
+
+// SafeRange is a method which calls the specified function: fn sequentially
+// for each key and value in this map like Range, but the iteration is not
+// broken even if fn stores or deletes entries of this map, including the
+// current entry.
+// The iteration is over the entries at the time when this method is called.
+// If fn writes to this map, the entries are copied once at the first write.
+func (om *Map[K, V]) SafeRange(fn func(key K, value V) bool) {
+	prev := om.cow
+	s := om.Snapshot()
+	token := om.cow
+
+	s.Range(fn)
+
+	if om.cow == token {
+		om.cow = prev
+	}
+}
+
+// beforeWrite is a method which is called by methods just before they modify
+// entries, counts the modification, and copies the entries if they are
+// shared with snapshots.
+// This method returns true if the entries are copied, then the entries which
+// the caller has looked up have to be looked up again.
+func (om *Map[K, V]) beforeWrite() bool {
+	om.mod++
+	if om.cow == nil {
+		return false
+	}
+	om.cow = nil
+
+	m := make(map[K](*Entry[K, V]), len(om.m))
+	var head, last *Entry[K, V]
+	for ent := om.head; ent != nil; ent = ent.next {
+		e := &Entry[K, V]{key: ent.key, value: ent.value, prev: last}
+		if last == nil {
+			head = e
+		} else {
+			last.next = e
+		}
+		last = e
+		m[e.key] = e
+	}
+	for k, ent := range om.m {
+		if ent.deleted {
+			m[k] = &Entry[K, V]{key: ent.key, value: ent.value, deleted: true}
+		}
+	}
+
+	om.m = m
+	om.head = head
+	om.last = last
+	return true
+}
+
+// Len is a method which returns the number of entries in this snapshot.
+func (s Snapshot[K, V]) Len() int {
+	return s.len
+}
+
+// Load is a method which returns a value stored in this snapshot for a key.
+// If no value was found for a key, the ok result is false.
+func (s Snapshot[K, V]) Load(key K) (value V, ok bool) {
+	ent, exists := s.m[key]
+	if exists && !ent.deleted {
+		value = ent.value
+		ok = true
+	}
+	return
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in this snapshot in the order of key insertions.
+// If fn returns false, this method stops the iteration.
+func (s Snapshot[K, V]) Range(fn func(key K, value V) bool) {
+	for ent := s.head; ent != nil; ent = ent.next {
+		if !fn(ent.key, ent.value) {
+			break
+		}
+	}
+}
+
+// Front is a method which returns the head entry of this snapshot.
+func (s Snapshot[K, V]) Front() *Entry[K, V] {
+	return s.head
+}
+
+// Back is a method which returns the last entry of this snapshot.
+func (s Snapshot[K, V]) Back() *Entry[K, V] {
+	return s.last
+}
+
+// String is a method which returns a string of the content of this snapshot.
+func (s Snapshot[K, V]) String() string {
+	var buf strings.Builder
+	buf.WriteString("Snapshot[")
+	ent := s.Front()
+	if ent != nil {
+		buf.WriteString(fmt.Sprintf("%v:%v", ent.Key(), ent.Value()))
+		for ent = ent.Next(); ent != nil; ent = ent.Next() {
+			buf.WriteString(fmt.Sprintf(" %v:%v", ent.Key(), ent.Value()))
+		}
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
+
+// HashSnapshot is a struct which represents a read-only view of the content
+// of a HashMap at the time when it was taken by HashMap.Snapshot.
+// The content of a snapshot is not affected by any later writes to the map.
+type HashSnapshot[K any, V any] struct {
+	s    Snapshot[*hashKey[K], V]
+	keys *hashKeys[K]
+}
+
+// Snapshot is a method which returns a read-only view of the current content
+// of this map, like Map.Snapshot.
+// The table of the keys of this map is also shared with the snapshot, and is
+// copied by the first write which adds or removes a key after this method.
+func (hm *HashMap[K, V]) Snapshot() HashSnapshot[K, V] {
+	if hm.keys != nil {
+		hm.keys.shared = true
+	}
+	return HashSnapshot[K, V]{s: hm.om.Snapshot(), keys: hm.keys}
+}
+
+// SafeRange is a method which calls the specified function: fn sequentially
+// for each key and value in this map like Map.SafeRange, so the iteration is
+// not broken even if fn stores or deletes entries of this map.
+func (hm *HashMap[K, V]) SafeRange(fn func(key K, value V) bool) {
+	hm.om.SafeRange(func(box *hashKey[K], value V) bool {
+		return fn(box.key, value)
+	})
+}
+
+// Len is a method which returns the number of entries in this snapshot.
+func (s HashSnapshot[K, V]) Len() int {
+	return s.s.Len()
+}
+
+// Load is a method which returns a value stored in this snapshot for a key.
+// If no value was found for a key, the ok result is false.
+func (s HashSnapshot[K, V]) Load(key K) (value V, ok bool) {
+	box := s.keys.lookup(key)
+	if box == nil {
+		return
+	}
+	return s.s.Load(box)
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in this snapshot in the order of key insertions.
+// If fn returns false, this method stops the iteration.
+func (s HashSnapshot[K, V]) Range(fn func(key K, value V) bool) {
+	s.s.Range(func(box *hashKey[K], value V) bool {
+		return fn(box.key, value)
+	})
+}
+
+// Front is a method which returns the head entry of this snapshot.
+func (s HashSnapshot[K, V]) Front() *HashEntry[K, V] {
+	return (*HashEntry[K, V])(s.s.Front())
+}
+
+// Back is a method which returns the last entry of this snapshot.
+func (s HashSnapshot[K, V]) Back() *HashEntry[K, V] {
+	return (*HashEntry[K, V])(s.s.Back())
+}
+
+// String is a method which returns a string of the content of this snapshot.
+func (s HashSnapshot[K, V]) String() string {
+	return hashMapString("HashSnapshot[", s.s.head)
+}
@@ -0,0 +1,210 @@
+package orderedmap_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func newABC() orderedmap.Map[string, int] {
+	om := orderedmap.New[string, int]()
+	om.Store("a", 1)
+	om.Store("b", 2)
+	om.Store("c", 3)
+	return om
+}
+
+func TestMap_Snapshot(t *testing.T) {
+	om := newABC()
+	s := om.Snapshot()
+	assert.Equal(t, s.Len(), 3)
+	assert.Equal(t, s.String(), "Snapshot[a:1 b:2 c:3]")
+
+	om.Store("d", 4)
+	om.Store("a", 10)
+	om.Delete("b")
+	om.Ldelete("c")
+
+	assert.Equal(t, om.String(), "Map[a:10 d:4]")
+	assert.Equal(t, s.String(), "Snapshot[a:1 b:2 c:3]")
+	assert.Equal(t, s.Len(), 3)
+
+	v, ok := s.Load("c")
+	assert.True(t, ok)
+	assert.Equal(t, v, 3)
+	_, ok = s.Load("d")
+	assert.False(t, ok)
+
+	om.Store("c", 30)
+	assert.Equal(t, om.String(), "Map[a:10 d:4 c:30]")
+	assert.Equal(t, s.String(), "Snapshot[a:1 b:2 c:3]")
+}
+
+func TestMap_Snapshot_tombstones(t *testing.T) {
+	om := newABC()
+	om.Ldelete("b")
+
+	s := om.Snapshot()
+	_, ok := s.Load("b")
+	assert.False(t, ok)
+
+	om.Store("b", 20)
+	assert.Equal(t, om.String(), "Map[a:1 c:3 b:20]")
+	_, ok = s.Load("b")
+	assert.False(t, ok)
+	assert.Equal(t, s.String(), "Snapshot[a:1 c:3]")
+}
+
+func TestMap_Snapshot_multipleSnapshots(t *testing.T) {
+	om := newABC()
+	s1 := om.Snapshot()
+	om.Store("d", 4)
+	s2 := om.Snapshot()
+	s3 := om.Snapshot()
+	om.Delete("a")
+
+	assert.Equal(t, s1.String(), "Snapshot[a:1 b:2 c:3]")
+	assert.Equal(t, s2.String(), "Snapshot[a:1 b:2 c:3 d:4]")
+	assert.Equal(t, s3.String(), "Snapshot[a:1 b:2 c:3 d:4]")
+	assert.Equal(t, om.String(), "Map[b:2 c:3 d:4]")
+}
+
+func TestMap_Snapshot_allWriteMethods(t *testing.T) {
+	writes := []func(om *orderedmap.Map[string, int]){
+		func(om *orderedmap.Map[string, int]) { om.Store("a", 9) },
+		func(om *orderedmap.Map[string, int]) { om.Swap("a", 9) },
+		func(om *orderedmap.Map[string, int]) { om.LoadOrStore("x", 9) },
+		func(om *orderedmap.Map[string, int]) {
+			om.LoadOrStoreFunc("x", func() (int, error) { return 9, nil })
+		},
+		func(om *orderedmap.Map[string, int]) { om.Delete("a") },
+		func(om *orderedmap.Map[string, int]) { om.Ldelete("a") },
+		func(om *orderedmap.Map[string, int]) { om.LoadAndDelete("b") },
+		func(om *orderedmap.Map[string, int]) { om.LoadAndLdelete("b") },
+		func(om *orderedmap.Map[string, int]) { om.FrontAndDelete() },
+		func(om *orderedmap.Map[string, int]) { om.FrontAndLdelete() },
+		func(om *orderedmap.Map[string, int]) { om.BackAndDelete() },
+		func(om *orderedmap.Map[string, int]) { om.BackAndLdelete() },
+	}
+
+	for _, write := range writes {
+		om := newABC()
+		s := om.Snapshot()
+		write(&om)
+		assert.NotEqual(t, om.String(), "Map[a:1 b:2 c:3]")
+		assert.Equal(t, s.String(), "Snapshot[a:1 b:2 c:3]")
+
+		var keys []string
+		for ent := s.Back(); ent != nil; ent = ent.Prev() {
+			keys = append(keys, ent.Key())
+		}
+		assert.Equal(t, keys, []string{"c", "b", "a"})
+	}
+}
+
+func TestMap_Snapshot_iterateWhileDeleting(t *testing.T) {
+	om := newABC()
+
+	var visited []string
+	for ent := om.Snapshot().Front(); ent != nil; ent = ent.Next() {
+		visited = append(visited, ent.Key())
+		om.Delete(ent.Key())
+	}
+	assert.Equal(t, visited, []string{"a", "b", "c"})
+	assert.Equal(t, om.Len(), 0)
+}
+
+func TestMap_SafeRange(t *testing.T) {
+	om := newABC()
+
+	var visited []string
+	om.SafeRange(func(k string, v int) bool {
+		visited = append(visited, k)
+		om.Delete(k)
+		if k == "a" {
+			om.Delete("c")
+			om.Store("d", 4)
+		}
+		return true
+	})
+	assert.Equal(t, visited, []string{"a", "b", "c"})
+	assert.Equal(t, om.String(), "Map[d:4]")
+
+	visited = nil
+	om = newABC()
+	om.SafeRange(func(k string, v int) bool {
+		visited = append(visited, k)
+		return k != "b"
+	})
+	assert.Equal(t, visited, []string{"a", "b"})
+}
+
+func TestMap_SafeRange_keepsOtherSnapshots(t *testing.T) {
+	om := newABC()
+
+	var s orderedmap.Snapshot[string, int]
+	om.SafeRange(func(k string, v int) bool {
+		if k == "b" {
+			s = om.Snapshot()
+		}
+		return true
+	})
+	om.Store("a", 10)
+	assert.Equal(t, s.String(), "Snapshot[a:1 b:2 c:3]")
+
+	s2 := om.Snapshot()
+	om.SafeRange(func(k string, v int) bool { return true })
+	om.Store("b", 20)
+	assert.Equal(t, s2.String(), "Snapshot[a:10 b:2 c:3]")
+	assert.Equal(t, om.String(), "Map[a:10 b:20 c:3]")
+}
+
+func TestMap_Snapshot_noOpWritesDoNotCopy(t *testing.T) {
+	om := newABC()
+	om.Store("x", 0)
+	om.Ldelete("x")
+	front := om.Front()
+	om.Snapshot()
+
+	om.Delete("z")
+	om.Ldelete("z")
+	om.Ldelete("x")
+	om.LoadAndDelete("z")
+	om.LoadAndLdelete("x")
+	om.LoadOrStore("a", 10)
+	om.LoadOrStoreFunc("z", func() (int, error) { return 0, errors.New("x") })
+	om.Compute("z", func(v int, ok bool) (int, bool) { return 0, false })
+	om.MoveToFront("a")
+	om.MoveToBack("z")
+	om.MoveBefore("a", "b")
+	om.SortFunc(func(a, b *orderedmap.Entry[string, int]) bool {
+		return a.Key() < b.Key()
+	})
+	om.DeleteIf(func(k string, v int) bool { return false })
+	assert.Same(t, om.Front(), front)
+
+	om.Store("a", 10)
+	assert.NotSame(t, om.Front(), front)
+	assert.Equal(t, front.Value(), 1)
+	assert.Equal(t, om.Front().Value(), 10)
+	assert.Equal(t, om.String(), "Map[a:10 b:2 c:3]")
+
+	s := om.Snapshot()
+	om.DeleteIf(func(k string, v int) bool { return k != "a" })
+	assert.Equal(t, om.String(), "Map[a:10]")
+	assert.Equal(t, s.String(), "Snapshot[a:10 b:2 c:3]")
+}
+
+func BenchmarkMap_Snapshot_noWrites(b *testing.B) {
+	om := orderedmap.New[int, int]()
+	for i := 0; i < 10000; i++ {
+		om.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := om.Snapshot()
+		s.Load(i % 10000)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strconv"
+)
+
+const sqlFormat = "sql"
+
+var rawBytesType = reflect.TypeOf(sql.RawBytes{})
+
+// ScanRow is a function which scans the current row of rows into an ordered
+// map of which keys are column names and are in the order of the columns.
+// Like sql.Rows.Scan, rows.Next has to be called before this function.
+//
+// Each column value is scanned into a Go value of the type reported by
+// sql.ColumnType.ScanType if the driver reports it, and SQL NULL is stored as
+// nil.
+// If a scan type implements driver.Valuer, like sql.NullInt64, the result of
+// its Value method is stored.
+//
+// If multiple columns have a same name, such as columns of joined tables, the
+// first one has the name and the later ones have the name with a suffix of a
+// sequence number from 2, like "id_2", which is not used by other columns.
+func ScanRow(rows *sql.Rows) (*Map[string, any], error) {
+	cts, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	dests := make([]any, len(cts))
+	for i, ct := range cts {
+		dests[i] = newScanDest(ct.ScanType())
+	}
+
+	err = rows.Scan(dests...)
+	if err != nil {
+		return nil, err
+	}
+
+	names := uniqueColumnNames(cts)
+	om := New[string, any]()
+	for i := range cts {
+		v, err := scannedValue(dests[i])
+		if err != nil {
+			return nil, err
+		}
+		om.Store(names[i], v)
+	}
+	return &om, nil
+}
+
+func uniqueColumnNames(cts []*sql.ColumnType) []string {
+	used := make(map[string]bool, len(cts))
+	for _, ct := range cts {
+		used[ct.Name()] = true
+	}
+
+	names := make([]string, len(cts))
+	seen := make(map[string]bool, len(cts))
+	for i, ct := range cts {
+		name := ct.Name()
+		if seen[name] {
+			base := name
+			for n := 2; used[name]; n++ {
+				name = base + "_" + strconv.Itoa(n)
+			}
+			used[name] = true
+		}
+		seen[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// ScanAll is a function which scans all remaining rows of rows into ordered
+// maps with ScanRow, and closes rows.
+func ScanAll(rows *sql.Rows) ([]*Map[string, any], error) {
+	defer rows.Close()
+
+	oms := make([]*Map[string, any], 0)
+	for rows.Next() {
+		om, err := ScanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		oms = append(oms, om)
+	}
+	err := rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	return oms, nil
+}
+
+// newScanDest creates a scan destination which is a pointer to a pointer of a
+// scan type, so that SQL NULL can be scanned as a nil pointer.
+func newScanDest(t reflect.Type) any {
+	if t == nil || t.Kind() == reflect.Interface {
+		return new(any)
+	}
+	if t == rawBytesType {
+		// sql.RawBytes refers to a memory owned by the driver, so scan into
+		// []byte which is copied.
+		t = reflect.TypeOf([]byte(nil))
+	}
+	return reflect.New(reflect.PtrTo(t)).Interface()
+}
+
+func scannedValue(dest any) (any, error) {
+	if p, ok := dest.(*any); ok {
+		return *p, nil
+	}
+
+	rv := reflect.ValueOf(dest).Elem()
+	if rv.IsNil() {
+		return nil, nil
+	}
+	v := rv.Elem().Interface()
+	if valuer, ok := v.(driver.Valuer); ok {
+		return valuer.Value()
+	}
+	return v, nil
+}
+
+// Value is a method which returns a JSON string of this map as a byte array.
+// This method is an implementation of driver.Valuer interface, which makes
+// this map be able to be stored into a JSON or JSONB column.
+func (om Map[K, V]) Value() (driver.Value, error) {
+	return om.MarshalJSON()
+}
+
+// Scan is a method which replaces the content of this map with a JSON
+// string read from a database column.
+// This method is an implementation of sql.Scanner interface.
+// The source value has to be a []byte, a string or nil. If it is nil, this
+// map becomes empty.
+func (om *Map[K, V]) Scan(src any) error {
+	var data []byte
+	switch x := src.(type) {
+	case nil:
+	case []byte:
+		data = x
+	case string:
+		data = []byte(x)
+	default:
+		return DecodeTypeError{
+			Format: sqlFormat,
+			Value:  binaryTypeName(src),
+			Type:   reflect.TypeOf(om).Elem(),
+		}
+	}
+
+	om.Clear()
+	if len(data) == 0 {
+		return nil
+	}
+	return om.UnmarshalJSON(data)
+}
@@ -0,0 +1,377 @@
+package orderedmap_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+// fakeColumn is a column of a result set returned by fakeDriver.
+type fakeColumn struct {
+	name     string
+	scanType reflect.Type
+	dbType   string
+}
+
+// fakeResult is a result set, or an error which is returned by a query.
+type fakeResult struct {
+	columns []fakeColumn
+	rows    [][]driver.Value
+	err     error
+}
+
+// fakeDriver is an in-memory driver of which queries return result sets
+// registered by their query strings, and which records arguments of
+// executed statements.
+type fakeDriver struct {
+	mu      sync.Mutex
+	results map[string]fakeResult
+	execs   [][]driver.Value
+}
+
+var theFakeDriver = &fakeDriver{results: make(map[string]fakeResult)}
+
+func init() {
+	sql.Register("orderedmap-fake", theFakeDriver)
+}
+
+func (d *fakeDriver) setResult(query string, r fakeResult) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.results[query] = r
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{d: d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{d: c.d, query: query}, nil
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s fakeStmt) Close() error {
+	return nil
+}
+
+func (s fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.execs = append(s.d.execs, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	r, ok := s.d.results[s.query]
+	if !ok {
+		return nil, errors.New("unknown query: " + s.query)
+	}
+	return &fakeRows{result: r}, nil
+}
+
+type fakeRows struct {
+	result fakeResult
+	pos    int
+}
+
+func (r *fakeRows) Columns() []string {
+	names := make([]string, len(r.result.columns))
+	for i, c := range r.result.columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.result.rows) {
+		if r.result.err != nil {
+			return r.result.err
+		}
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+func (r *fakeRows) ColumnTypeScanType(index int) reflect.Type {
+	return r.result.columns[index].scanType
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.result.columns[index].dbType
+}
+
+func openFakeDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("orderedmap-fake", "")
+	assert.Nil(t, err)
+	return db
+}
+
+func TestScanRow(t *testing.T) {
+	theFakeDriver.setResult("SELECT z, a, m FROM t", fakeResult{
+		columns: []fakeColumn{
+			{name: "z", scanType: reflect.TypeOf(int64(0)), dbType: "BIGINT"},
+			{name: "a", scanType: reflect.TypeOf(""), dbType: "TEXT"},
+			{name: "m", scanType: reflect.TypeOf(false), dbType: "BOOL"},
+		},
+		rows: [][]driver.Value{
+			{int64(1), "foo", true},
+		},
+	})
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT z, a, m FROM t")
+	assert.Nil(t, err)
+	defer rows.Close()
+
+	assert.True(t, rows.Next())
+	om, err := orderedmap.ScanRow(rows)
+	assert.Nil(t, err)
+	assert.Equal(t, om.String(), "Map[z:1 a:foo m:true]")
+
+	v, _ := om.Load("z")
+	assert.Equal(t, v, int64(1))
+	assert.False(t, rows.Next())
+}
+
+func TestScanRow_scanTypes(t *testing.T) {
+	ts := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	theFakeDriver.setResult("SELECT scan types", fakeResult{
+		columns: []fakeColumn{
+			{name: "i32", scanType: reflect.TypeOf(int32(0))},
+			{name: "f", scanType: reflect.TypeOf(float64(0))},
+			{name: "s", scanType: reflect.TypeOf("")},
+			{name: "raw", scanType: reflect.TypeOf(sql.RawBytes{})},
+			{name: "ts", scanType: reflect.TypeOf(time.Time{})},
+			{name: "ni", scanType: reflect.TypeOf(sql.NullInt64{})},
+			{name: "ns", scanType: reflect.TypeOf(sql.NullString{})},
+			{name: "any", scanType: nil},
+		},
+		rows: [][]driver.Value{
+			{int64(7), 1.5, []byte("bytes to string"), []byte("raw"), ts, int64(3), nil, []byte("x")},
+			{nil, nil, nil, nil, nil, nil, "s", nil},
+		},
+	})
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT scan types")
+	assert.Nil(t, err)
+	oms, err := orderedmap.ScanAll(rows)
+	assert.Nil(t, err)
+	assert.Equal(t, len(oms), 2)
+
+	om := oms[0]
+	v, _ := om.Load("i32")
+	assert.Equal(t, v, int32(7))
+	v, _ = om.Load("f")
+	assert.Equal(t, v, 1.5)
+	v, _ = om.Load("s")
+	assert.Equal(t, v, "bytes to string")
+	v, _ = om.Load("raw")
+	assert.Equal(t, v, []byte("raw"))
+	v, _ = om.Load("ts")
+	assert.Equal(t, v, ts)
+	v, _ = om.Load("ni")
+	assert.Equal(t, v, int64(3))
+	v, _ = om.Load("ns")
+	assert.Nil(t, v)
+	v, _ = om.Load("any")
+	assert.Equal(t, v, []byte("x"))
+
+	om = oms[1]
+	assert.Equal(t, om.String(),
+		"Map[i32:<nil> f:<nil> s:<nil> raw:<nil> ts:<nil> ni:<nil> ns:s any:<nil>]")
+}
+
+func TestScanRow_duplicatedColumnNames(t *testing.T) {
+	theFakeDriver.setResult("SELECT dup", fakeResult{
+		columns: []fakeColumn{
+			{name: "a", scanType: reflect.TypeOf(int64(0))},
+			{name: "b", scanType: reflect.TypeOf(int64(0))},
+			{name: "a", scanType: reflect.TypeOf(int64(0))},
+		},
+		rows: [][]driver.Value{{int64(1), int64(2), int64(3)}},
+	})
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT dup")
+	assert.Nil(t, err)
+	oms, err := orderedmap.ScanAll(rows)
+	assert.Nil(t, err)
+	assert.Equal(t, oms[0].String(), "Map[a:1 b:2 a_2:3]")
+
+	theFakeDriver.setResult("SELECT dup2", fakeResult{
+		columns: []fakeColumn{
+			{name: "a", scanType: reflect.TypeOf(int64(0))},
+			{name: "a", scanType: reflect.TypeOf(int64(0))},
+			{name: "a_2", scanType: reflect.TypeOf(int64(0))},
+			{name: "a", scanType: reflect.TypeOf(int64(0))},
+		},
+		rows: [][]driver.Value{{int64(1), int64(2), int64(3), int64(4)}},
+	})
+
+	rows, err = db.Query("SELECT dup2")
+	assert.Nil(t, err)
+	oms, err = orderedmap.ScanAll(rows)
+	assert.Nil(t, err)
+	assert.Equal(t, oms[0].String(), "Map[a:1 a_3:2 a_2:3 a_4:4]")
+}
+
+func TestScanRow_scanError(t *testing.T) {
+	theFakeDriver.setResult("SELECT bad", fakeResult{
+		columns: []fakeColumn{
+			{name: "n", scanType: reflect.TypeOf(int64(0))},
+		},
+		rows: [][]driver.Value{{"not a number"}},
+	})
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT bad")
+	assert.Nil(t, err)
+	oms, err := orderedmap.ScanAll(rows)
+	assert.NotNil(t, err)
+	assert.Nil(t, oms)
+}
+
+func TestScanAll_empty(t *testing.T) {
+	theFakeDriver.setResult("SELECT empty", fakeResult{
+		columns: []fakeColumn{{name: "a", scanType: reflect.TypeOf("")}},
+	})
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT empty")
+	assert.Nil(t, err)
+	oms, err := orderedmap.ScanAll(rows)
+	assert.Nil(t, err)
+	assert.Equal(t, len(oms), 0)
+}
+
+func TestScanAll_rowsError(t *testing.T) {
+	rowsErr := errors.New("connection lost")
+	theFakeDriver.setResult("SELECT broken", fakeResult{
+		columns: []fakeColumn{{name: "a", scanType: reflect.TypeOf("")}},
+		rows:    [][]driver.Value{{"x"}},
+		err:     rowsErr,
+	})
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT broken")
+	assert.Nil(t, err)
+	oms, err := orderedmap.ScanAll(rows)
+	assert.Equal(t, err, rowsErr)
+	assert.Nil(t, oms)
+}
+
+func TestMap_Value(t *testing.T) {
+	om := orderedmap.New[string, int]()
+	om.Store("z", 1)
+	om.Store("a", 2)
+
+	v, err := om.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, v, []byte(`{"z":1,"a":2}`))
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	_, err = db.Exec("INSERT INTO t (doc) VALUES (?)", om)
+	assert.Nil(t, err)
+
+	theFakeDriver.mu.Lock()
+	last := theFakeDriver.execs[len(theFakeDriver.execs)-1]
+	theFakeDriver.mu.Unlock()
+	assert.Equal(t, last, []driver.Value{[]byte(`{"z":1,"a":2}`)})
+}
+
+func TestMap_Scan(t *testing.T) {
+	om := orderedmap.New[string, any]()
+	om.Store("old", true)
+
+	assert.Nil(t, om.Scan([]byte(`{"z":1,"a":{"y":2,"b":3}}`)))
+	assert.Equal(t, om.String(), "Map[z:1 a:map[b:3 y:2]]")
+
+	assert.Nil(t, om.Scan(`{"k":"v"}`))
+	assert.Equal(t, om.String(), "Map[k:v]")
+
+	assert.Nil(t, om.Scan(nil))
+	assert.Equal(t, om.Len(), 0)
+	om.Store("after", 1)
+	assert.Equal(t, om.String(), "Map[after:1]")
+
+	err := om.Scan(int64(1))
+	assert.Equal(t, err.Error(),
+		"sql: cannot decode int64 into Go value of type orderedmap.Map[string,interface {}]")
+}
+
+func TestMap_Scan_column(t *testing.T) {
+	theFakeDriver.setResult("SELECT doc", fakeResult{
+		columns: []fakeColumn{{name: "doc", dbType: "JSONB"}},
+		rows: [][]driver.Value{
+			{[]byte(`{"z":1,"a":2}`)},
+			{nil},
+		},
+	})
+
+	db := openFakeDB(t)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT doc")
+	assert.Nil(t, err)
+	defer rows.Close()
+
+	om := orderedmap.New[string, int]()
+	assert.True(t, rows.Next())
+	assert.Nil(t, rows.Scan(&om))
+	assert.Equal(t, om.String(), "Map[z:1 a:2]")
+
+	assert.True(t, rows.Next())
+	assert.Nil(t, rows.Scan(&om))
+	assert.Equal(t, om.Len(), 0)
+}
@@ -0,0 +1,678 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package tomlom
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sttk/orderedmap"
+)
+
+type table = orderedmap.Map[string, any]
+
+type tableKind int
+
+const (
+	kindImplicit tableKind = iota
+	kindHeader
+	kindDotted
+	kindInline
+)
+
+var (
+	reDecInt = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+	reHexInt = regexp.MustCompile(`^0x[0-9A-Fa-f](_?[0-9A-Fa-f])*$`)
+	reOctInt = regexp.MustCompile(`^0o[0-7](_?[0-7])*$`)
+	reBinInt = regexp.MustCompile(`^0b[01](_?[01])*$`)
+	reFloat  = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$`)
+	reDate   = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}`)
+)
+
+// Unmarshal is a function which deserializes a TOML string into an ordered
+// map.
+// The map must be created with orderedmap.New, and the deserialized entries
+// are added to it.
+func Unmarshal(data []byte, om *orderedmap.Map[string, any]) error {
+	p := parser{
+		data:    data,
+		root:    om,
+		current: om,
+		kinds:   make(map[*table]tableKind),
+	}
+	p.kinds[om] = kindHeader
+	return p.parse()
+}
+
+type parser struct {
+	data    []byte
+	pos     int
+	root    *table
+	current *table
+	kinds   map[*table]tableKind
+}
+
+func (p *parser) errorf(msg string) error {
+	return SyntaxError{Line: bytes.Count(p.data[:p.pos], []byte("\n")) + 1, msg: msg}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.data[p.pos]
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	return bytes.HasPrefix(p.data[p.pos:], []byte(s))
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) skipComment() {
+	if p.peek() != '#' {
+		return
+	}
+	for !p.eof() && p.data[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+// skipBlank skips spaces, newlines and comments.
+func (p *parser) skipBlank() {
+	for {
+		p.skipSpaces()
+		p.skipComment()
+		if p.hasPrefix("\n") {
+			p.pos++
+		} else if p.hasPrefix("\r\n") {
+			p.pos += 2
+		} else {
+			return
+		}
+	}
+}
+
+func (p *parser) expectLineEnd() error {
+	p.skipSpaces()
+	p.skipComment()
+	if p.eof() {
+		return nil
+	}
+	if p.hasPrefix("\n") {
+		p.pos++
+		return nil
+	}
+	if p.hasPrefix("\r\n") {
+		p.pos += 2
+		return nil
+	}
+	return p.errorf("Expected a newline, but found '" + string(p.peek()) + "'")
+}
+
+func (p *parser) parse() error {
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+
+		var err error
+		if p.hasPrefix("[[") {
+			p.pos += 2
+			err = p.parseArrayTableHeader()
+		} else if p.hasPrefix("[") {
+			p.pos++
+			err = p.parseTableHeader()
+		} else {
+			err = p.parseKeyValue(p.current, p.kinds)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = p.expectLineEnd()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) parseHeaderKeys(closing string) ([]string, error) {
+	p.skipSpaces()
+	keys, err := p.parseKeys()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if !p.hasPrefix(closing) {
+		return nil, p.errorf("Expected '" + closing + "' of a table header")
+	}
+	p.pos += len(closing)
+	return keys, nil
+}
+
+// descend gets or creates an intermediate table for a table header.
+func (p *parser) descend(t *table, key string) (*table, error) {
+	v, ok := t.Load(key)
+	if !ok {
+		nt := orderedmap.New[string, any]()
+		t.Store(key, &nt)
+		p.kinds[&nt] = kindImplicit
+		return &nt, nil
+	}
+	switch x := v.(type) {
+	case *table:
+		if p.kinds[x] == kindInline {
+			return nil, p.errorf("Cannot extend an inline table: " + key)
+		}
+		return x, nil
+	case []*table:
+		return x[len(x)-1], nil
+	}
+	return nil, p.errorf("Key is already defined as a non-table value: " + key)
+}
+
+func (p *parser) parseTableHeader() error {
+	keys, err := p.parseHeaderKeys("]")
+	if err != nil {
+		return err
+	}
+
+	t := p.root
+	for _, k := range keys[:len(keys)-1] {
+		t, err = p.descend(t, k)
+		if err != nil {
+			return err
+		}
+	}
+
+	k := keys[len(keys)-1]
+	v, ok := t.Load(k)
+	if !ok {
+		nt := orderedmap.New[string, any]()
+		t.Store(k, &nt)
+		p.kinds[&nt] = kindHeader
+		p.current = &nt
+		return nil
+	}
+	if x, ok := v.(*table); ok && p.kinds[x] == kindImplicit {
+		p.kinds[x] = kindHeader
+		p.current = x
+		return nil
+	}
+	return p.errorf("Table is already defined: " + strings.Join(keys, "."))
+}
+
+func (p *parser) parseArrayTableHeader() error {
+	keys, err := p.parseHeaderKeys("]]")
+	if err != nil {
+		return err
+	}
+
+	t := p.root
+	for _, k := range keys[:len(keys)-1] {
+		t, err = p.descend(t, k)
+		if err != nil {
+			return err
+		}
+	}
+
+	k := keys[len(keys)-1]
+	nt := orderedmap.New[string, any]()
+	p.kinds[&nt] = kindHeader
+	p.current = &nt
+
+	v, ok := t.Load(k)
+	if !ok {
+		t.Store(k, []*table{&nt})
+		return nil
+	}
+	if arr, ok := v.([]*table); ok {
+		t.Store(k, append(arr, &nt))
+		return nil
+	}
+	return p.errorf("Key is already defined as a non-array of tables: " +
+		strings.Join(keys, "."))
+}
+
+func (p *parser) parseKeys() ([]string, error) {
+	keys := make([]string, 0, 1)
+	for {
+		k, err := p.parseSimpleKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+		p.skipSpaces()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+		p.skipSpaces()
+	}
+}
+
+func (p *parser) parseSimpleKey() (string, error) {
+	switch p.peek() {
+	case '"':
+		if p.hasPrefix(`"""`) {
+			return "", p.errorf("Multi-line strings are not allowed as keys")
+		}
+		p.pos++
+		return p.parseBasicString()
+	case '\'':
+		if p.hasPrefix(`'''`) {
+			return "", p.errorf("Multi-line strings are not allowed as keys")
+		}
+		p.pos++
+		return p.parseLiteralString()
+	}
+
+	start := p.pos
+	for !p.eof() {
+		c := p.data[p.pos]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') ||
+			(c >= '0' && c <= '9') || c == '_' || c == '-' {
+			p.pos++
+		} else {
+			break
+		}
+	}
+	if start == p.pos {
+		return "", p.errorf("Expected a key")
+	}
+	return string(p.data[start:p.pos]), nil
+}
+
+func (p *parser) parseKeyValue(t *table, kinds map[*table]tableKind) error {
+	keys, err := p.parseKeys()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces()
+	if p.peek() != '=' {
+		return p.errorf("Expected '=' after a key")
+	}
+	p.pos++
+	p.skipSpaces()
+
+	for _, k := range keys[:len(keys)-1] {
+		v, ok := t.Load(k)
+		if !ok {
+			nt := orderedmap.New[string, any]()
+			t.Store(k, &nt)
+			kinds[&nt] = kindDotted
+			t = &nt
+			continue
+		}
+		x, ok := v.(*table)
+		if !ok || kinds[x] != kindDotted {
+			return p.errorf("Cannot add keys to an already defined value: " + k)
+		}
+		t = x
+	}
+
+	k := keys[len(keys)-1]
+	if _, ok := t.Load(k); ok {
+		return p.errorf("Key is already defined: " + strings.Join(keys, "."))
+	}
+
+	v, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	t.Store(k, v)
+	return nil
+}
+
+func (p *parser) parseValue() (any, error) {
+	switch {
+	case p.hasPrefix(`"""`):
+		p.pos += 3
+		return p.parseMultiLineBasicString()
+	case p.hasPrefix(`"`):
+		p.pos++
+		return p.parseBasicString()
+	case p.hasPrefix(`'''`):
+		p.pos += 3
+		return p.parseMultiLineLiteralString()
+	case p.hasPrefix(`'`):
+		p.pos++
+		return p.parseLiteralString()
+	case p.hasPrefix("["):
+		p.pos++
+		return p.parseArray()
+	case p.hasPrefix("{"):
+		p.pos++
+		return p.parseInlineTable()
+	case p.hasPrefix("true"):
+		p.pos += 4
+		return true, nil
+	case p.hasPrefix("false"):
+		p.pos += 5
+		return false, nil
+	}
+	return p.parseNumberOrDateTime()
+}
+
+func (p *parser) parseEscape(buf *strings.Builder) error {
+	if p.eof() {
+		return p.errorf("Unterminated escape sequence")
+	}
+	c := p.data[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		buf.WriteByte('\b')
+	case 't':
+		buf.WriteByte('\t')
+	case 'n':
+		buf.WriteByte('\n')
+	case 'f':
+		buf.WriteByte('\f')
+	case 'r':
+		buf.WriteByte('\r')
+	case '"':
+		buf.WriteByte('"')
+	case '\\':
+		buf.WriteByte('\\')
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.data) {
+			return p.errorf("Invalid unicode escape sequence")
+		}
+		code, err := strconv.ParseUint(string(p.data[p.pos:p.pos+n]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("Invalid unicode escape sequence")
+		}
+		p.pos += n
+		buf.WriteRune(rune(code))
+	default:
+		return p.errorf("Invalid escape sequence: \\" + string(c))
+	}
+	return nil
+}
+
+func (p *parser) parseBasicString() (string, error) {
+	var buf strings.Builder
+	for !p.eof() {
+		c := p.data[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return buf.String(), nil
+		case '\\':
+			p.pos++
+			err := p.parseEscape(&buf)
+			if err != nil {
+				return "", err
+			}
+		case '\n':
+			return "", p.errorf("Unterminated string")
+		default:
+			buf.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("Unterminated string")
+}
+
+func (p *parser) parseLiteralString() (string, error) {
+	start := p.pos
+	for !p.eof() {
+		switch p.data[p.pos] {
+		case '\'':
+			s := string(p.data[start:p.pos])
+			p.pos++
+			return s, nil
+		case '\n':
+			return "", p.errorf("Unterminated string")
+		}
+		p.pos++
+	}
+	return "", p.errorf("Unterminated string")
+}
+
+func (p *parser) skipFirstNewline() {
+	if p.hasPrefix("\n") {
+		p.pos++
+	} else if p.hasPrefix("\r\n") {
+		p.pos += 2
+	}
+}
+
+// closesMultiLine checks whether the closing delimiter of a multi-line string
+// is at the current position, and returns the number of quotes which are a
+// part of the content, because up to two quotes can be adjacent to it.
+func (p *parser) closesMultiLine(delim string) (bool, int) {
+	if !p.hasPrefix(delim) {
+		return false, 0
+	}
+	n := 0
+	for n < 2 && p.pos+3+n < len(p.data) && p.data[p.pos+3+n] == delim[0] {
+		n++
+	}
+	return true, n
+}
+
+func (p *parser) parseMultiLineBasicString() (string, error) {
+	p.skipFirstNewline()
+
+	var buf strings.Builder
+	for !p.eof() {
+		if ok, n := p.closesMultiLine(`"""`); ok {
+			buf.WriteString(strings.Repeat(`"`, n))
+			p.pos += 3 + n
+			return buf.String(), nil
+		}
+		c := p.data[p.pos]
+		if c != '\\' {
+			buf.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		p.pos++
+		i := p.pos
+		for i < len(p.data) && (p.data[i] == ' ' || p.data[i] == '\t' || p.data[i] == '\r') {
+			i++
+		}
+		if i < len(p.data) && p.data[i] == '\n' {
+			p.pos = i
+			for !p.eof() && strings.IndexByte(" \t\r\n", p.data[p.pos]) >= 0 {
+				p.pos++
+			}
+			continue
+		}
+		err := p.parseEscape(&buf)
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", p.errorf("Unterminated multi-line string")
+}
+
+func (p *parser) parseMultiLineLiteralString() (string, error) {
+	p.skipFirstNewline()
+
+	start := p.pos
+	for !p.eof() {
+		if ok, n := p.closesMultiLine(`'''`); ok {
+			s := string(p.data[start : p.pos+n])
+			p.pos += 3 + n
+			return s, nil
+		}
+		p.pos++
+	}
+	return "", p.errorf("Unterminated multi-line string")
+}
+
+func (p *parser) parseArray() (any, error) {
+	arr := make([]any, 0)
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return arr, nil
+		}
+
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return arr, nil
+		default:
+			return nil, p.errorf("Expected ',' or ']' in an array")
+		}
+	}
+}
+
+func (p *parser) parseInlineTable() (any, error) {
+	t := orderedmap.New[string, any]()
+	kinds := make(map[*table]tableKind)
+
+	p.skipSpaces()
+	if p.peek() == '}' {
+		p.pos++
+		p.kinds[&t] = kindInline
+		return &t, nil
+	}
+
+	for {
+		p.skipSpaces()
+		err := p.parseKeyValue(&t, kinds)
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			p.kinds[&t] = kindInline
+			for nt := range kinds {
+				p.kinds[nt] = kindInline
+			}
+			return &t, nil
+		default:
+			return nil, p.errorf("Expected ',' or '}' in an inline table")
+		}
+	}
+}
+
+func (p *parser) scanToken() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.data[p.pos]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') ||
+			(c >= '0' && c <= '9') || strings.IndexByte("_+-.:", c) >= 0 {
+			p.pos++
+			continue
+		}
+		// A space can separate a date and a time in a date-time.
+		if c == ' ' && p.pos-start == 10 && reDate.Match(p.data[start:p.pos]) &&
+			p.pos+3 < len(p.data) && p.data[p.pos+3] == ':' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return string(p.data[start:p.pos])
+}
+
+func (p *parser) parseNumberOrDateTime() (any, error) {
+	tok := p.scanToken()
+	if len(tok) == 0 {
+		return nil, p.errorf("Expected a value")
+	}
+
+	if reDate.MatchString(tok) || strings.Contains(tok, ":") {
+		return p.parseDateTime(tok)
+	}
+
+	switch tok {
+	case "inf", "+inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	case "nan", "+nan", "-nan":
+		return math.NaN(), nil
+	}
+
+	var base int
+	switch {
+	case reDecInt.MatchString(tok):
+		base = 10
+	case reHexInt.MatchString(tok):
+		base, tok = 16, tok[2:]
+	case reOctInt.MatchString(tok):
+		base, tok = 8, tok[2:]
+	case reBinInt.MatchString(tok):
+		base, tok = 2, tok[2:]
+	case reFloat.MatchString(tok):
+		f, err := strconv.ParseFloat(strings.ReplaceAll(tok, "_", ""), 64)
+		if err != nil {
+			return nil, p.errorf("Invalid float: " + tok)
+		}
+		return f, nil
+	default:
+		return nil, p.errorf("Invalid value: " + tok)
+	}
+
+	n, err := strconv.ParseInt(strings.ReplaceAll(tok, "_", ""), base, 64)
+	if err != nil {
+		return nil, p.errorf("Invalid integer: " + tok)
+	}
+	return n, nil
+}
+
+func (p *parser) parseDateTime(tok string) (any, error) {
+	s := tok
+	if len(s) > 10 && (s[10] == ' ' || s[10] == 't') {
+		s = s[:10] + "T" + s[11:]
+	}
+	s = strings.Replace(s, "z", "Z", 1)
+
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if _, err := time.Parse("2006-01-02T15:04:05.999999999", s); err == nil {
+		return LocalDateTime(s), nil
+	}
+	if _, err := time.Parse("2006-01-02", s); err == nil {
+		return LocalDate(s), nil
+	}
+	if _, err := time.Parse("15:04:05.999999999", s); err == nil {
+		return LocalTime(s), nil
+	}
+	return nil, p.errorf("Invalid date-time: " + tok)
+}
@@ -0,0 +1,294 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package tomlom
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sttk/orderedmap"
+)
+
+var reBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Marshal is a function which serializes an ordered map into a TOML string.
+// The tables and keys are written in the order of key insertions.
+func Marshal(om *orderedmap.Map[string, any]) ([]byte, error) {
+	var buf bytes.Buffer
+	err := writeTable(&buf, om, nil, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// isSection checks whether a value is written as a table section: [a] or
+// [[a]], instead of a key/value pair.
+func isSection(v any) bool {
+	switch x := v.(type) {
+	case *table:
+		return x != nil
+	case []*table:
+		return len(x) > 0
+	}
+	return false
+}
+
+func writeTable(buf *bytes.Buffer, t *table, path []string, open, close string) error {
+	entries := make([]*orderedmap.Entry[string, any], 0, t.Len())
+	for ent := t.Front(); ent != nil; ent = ent.Next() {
+		entries = append(entries, ent)
+	}
+
+	split := len(entries)
+	for split > 0 && isSection(entries[split-1].Value()) {
+		split--
+	}
+
+	// The header of a table which has only sub-tables is omitted, except for
+	// an element of an array of tables.
+	if len(open) > 0 && (split > 0 || len(entries) == 0 || open == "[[") {
+		writeHeader(buf, open, path, close)
+	}
+
+	for _, ent := range entries[:split] {
+		err := writeKeyValue(buf, []string{ent.Key()}, ent.Value())
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, ent := range entries[split:] {
+		childPath := append(path[:len(path):len(path)], ent.Key())
+
+		switch x := ent.Value().(type) {
+		case *table:
+			err := writeTable(buf, x, childPath, "[", "]")
+			if err != nil {
+				return err
+			}
+		case []*table:
+			for _, elem := range x {
+				err := writeTable(buf, elem, childPath, "[[", "]]")
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func writeHeader(buf *bytes.Buffer, open string, path []string, close string) {
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString(open)
+	writeKeys(buf, path)
+	buf.WriteString(close)
+	buf.WriteString("\n")
+}
+
+func writeKeys(buf *bytes.Buffer, keys []string) {
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteString(".")
+		}
+		if reBareKey.MatchString(k) {
+			buf.WriteString(k)
+		} else {
+			writeString(buf, k)
+		}
+	}
+}
+
+// writeKeyValue writes a key/value pair. If the value is a non-empty table,
+// its entries are written with dotted keys.
+func writeKeyValue(buf *bytes.Buffer, keys []string, v any) error {
+	if t, ok := v.(*table); ok && t != nil && t.Len() > 0 {
+		for ent := t.Front(); ent != nil; ent = ent.Next() {
+			err := writeKeyValue(buf, append(keys[:len(keys):len(keys)], ent.Key()), ent.Value())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writeKeys(buf, keys)
+	buf.WriteString(" = ")
+	err := writeValue(buf, v)
+	if err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+	return nil
+}
+
+func writeValue(buf *bytes.Buffer, v any) error {
+	switch x := v.(type) {
+	case string:
+		writeString(buf, x)
+	case bool:
+		buf.WriteString(strconv.FormatBool(x))
+	case int:
+		buf.WriteString(strconv.FormatInt(int64(x), 10))
+	case int8:
+		buf.WriteString(strconv.FormatInt(int64(x), 10))
+	case int16:
+		buf.WriteString(strconv.FormatInt(int64(x), 10))
+	case int32:
+		buf.WriteString(strconv.FormatInt(int64(x), 10))
+	case int64:
+		buf.WriteString(strconv.FormatInt(x, 10))
+	case uint:
+		return writeUint(buf, uint64(x), v)
+	case uint8:
+		return writeUint(buf, uint64(x), v)
+	case uint16:
+		return writeUint(buf, uint64(x), v)
+	case uint32:
+		return writeUint(buf, uint64(x), v)
+	case uint64:
+		return writeUint(buf, x, v)
+	case float32:
+		writeFloat(buf, float64(x), 32)
+	case float64:
+		writeFloat(buf, x, 64)
+	case time.Time:
+		buf.WriteString(x.Format(time.RFC3339Nano))
+	case LocalDateTime:
+		buf.WriteString(string(x))
+	case LocalDate:
+		buf.WriteString(string(x))
+	case LocalTime:
+		buf.WriteString(string(x))
+	case *table:
+		if x == nil {
+			return UnsupportedValueTypeError{Type: reflect.TypeOf(v)}
+		}
+		return writeInlineTable(buf, x)
+	case []*table:
+		buf.WriteString("[")
+		for i, elem := range x {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			err := writeValue(buf, elem)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	default:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return UnsupportedValueTypeError{Type: reflect.TypeOf(v)}
+		}
+		buf.WriteString("[")
+		for i := 0; i < rv.Len(); i++ {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			err := writeValue(buf, rv.Index(i).Interface())
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	}
+	return nil
+}
+
+func writeUint(buf *bytes.Buffer, n uint64, v any) error {
+	if n > math.MaxInt64 {
+		return UnsupportedValueTypeError{Type: reflect.TypeOf(v)}
+	}
+	buf.WriteString(strconv.FormatUint(n, 10))
+	return nil
+}
+
+func writeFloat(buf *bytes.Buffer, f float64, bitSize int) {
+	switch {
+	case math.IsNaN(f):
+		buf.WriteString("nan")
+		return
+	case math.IsInf(f, 1):
+		buf.WriteString("inf")
+		return
+	case math.IsInf(f, -1):
+		buf.WriteString("-inf")
+		return
+	}
+
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	s := strconv.FormatFloat(f, format, -1, bitSize)
+	buf.WriteString(s)
+	if !strings.ContainsAny(s, ".e") {
+		buf.WriteString(".0")
+	}
+}
+
+func writeInlineTable(buf *bytes.Buffer, t *table) error {
+	if t.Len() == 0 {
+		buf.WriteString("{}")
+		return nil
+	}
+	buf.WriteString("{ ")
+	for ent := t.Front(); ent != nil; ent = ent.Next() {
+		if ent != t.Front() {
+			buf.WriteString(", ")
+		}
+		writeKeys(buf, []string{ent.Key()})
+		buf.WriteString(" = ")
+		err := writeValue(buf, ent.Value())
+		if err != nil {
+			return err
+		}
+	}
+	buf.WriteString(" }")
+	return nil
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteString(`"`)
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				buf.WriteString(`\u`)
+				s := strconv.FormatInt(int64(r), 16)
+				buf.WriteString(strings.Repeat("0", 4-len(s)))
+				buf.WriteString(s)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteString(`"`)
+}
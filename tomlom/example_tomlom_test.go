@@ -0,0 +1,41 @@
+package tomlom_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap"
+	"github.com/sttk/orderedmap/tomlom"
+)
+
+func ExampleUnmarshal() {
+	om := orderedmap.New[string, any]()
+	b := []byte("foo = \"bar\"\n\n[baz]\nqux = 1\nquux = 2\n")
+
+	e := tomlom.Unmarshal(b, &om)
+	fmt.Printf("om = %v\n", om)
+	fmt.Printf("e = %v\n", e)
+	// Output:
+	// om = Map[foo:bar baz:Map[qux:1 quux:2]]
+	// e = <nil>
+}
+
+func ExampleMarshal() {
+	baz := orderedmap.New[string, any]()
+	baz.Store("qux", 1)
+	baz.Store("quux", 2)
+
+	om := orderedmap.New[string, any]()
+	om.Store("foo", "bar")
+	om.Store("baz", &baz)
+
+	b, e := tomlom.Marshal(&om)
+	fmt.Printf("%s", b)
+	fmt.Printf("e = %v\n", e)
+	// Output:
+	// foo = "bar"
+	//
+	// [baz]
+	// qux = 1
+	// quux = 2
+	// e = <nil>
+}
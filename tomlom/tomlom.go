@@ -0,0 +1,78 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+// Package tomlom provides TOML serialization and deserialization of ordered
+// maps which preserve the order of tables and keys.
+//
+// This package has a self-contained TOML v1.0.0 parser, so it does not depend
+// on any TOML library.
+//
+// # Usage
+//
+// To deserialize a TOML string into an ordered map is as follows:
+//
+//	om := orderedmap.New[string, any]()
+//	e := tomlom.Unmarshal(byteSeq, &om)
+//
+// Tables, including inline tables and tables defined by dotted keys, are
+// deserialized into *orderedmap.Map[string, any], and arrays of tables are
+// deserialized into []*orderedmap.Map[string, any].
+// Other arrays are deserialized into []any.
+// Integers, floats, booleans and strings are deserialized into int64,
+// float64, bool and string.
+// Offset date-times are deserialized into time.Time, and local date-times,
+// local dates and local times are deserialized into LocalDateTime, LocalDate
+// and LocalTime.
+//
+// To serialize an ordered map into a TOML string is as follows:
+//
+//	byteSeq, e := tomlom.Marshal(&om)
+//
+// The tables and keys are written in the order of key insertions.
+// A table which is followed by non-table values in its parent table is
+// written with dotted keys, because TOML requires that key/value pairs of a
+// table are placed before its sub-tables.
+package tomlom
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// LocalDateTime is a string type which represents a TOML local date-time,
+// for example 1979-05-27T07:32:00.
+type LocalDateTime string
+
+// LocalDate is a string type which represents a TOML local date, for example
+// 1979-05-27.
+type LocalDate string
+
+// LocalTime is a string type which represents a TOML local time, for example
+// 07:32:00.
+type LocalTime string
+
+// SyntaxError is an error type which is returned by Unmarshal when an input
+// TOML string is invalid.
+type SyntaxError struct {
+	Line int
+	msg  string
+}
+
+func (err SyntaxError) Error() string {
+	return err.msg + " (line:" + strconv.Itoa(err.Line) + ")"
+}
+
+// UnsupportedValueTypeError is an error type which is returned by Marshal
+// when attempting to encode a value of which type cannot be expressed in TOML.
+type UnsupportedValueTypeError struct {
+	Type reflect.Type
+}
+
+func (err UnsupportedValueTypeError) Error() string {
+	if err.Type == nil {
+		return "toml: unsupported value type: nil"
+	} else {
+		return "toml: unsupported value type: " + err.Type.String()
+	}
+}
@@ -0,0 +1,257 @@
+package tomlom_test
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+	"github.com/sttk/orderedmap/tomlom"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	bs, err := os.ReadFile("testdata/" + name)
+	assert.Nil(t, err)
+	return bs
+}
+
+func TestRoundTrip_canonical(t *testing.T) {
+	src := readFixture(t, "canonical.toml")
+
+	om := orderedmap.New[string, any]()
+	err := tomlom.Unmarshal(src, &om)
+	assert.Nil(t, err)
+
+	bs, err := tomlom.Marshal(&om)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), string(src))
+}
+
+func TestRoundTrip_input(t *testing.T) {
+	src := readFixture(t, "input.toml")
+	golden := readFixture(t, "input.golden.toml")
+
+	om := orderedmap.New[string, any]()
+	err := tomlom.Unmarshal(src, &om)
+	assert.Nil(t, err)
+
+	bs, err := tomlom.Marshal(&om)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), string(golden))
+
+	om2 := orderedmap.New[string, any]()
+	err = tomlom.Unmarshal(bs, &om2)
+	assert.Nil(t, err)
+	assert.Equal(t, om2.String(), om.String())
+}
+
+func TestUnmarshal_order(t *testing.T) {
+	src := `
+z = 1
+a = 2
+
+[second]
+y = "x"
+b = "c"
+
+[first]
+k = true
+`
+	om := orderedmap.New[string, any]()
+	err := tomlom.Unmarshal([]byte(src), &om)
+	assert.Nil(t, err)
+	assert.Equal(t, om.String(), "Map[z:1 a:2 second:Map[y:x b:c] first:Map[k:true]]")
+}
+
+func TestUnmarshal_valueTypes(t *testing.T) {
+	src := `
+s = "a\u00e9\U0001F600"
+i = -0x1
+`
+	om := orderedmap.New[string, any]()
+	err := tomlom.Unmarshal([]byte(src), &om)
+	assert.Equal(t, err.Error(), "Invalid value: -0x1 (line:3)")
+
+	src = `
+s = "a\u00e9\U0001F600"
+i = 42
+f = 3.14
+b = false
+odt = 1979-05-27T00:32:00.999999-07:00
+ldt = 1979-05-27T07:32:00
+ld = 1979-05-27
+lt = 00:32:00.999999
+arr = [1, "a"]
+tbl = { a = 1 }
+nan = nan
+`
+	om = orderedmap.New[string, any]()
+	err = tomlom.Unmarshal([]byte(src), &om)
+	assert.Nil(t, err)
+
+	v, _ := om.Load("s")
+	assert.Equal(t, v, "aé😀")
+	v, _ = om.Load("i")
+	assert.Equal(t, v, int64(42))
+	v, _ = om.Load("f")
+	assert.Equal(t, v, 3.14)
+	v, _ = om.Load("b")
+	assert.Equal(t, v, false)
+	v, _ = om.Load("odt")
+	assert.Equal(t, v.(time.Time).UTC(), time.Date(1979, 5, 27, 7, 32, 0, 999999000, time.UTC))
+	v, _ = om.Load("ldt")
+	assert.Equal(t, v, tomlom.LocalDateTime("1979-05-27T07:32:00"))
+	v, _ = om.Load("ld")
+	assert.Equal(t, v, tomlom.LocalDate("1979-05-27"))
+	v, _ = om.Load("lt")
+	assert.Equal(t, v, tomlom.LocalTime("00:32:00.999999"))
+	v, _ = om.Load("arr")
+	assert.Equal(t, v, []any{int64(1), "a"})
+	v, _ = om.Load("tbl")
+	assert.Equal(t, v.(*orderedmap.Map[string, any]).String(), "Map[a:1]")
+	v, _ = om.Load("nan")
+	assert.True(t, math.IsNaN(v.(float64)))
+}
+
+func TestUnmarshal_arrayOfTables(t *testing.T) {
+	src := `
+[[p]]
+n = 1
+[[p.q]]
+m = 1
+[[p]]
+n = 2
+`
+	om := orderedmap.New[string, any]()
+	err := tomlom.Unmarshal([]byte(src), &om)
+	assert.Nil(t, err)
+
+	v, _ := om.Load("p")
+	arr := v.([]*orderedmap.Map[string, any])
+	assert.Equal(t, len(arr), 2)
+	assert.Equal(t, arr[0].String(), "Map[n:1 q:[Map[m:1]]]")
+	assert.Equal(t, arr[1].String(), "Map[n:2]")
+}
+
+func TestUnmarshal_multiLineStrings(t *testing.T) {
+	src := `
+a = """
+one ""two"" three"""""
+b = '''''quoted'''''
+c = """\
+  trimmed \
+  line"""
+`
+	om := orderedmap.New[string, any]()
+	err := tomlom.Unmarshal([]byte(src), &om)
+	assert.Nil(t, err)
+
+	v, _ := om.Load("a")
+	assert.Equal(t, v, `one ""two"" three""`)
+	v, _ = om.Load("b")
+	assert.Equal(t, v, `''quoted''`)
+	v, _ = om.Load("c")
+	assert.Equal(t, v, "trimmed line")
+}
+
+func TestUnmarshal_errors(t *testing.T) {
+	cases := []struct {
+		src string
+		msg string
+	}{
+		{"a = 1\na = 2", "Key is already defined: a (line:2)"},
+		{"[a]\n[a]", "Table is already defined: a (line:2)"},
+		{"a = 1\n[a]", "Table is already defined: a (line:2)"},
+		{"a = 1\n[a.b]", "Key is already defined as a non-table value: a (line:2)"},
+		{"a = {}\n[a.b]", "Cannot extend an inline table: a (line:2)"},
+		{"a = { b = 1 }\na.c = 2", "Cannot add keys to an already defined value: a (line:2)"},
+		{"[a.b]\n[a]\nb.c = 1", "Cannot add keys to an already defined value: b (line:3)"},
+		{"[x]\na.b = 1\n[x.a]", "Table is already defined: x.a (line:3)"},
+		{"a = []\n[[a]]", "Key is already defined as a non-array of tables: a (line:2)"},
+		{"[[a]]\n[a]", "Table is already defined: a (line:2)"},
+		{"a = 1 b = 2", "Expected a newline, but found 'b' (line:1)"},
+		{"a 1", "Expected '=' after a key (line:1)"},
+		{"= 1", "Expected a key (line:1)"},
+		{"a = ", "Expected a value (line:1)"},
+		{"a = 01", "Invalid value: 01 (line:1)"},
+		{"a = 1__0", "Invalid value: 1__0 (line:1)"},
+		{"a = 0xFFFFFFFFFFFFFFFFF", "Invalid integer: FFFFFFFFFFFFFFFFF (line:1)"},
+		{"a = 1979-13-27", "Invalid date-time: 1979-13-27 (line:1)"},
+		{"a = \"abc", "Unterminated string (line:1)"},
+		{"a = \"abc\nd\"", "Unterminated string (line:1)"},
+		{"a = 'abc", "Unterminated string (line:1)"},
+		{"a = \"\\q\"", "Invalid escape sequence: \\q (line:1)"},
+		{"a = \"\\uD800\"", "Invalid unicode escape sequence (line:1)"},
+		{"a = \"\"\"abc", "Unterminated multi-line string (line:1)"},
+		{"a = '''abc", "Unterminated multi-line string (line:1)"},
+		{"a = [1 2]", "Expected ',' or ']' in an array (line:1)"},
+		{"a = { b = 1 c = 2 }", "Expected ',' or '}' in an inline table (line:1)"},
+		{"[a", "Expected ']' of a table header (line:1)"},
+		{"[[a]", "Expected ']]' of a table header (line:1)"},
+		{"\"\"\"a\"\"\" = 1", "Multi-line strings are not allowed as keys (line:1)"},
+	}
+	for _, c := range cases {
+		om := orderedmap.New[string, any]()
+		err := tomlom.Unmarshal([]byte(c.src), &om)
+		if assert.NotNil(t, err, c.src) {
+			assert.Equal(t, err.Error(), c.msg, c.src)
+			assert.IsType(t, tomlom.SyntaxError{}, err)
+		}
+	}
+}
+
+func TestMarshal_empty(t *testing.T) {
+	om := orderedmap.New[string, any]()
+	bs, err := tomlom.Marshal(&om)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), "")
+}
+
+func TestMarshal_goValues(t *testing.T) {
+	inner := orderedmap.New[string, any]()
+	inner.Store("x", uint8(1))
+
+	om := orderedmap.New[string, any]()
+	om.Store("ints", []int{1, -2})
+	om.Store("floats", []float32{1, 0.25})
+	om.Store("big", 1e21)
+	om.Store("key.with.dots", "s\x01")
+	om.Store("tables", []*orderedmap.Map[string, any]{&inner})
+	om.Store("after", int16(3))
+
+	bs, err := tomlom.Marshal(&om)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), `ints = [1, -2]
+floats = [1.0, 0.25]
+big = 1e+21
+"key.with.dots" = "s\u0001"
+tables = [{ x = 1 }]
+after = 3
+`)
+}
+
+func TestMarshal_unsupportedValueTypeError(t *testing.T) {
+	om := orderedmap.New[string, any]()
+	om.Store("a", nil)
+	_, err := tomlom.Marshal(&om)
+	assert.Equal(t, err.Error(), "toml: unsupported value type: nil")
+
+	om = orderedmap.New[string, any]()
+	om.Store("a", []any{uint64(math.MaxUint64)})
+	_, err = tomlom.Marshal(&om)
+	assert.Equal(t, err.Error(), "toml: unsupported value type: uint64")
+
+	om = orderedmap.New[string, any]()
+	om.Store("a", map[string]int{})
+	_, err = tomlom.Marshal(&om)
+	assert.Equal(t, err.Error(), "toml: unsupported value type: map[string]int")
+
+	inner := orderedmap.New[string, any]()
+	inner.Store("c", complex(1, 2))
+	om = orderedmap.New[string, any]()
+	om.Store("t", &inner)
+	_, err = tomlom.Marshal(&om)
+	assert.Equal(t, err.Error(), "toml: unsupported value type: complex128")
+}
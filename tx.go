@@ -0,0 +1,379 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+// TxDoneError is an error type which is returned by Tx when it has already
+// been committed or rolled back.
+type TxDoneError struct{}
+
+func (err TxDoneError) Error() string {
+	return "transaction has already been committed or rolled back"
+}
+
+// TxConflictError is an error type which is returned by Tx.Commit when the map
+// was modified outside of the transaction after it began.
+type TxConflictError struct{}
+
+func (err TxConflictError) Error() string {
+	return "map was modified outside of the transaction"
+}
+
+// Tx is a struct which represents a transaction on a Map, and is created by
+// Map.Begin.
+// Writes through a transaction are not visible to the map until Commit is
+// called, and are discarded by Rollback. Reads through a transaction see its
+// own writes.
+//
+// A transaction does not copy the entries of the map when it begins. The
+// entries are copied once at the first write through the transaction, so the
+// map including the positions of deleted entries is left unchanged until
+// Commit. After committing writes, the entries which were got from the map by
+// Front, Back, Prev or Next before Commit are detached from the map, like
+// after a write following Map.Snapshot.
+type Tx[K comparable, V any] struct {
+	om    *Map[K, V]
+	work  Map[K, V]
+	token *cowToken
+	prev  *cowToken
+	base  uint64
+	done  bool
+}
+
+// Begin is a method which starts a transaction on this map.
+// This map must not be modified outside of the transaction until it is
+// committed or rolled back, otherwise Commit returns a TxConflictError.
+func (om *Map[K, V]) Begin() *Tx[K, V] {
+	prev := om.cow
+	om.cow = &cowToken{}
+	tx := &Tx[K, V]{
+		om: om,
+		work: Map[K, V]{
+			m: om.m, head: om.head, last: om.last, len: om.len, cow: om.cow,
+		},
+		token: om.cow,
+		prev:  prev,
+		base:  om.mod,
+	}
+	if om.observed() {
+		tx.work.obs = &observers[K, V]{capture: true}
+	}
+	return tx
+}
+
+// Commit is a method which applies all writes through this transaction to
+// the map at once.
+// The events of the writes are notified to the functions registered by
+// Map.Observe at this time, as a batch.
+// If this transaction has already finished, this method returns a
+// TxDoneError. If the map was modified outside of this transaction, this
+// method discards the writes and returns a TxConflictError.
+func (tx *Tx[K, V]) Commit() error {
+	if tx.done {
+		return TxDoneError{}
+	}
+	tx.done = true
+
+	if tx.work.cow != nil {
+		tx.release()
+		return nil
+	}
+
+	om := tx.om
+	if om.mod != tx.base {
+		return TxConflictError{}
+	}
+
+	om.m = tx.work.m
+	om.head = tx.work.head
+	om.last = tx.work.last
+	om.len = tx.work.len
+	om.mod++
+	om.cow = nil
+
+	if tx.work.obs != nil {
+		events := tx.work.obs.pending
+		om.Batch(func() {
+			for _, ev := range events {
+				om.emit(ev)
+			}
+		})
+	}
+	tx.work = Map[K, V]{}
+	return nil
+}
+
+// Rollback is a method which discards all writes through this transaction.
+// If this transaction has already finished, this method returns a
+// TxDoneError.
+func (tx *Tx[K, V]) Rollback() error {
+	if tx.done {
+		return TxDoneError{}
+	}
+	tx.done = true
+	tx.release()
+	tx.work = Map[K, V]{}
+	return nil
+}
+
+// release is a method which stops sharing the entries of the map with this
+// transaction if no other snapshots were taken during it.
+func (tx *Tx[K, V]) release() {
+	if tx.om.cow == tx.token {
+		tx.om.cow = tx.prev
+	}
+}
+
+// Len is a method which returns the number of entries in this transaction.
+func (tx *Tx[K, V]) Len() int {
+	return tx.work.Len()
+}
+
+// Load is a method which returns a value stored in this transaction for a
+// key.
+// If no value was found for a key, the ok result is false.
+func (tx *Tx[K, V]) Load(key K) (value V, ok bool) {
+	return tx.work.Load(key)
+}
+
+// Store is a method which sets a value for a key in this transaction.
+func (tx *Tx[K, V]) Store(key K, value V) {
+	tx.work.Store(key, value)
+}
+
+// Swap is a method which sets a value for a key in this transaction, and
+// returns the previous value if any.
+func (tx *Tx[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	return tx.work.Swap(key, value)
+}
+
+// LoadOrStore is a method which returns the value for a key if present in
+// this transaction, otherwise stores and returns the specified value.
+func (tx *Tx[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	return tx.work.LoadOrStore(key, value)
+}
+
+// Delete is a method which deletes a value for a key in this transaction.
+func (tx *Tx[K, V]) Delete(key K) {
+	tx.work.Delete(key)
+}
+
+// Ldelete is a method which logically deletes a value for a key in this
+// transaction.
+func (tx *Tx[K, V]) Ldelete(key K) {
+	tx.work.Ldelete(key)
+}
+
+// LoadAndDelete is a method which deletes a value for a key in this
+// transaction, and returns the previous value if any.
+func (tx *Tx[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	return tx.work.LoadAndDelete(key)
+}
+
+// LoadAndLdelete is a method which logically deletes a value for a key in
+// this transaction, and returns the previous value if any.
+func (tx *Tx[K, V]) LoadAndLdelete(key K) (value V, loaded bool) {
+	return tx.work.LoadAndLdelete(key)
+}
+
+// MoveToFront is a method which moves an entry for a key to the head in this
+// transaction, like Map.MoveToFront.
+func (tx *Tx[K, V]) MoveToFront(key K) bool {
+	return tx.work.MoveToFront(key)
+}
+
+// MoveToBack is a method which moves an entry for a key to the end in this
+// transaction, like Map.MoveToBack.
+func (tx *Tx[K, V]) MoveToBack(key K) bool {
+	return tx.work.MoveToBack(key)
+}
+
+// MoveBefore is a method which moves an entry for a key to the position just
+// before an entry for a mark key in this transaction, like Map.MoveBefore.
+func (tx *Tx[K, V]) MoveBefore(key, mark K) bool {
+	return tx.work.MoveBefore(key, mark)
+}
+
+// MoveAfter is a method which moves an entry for a key to the position just
+// after an entry for a mark key in this transaction, like Map.MoveAfter.
+func (tx *Tx[K, V]) MoveAfter(key, mark K) bool {
+	return tx.work.MoveAfter(key, mark)
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in this transaction in the order of key insertions.
+// If fn returns false, this method stops the iteration.
+func (tx *Tx[K, V]) Range(fn func(key K, value V) bool) {
+	tx.work.Range(fn)
+}
+
+// Front is a method which returns the head entry in this transaction.
+func (tx *Tx[K, V]) Front() *Entry[K, V] {
+	return tx.work.Front()
+}
+
+// Back is a method which returns the last entry in this transaction.
+func (tx *Tx[K, V]) Back() *Entry[K, V] {
+	return tx.work.Back()
+}
+
+// HashTx is a struct which represents a transaction on a HashMap, and is
+// created by HashMap.Begin. It works in the same way as Tx.
+type HashTx[K any, V any] struct {
+	tx   *Tx[*hashKey[K], V]
+	hm   *HashMap[K, V]
+	keys *hashKeys[K]
+}
+
+// Begin is a method which starts a transaction on this map.
+// This map must not be modified outside of the transaction until it is
+// committed or rolled back, otherwise Commit returns a TxConflictError.
+func (hm *HashMap[K, V]) Begin() *HashTx[K, V] {
+	hm.keys.shared = true
+	return &HashTx[K, V]{tx: hm.om.Begin(), hm: hm, keys: hm.keys}
+}
+
+// Commit is a method which applies all writes through this transaction to
+// the map at once, like Tx.Commit.
+func (tx *HashTx[K, V]) Commit() error {
+	err := tx.tx.Commit()
+	if err != nil {
+		return err
+	}
+	tx.hm.keys = tx.keys
+	return nil
+}
+
+// Rollback is a method which discards all writes through this transaction.
+// If this transaction has already finished, this method returns a
+// TxDoneError.
+func (tx *HashTx[K, V]) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+func (tx *HashTx[K, V]) intern(key K) *hashKey[K] {
+	box, keys := tx.keys.intern(key)
+	tx.keys = keys
+	return box
+}
+
+func (tx *HashTx[K, V]) release(box *hashKey[K]) {
+	if _, exists := tx.tx.work.m[box]; !exists {
+		tx.keys = tx.keys.remove(box)
+	}
+}
+
+// Len is a method which returns the number of entries in this transaction.
+func (tx *HashTx[K, V]) Len() int {
+	return tx.tx.Len()
+}
+
+// Load is a method which returns a value stored in this transaction for a
+// key.
+// If no value was found for a key, the ok result is false.
+func (tx *HashTx[K, V]) Load(key K) (value V, ok bool) {
+	box := tx.keys.lookup(key)
+	if box == nil {
+		return
+	}
+	return tx.tx.Load(box)
+}
+
+// Store is a method which sets a value for a key in this transaction.
+func (tx *HashTx[K, V]) Store(key K, value V) {
+	tx.tx.Store(tx.intern(key), value)
+}
+
+// Swap is a method which sets a value for a key in this transaction, and
+// returns the previous value if any.
+func (tx *HashTx[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	return tx.tx.Swap(tx.intern(key), value)
+}
+
+// LoadOrStore is a method which returns the value for a key if present in
+// this transaction, otherwise stores and returns the specified value.
+func (tx *HashTx[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	return tx.tx.LoadOrStore(tx.intern(key), value)
+}
+
+// Delete is a method which deletes a value for a key in this transaction.
+func (tx *HashTx[K, V]) Delete(key K) {
+	tx.LoadAndDelete(key)
+}
+
+// Ldelete is a method which logically deletes a value for a key in this
+// transaction.
+func (tx *HashTx[K, V]) Ldelete(key K) {
+	tx.LoadAndLdelete(key)
+}
+
+// LoadAndDelete is a method which deletes a value for a key in this
+// transaction, and returns the previous value if any.
+func (tx *HashTx[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	box := tx.keys.lookup(key)
+	if box == nil {
+		return
+	}
+	value, loaded = tx.tx.LoadAndDelete(box)
+	tx.release(box)
+	return
+}
+
+// LoadAndLdelete is a method which logically deletes a value for a key in
+// this transaction, and returns the previous value if any.
+func (tx *HashTx[K, V]) LoadAndLdelete(key K) (value V, loaded bool) {
+	box := tx.keys.lookup(key)
+	if box == nil {
+		return
+	}
+	return tx.tx.LoadAndLdelete(box)
+}
+
+// MoveToFront is a method which moves an entry for a key to the head in this
+// transaction, like HashMap.MoveToFront.
+func (tx *HashTx[K, V]) MoveToFront(key K) bool {
+	box := tx.keys.lookup(key)
+	return box != nil && tx.tx.MoveToFront(box)
+}
+
+// MoveToBack is a method which moves an entry for a key to the end in this
+// transaction, like HashMap.MoveToBack.
+func (tx *HashTx[K, V]) MoveToBack(key K) bool {
+	box := tx.keys.lookup(key)
+	return box != nil && tx.tx.MoveToBack(box)
+}
+
+// MoveBefore is a method which moves an entry for a key to the position just
+// before an entry for a mark key in this transaction, like
+// HashMap.MoveBefore.
+func (tx *HashTx[K, V]) MoveBefore(key, mark K) bool {
+	box, markBox := tx.keys.lookup(key), tx.keys.lookup(mark)
+	return box != nil && markBox != nil && tx.tx.MoveBefore(box, markBox)
+}
+
+// MoveAfter is a method which moves an entry for a key to the position just
+// after an entry for a mark key in this transaction, like HashMap.MoveAfter.
+func (tx *HashTx[K, V]) MoveAfter(key, mark K) bool {
+	box, markBox := tx.keys.lookup(key), tx.keys.lookup(mark)
+	return box != nil && markBox != nil && tx.tx.MoveAfter(box, markBox)
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in this transaction in the order of key insertions.
+// If fn returns false, this method stops the iteration.
+func (tx *HashTx[K, V]) Range(fn func(key K, value V) bool) {
+	tx.tx.Range(func(box *hashKey[K], value V) bool {
+		return fn(box.key, value)
+	})
+}
+
+// Front is a method which returns the head entry in this transaction.
+func (tx *HashTx[K, V]) Front() *HashEntry[K, V] {
+	return (*HashEntry[K, V])(tx.tx.Front())
+}
+
+// Back is a method which returns the last entry in this transaction.
+func (tx *HashTx[K, V]) Back() *HashEntry[K, V] {
+	return (*HashEntry[K, V])(tx.tx.Back())
+}
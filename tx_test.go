@@ -0,0 +1,203 @@
+package orderedmap_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func TestMap_Begin_commit(t *testing.T) {
+	om := newABC()
+
+	tx := om.Begin()
+	tx.Store("d", 4)
+	tx.Store("a", 10)
+	tx.Delete("b")
+	tx.MoveToFront("c")
+
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:3]")
+	assert.Equal(t, tx.Len(), 3)
+	v, ok := tx.Load("a")
+	assert.True(t, ok)
+	assert.Equal(t, v, 10)
+	_, ok = tx.Load("b")
+	assert.False(t, ok)
+	assert.Equal(t, tx.Front().Key(), "c")
+	assert.Equal(t, tx.Back().Key(), "d")
+
+	var keys []string
+	tx.Range(func(k string, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	assert.Equal(t, keys, []string{"c", "a", "d"})
+
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, om.String(), "Map[c:3 a:10 d:4]")
+	assert.Equal(t, om.Len(), 3)
+
+	om.Store("e", 5)
+	assert.Equal(t, om.String(), "Map[c:3 a:10 d:4 e:5]")
+}
+
+func TestMap_Begin_rollback(t *testing.T) {
+	om := newABC()
+	om.Store("d", 4)
+	om.Ldelete("d")
+
+	tx := om.Begin()
+	tx.Delete("a")
+	tx.Ldelete("b")
+	v, loaded := tx.LoadAndDelete("c")
+	assert.True(t, loaded)
+	assert.Equal(t, v, 3)
+	tx.Store("d", 40)
+	tx.Store("e", 5)
+	assert.Equal(t, tx.Len(), 2)
+
+	assert.Nil(t, tx.Rollback())
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:3]")
+	assert.Equal(t, om.Len(), 3)
+	_, ok := om.Load("d")
+	assert.False(t, ok)
+
+	var keys []string
+	for ent := om.Back(); ent != nil; ent = ent.Prev() {
+		keys = append(keys, ent.Key())
+	}
+	assert.Equal(t, keys, []string{"c", "b", "a"})
+
+	om.Store("d", 4)
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:3 d:4]")
+}
+
+func TestMap_Begin_finished(t *testing.T) {
+	om := newABC()
+
+	tx := om.Begin()
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, tx.Commit(), orderedmap.TxDoneError{})
+	assert.Equal(t, tx.Rollback(), orderedmap.TxDoneError{})
+
+	tx = om.Begin()
+	assert.Nil(t, tx.Rollback())
+	assert.Equal(t, tx.Commit(), orderedmap.TxDoneError{})
+	assert.Equal(t, tx.Rollback().Error(),
+		"transaction has already been committed or rolled back")
+}
+
+func TestMap_Begin_conflict(t *testing.T) {
+	om := newABC()
+
+	tx := om.Begin()
+	tx.Store("d", 4)
+	om.Store("e", 5)
+
+	v, ok := tx.Load("e")
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+
+	err := tx.Commit()
+	assert.Equal(t, err, orderedmap.TxConflictError{})
+	assert.Equal(t, err.Error(), "map was modified outside of the transaction")
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:3 e:5]")
+
+	tx = om.Begin()
+	s := om.Snapshot()
+	tx.Delete("a")
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, om.String(), "Map[b:2 c:3 e:5]")
+	assert.Equal(t, s.String(), "Snapshot[a:1 b:2 c:3 e:5]")
+}
+
+func TestMap_Begin_conflictAfterClear(t *testing.T) {
+	om := newABC()
+
+	tx := om.Begin()
+	tx.Store("d", 4)
+	om.Clear()
+	om.Store("a", 1)
+
+	err := tx.Commit()
+	assert.Equal(t, err, orderedmap.TxConflictError{})
+	assert.Equal(t, om.String(), "Map[a:1]")
+
+	tx1 := om.Begin()
+	tx2 := om.Begin()
+	tx1.Store("b", 2)
+	tx2.Store("c", 3)
+	assert.Nil(t, tx1.Commit())
+	assert.Equal(t, tx2.Commit(), orderedmap.TxConflictError{})
+	assert.Equal(t, om.String(), "Map[a:1 b:2]")
+}
+
+func TestMap_Begin_noOpWritesOutside(t *testing.T) {
+	om := newABC()
+
+	tx := om.Begin()
+	tx.Store("d", 4)
+	om.Delete("z")
+	om.LoadOrStore("a", 10)
+	om.Compute("z", func(v int, ok bool) (int, bool) { return 0, false })
+	om.MoveToFront("a")
+
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:3 d:4]")
+}
+
+func TestMap_Begin_readOnly(t *testing.T) {
+	om := newABC()
+	s := om.Snapshot()
+
+	tx := om.Begin()
+	v, ok := tx.Load("b")
+	assert.True(t, ok)
+	assert.Equal(t, v, 2)
+	om.Store("d", 4)
+	assert.Nil(t, tx.Commit())
+
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:3 d:4]")
+	assert.Equal(t, s.String(), "Snapshot[a:1 b:2 c:3]")
+}
+
+func TestMap_Begin_snapshotKept(t *testing.T) {
+	om := newABC()
+	s := om.Snapshot()
+
+	tx := om.Begin()
+	assert.Nil(t, tx.Rollback())
+	om.Store("a", 10)
+
+	assert.Equal(t, s.String(), "Snapshot[a:1 b:2 c:3]")
+	assert.Equal(t, om.String(), "Map[a:10 b:2 c:3]")
+}
+
+func TestMap_Begin_otherWrites(t *testing.T) {
+	om := newABC()
+
+	tx := om.Begin()
+	prev, loaded := tx.Swap("a", 10)
+	assert.True(t, loaded)
+	assert.Equal(t, prev, 1)
+	actual, loaded := tx.LoadOrStore("a", 100)
+	assert.True(t, loaded)
+	assert.Equal(t, actual, 10)
+	actual, loaded = tx.LoadOrStore("d", 4)
+	assert.False(t, loaded)
+	assert.Equal(t, actual, 4)
+	v, loaded := tx.LoadAndLdelete("b")
+	assert.True(t, loaded)
+	assert.Equal(t, v, 2)
+	assert.True(t, tx.MoveToBack("a"))
+	assert.True(t, tx.MoveBefore("d", "c"))
+	assert.True(t, tx.MoveAfter("c", "a"))
+	assert.False(t, tx.MoveAfter("b", "a"))
+
+	assert.Equal(t, om.String(), "Map[a:1 b:2 c:3]")
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, om.String(), "Map[d:4 a:10 c:3]")
+
+	om.Store("b", 20)
+	assert.Equal(t, om.String(), "Map[d:4 a:10 c:3 b:20]")
+}
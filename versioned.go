@@ -0,0 +1,231 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+package orderedmap
+
+import (
+	"strconv"
+)
+
+// VersionNotFoundError is an error type which is returned by VersionedMap when
+// a specified version does not exist or has already been discarded.
+type VersionNotFoundError struct {
+	Version uint64
+}
+
+func (err VersionNotFoundError) Error() string {
+	return "version is not found or already discarded: " +
+		strconv.FormatUint(err.Version, 10)
+}
+
+// VersionedMap is a struct which represents an ordered map of which each
+// mutation creates a new version, and of which past versions can be read with
+// their values and their order of entries.
+//
+// The versions share their internal structure like Frozen, so each mutation
+// costs O(log n) time and space. A version is discarded when it falls out of
+// the number of latest versions to be retained and no VersionSnapshot holds
+// it.
+type VersionedMap[K comparable, V any] struct {
+	versions map[uint64]Frozen[K, V]
+	holds    map[uint64]int
+	cur      uint64
+	retain   int
+}
+
+// NewVersionedMap is a function which creates a new VersionedMap, which is
+// empty and of which version is zero.
+// The retain is the number of the latest versions, including the current
+// version, which are kept even if no VersionSnapshot holds them. If retain is
+// less than one, only the current version and the versions held by
+// VersionSnapshots are kept.
+func NewVersionedMap[K comparable, V any](retain int) *VersionedMap[K, V] {
+	if retain < 1 {
+		retain = 1
+	}
+	return &VersionedMap[K, V]{
+		versions: map[uint64]Frozen[K, V]{0: {}},
+		holds:    make(map[uint64]int),
+		retain:   retain,
+	}
+}
+
+// Version is a method which returns the current version of this map.
+func (vm *VersionedMap[K, V]) Version() uint64 {
+	return vm.cur
+}
+
+// HasVersion is a method which reports whether a version can be read.
+func (vm *VersionedMap[K, V]) HasVersion(version uint64) bool {
+	_, exists := vm.versions[version]
+	return exists
+}
+
+func (vm *VersionedMap[K, V]) current() Frozen[K, V] {
+	return vm.versions[vm.cur]
+}
+
+// commit is a method which adds a new version and discards the version which
+// falls out of the retained versions.
+func (vm *VersionedMap[K, V]) commit(f Frozen[K, V]) uint64 {
+	vm.cur++
+	vm.versions[vm.cur] = f
+	if vm.cur >= uint64(vm.retain) {
+		vm.collect(vm.cur - uint64(vm.retain))
+	}
+	return vm.cur
+}
+
+// collect is a method which discards a version if it is older than the
+// retained versions and is not held.
+func (vm *VersionedMap[K, V]) collect(version uint64) {
+	if vm.holds[version] > 0 || version+uint64(vm.retain) > vm.cur {
+		return
+	}
+	delete(vm.versions, version)
+}
+
+// Len is a method which returns the number of entries in the current version.
+func (vm *VersionedMap[K, V]) Len() int {
+	return vm.current().Len()
+}
+
+// Load is a method which returns a value stored for a key in the current
+// version.
+// If no value was found for a key, the ok result is false.
+func (vm *VersionedMap[K, V]) Load(key K) (value V, ok bool) {
+	return vm.current().Load(key)
+}
+
+// Range is a method which calls the specified function: fn sequentially for
+// each key and value in the current version in the order of entries.
+// If fn returns false, this method stops the iteration.
+func (vm *VersionedMap[K, V]) Range(fn func(key K, value V) bool) {
+	vm.current().Range(fn)
+}
+
+// Store is a method which sets a value for a key, and returns the new
+// version.
+// If the key is present, its position is not changed.
+func (vm *VersionedMap[K, V]) Store(key K, value V) uint64 {
+	return vm.commit(vm.current().With(key, value))
+}
+
+// Delete is a method which deletes a value for a key, and returns the new
+// version.
+// If the key is not present, no version is created and this method returns
+// the current version.
+func (vm *VersionedMap[K, V]) Delete(key K) uint64 {
+	f := vm.current()
+	if !f.Has(key) {
+		return vm.cur
+	}
+	return vm.commit(f.Without(key))
+}
+
+// Clear is a method which deletes all entries, and returns the new version.
+// If this map is empty, no version is created and this method returns the
+// current version.
+func (vm *VersionedMap[K, V]) Clear() uint64 {
+	if vm.current().Len() == 0 {
+		return vm.cur
+	}
+	return vm.commit(Frozen[K, V]{})
+}
+
+// At is a method which returns the content of a version as a Frozen.
+// If the version does not exist or has already been discarded, this method
+// returns a VersionNotFoundError.
+func (vm *VersionedMap[K, V]) At(version uint64) (Frozen[K, V], error) {
+	f, exists := vm.versions[version]
+	if !exists {
+		return f, VersionNotFoundError{Version: version}
+	}
+	return f, nil
+}
+
+// LoadAt is a method which returns a value stored for a key in a version.
+// If no value was found for a key, the ok result is false.
+// If the version does not exist or has already been discarded, this method
+// returns a VersionNotFoundError.
+func (vm *VersionedMap[K, V]) LoadAt(key K, version uint64) (value V, ok bool, err error) {
+	f, err := vm.At(version)
+	if err != nil {
+		return
+	}
+	value, ok = f.Load(key)
+	return
+}
+
+// RangeAt is a method which calls the specified function: fn sequentially for
+// each key and value in a version in the order of entries in that version.
+// If fn returns false, this method stops the iteration.
+// If the version does not exist or has already been discarded, this method
+// returns a VersionNotFoundError.
+func (vm *VersionedMap[K, V]) RangeAt(version uint64, fn func(key K, value V) bool) error {
+	f, err := vm.At(version)
+	if err != nil {
+		return err
+	}
+	f.Range(fn)
+	return nil
+}
+
+// String is a method which returns a string of the content of the current
+// version.
+func (vm *VersionedMap[K, V]) String() string {
+	return "VersionedMap" + vm.current().String()[len("Frozen"):]
+}
+
+// VersionSnapshot is a struct which holds a version of a VersionedMap so that
+// the version is not discarded until Release is called.
+type VersionSnapshot[K comparable, V any] struct {
+	Frozen[K, V]
+	vm      *VersionedMap[K, V]
+	version uint64
+}
+
+// Snapshot is a method which returns a VersionSnapshot which holds the
+// current version.
+func (vm *VersionedMap[K, V]) Snapshot() *VersionSnapshot[K, V] {
+	s, _ := vm.SnapshotAt(vm.cur)
+	return s
+}
+
+// SnapshotAt is a method which returns a VersionSnapshot which holds a
+// version.
+// If the version does not exist or has already been discarded, this method
+// returns a VersionNotFoundError.
+func (vm *VersionedMap[K, V]) SnapshotAt(version uint64) (*VersionSnapshot[K, V], error) {
+	f, err := vm.At(version)
+	if err != nil {
+		return nil, err
+	}
+	vm.holds[version]++
+	return &VersionSnapshot[K, V]{Frozen: f, vm: vm, version: version}, nil
+}
+
+// Version is a method which returns the version held by this snapshot.
+func (s *VersionSnapshot[K, V]) Version() uint64 {
+	return s.version
+}
+
+// Release is a method which stops holding the version, so that the version
+// can be discarded. The content of this snapshot can still be read after
+// this method.
+// Calling this method more than once has no effect.
+func (s *VersionSnapshot[K, V]) Release() {
+	vm := s.vm
+	if vm == nil {
+		return
+	}
+	s.vm = nil
+
+	vm.holds[s.version]--
+	if vm.holds[s.version] > 0 {
+		return
+	}
+	delete(vm.holds, s.version)
+	vm.collect(s.version)
+}
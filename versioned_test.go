@@ -0,0 +1,178 @@
+package orderedmap_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+)
+
+func rangeAtKeys(t *testing.T, vm *orderedmap.VersionedMap[string, int], version uint64) []string {
+	keys := []string{}
+	err := vm.RangeAt(version, func(k string, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	assert.Nil(t, err)
+	return keys
+}
+
+func TestVersionedMap(t *testing.T) {
+	vm := orderedmap.NewVersionedMap[string, int](10)
+	assert.Equal(t, vm.Version(), uint64(0))
+	assert.Equal(t, vm.Len(), 0)
+	assert.Equal(t, vm.String(), "VersionedMap[]")
+
+	assert.Equal(t, vm.Store("a", 1), uint64(1))
+	assert.Equal(t, vm.Store("b", 2), uint64(2))
+	assert.Equal(t, vm.Store("a", 10), uint64(3))
+	assert.Equal(t, vm.Delete("a"), uint64(4))
+	assert.Equal(t, vm.Delete("x"), uint64(4))
+	assert.Equal(t, vm.Store("a", 100), uint64(5))
+	assert.Equal(t, vm.Clear(), uint64(6))
+	assert.Equal(t, vm.Clear(), uint64(6))
+	assert.Equal(t, vm.Store("c", 3), uint64(7))
+
+	assert.Equal(t, vm.Version(), uint64(7))
+	assert.Equal(t, vm.String(), "VersionedMap[c:3]")
+	assert.Equal(t, vm.Len(), 1)
+	v, ok := vm.Load("c")
+	assert.True(t, ok)
+	assert.Equal(t, v, 3)
+
+	expected := []struct {
+		keys []string
+		a    int
+		aOk  bool
+	}{
+		{[]string{}, 0, false},
+		{[]string{"a"}, 1, true},
+		{[]string{"a", "b"}, 1, true},
+		{[]string{"a", "b"}, 10, true},
+		{[]string{"b"}, 0, false},
+		{[]string{"b", "a"}, 100, true},
+		{[]string{}, 0, false},
+		{[]string{"c"}, 0, false},
+	}
+	for i, e := range expected {
+		version := uint64(i)
+		assert.True(t, vm.HasVersion(version))
+		assert.Equal(t, rangeAtKeys(t, vm, version), e.keys)
+		v, ok, err := vm.LoadAt("a", version)
+		assert.Nil(t, err)
+		assert.Equal(t, ok, e.aOk)
+		assert.Equal(t, v, e.a)
+	}
+
+	assert.False(t, vm.HasVersion(8))
+	_, _, err := vm.LoadAt("a", 8)
+	assert.Equal(t, err, orderedmap.VersionNotFoundError{Version: 8})
+	assert.Equal(t, err.Error(), "version is not found or already discarded: 8")
+	err = vm.RangeAt(8, func(k string, v int) bool { return true })
+	assert.Equal(t, err, orderedmap.VersionNotFoundError{Version: 8})
+
+	f, err := vm.At(3)
+	assert.Nil(t, err)
+	assert.Equal(t, f.String(), "Frozen[a:10 b:2]")
+
+	var keys []string
+	vm.Range(func(k string, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	assert.Equal(t, keys, []string{"c"})
+}
+
+func TestVersionedMap_retain(t *testing.T) {
+	vm := orderedmap.NewVersionedMap[string, int](2)
+	vm.Store("a", 1)
+	assert.True(t, vm.HasVersion(0))
+	vm.Store("b", 2)
+	vm.Store("c", 3)
+
+	assert.False(t, vm.HasVersion(0))
+	assert.False(t, vm.HasVersion(1))
+	assert.True(t, vm.HasVersion(2))
+	assert.True(t, vm.HasVersion(3))
+	_, err := vm.At(1)
+	assert.Equal(t, err, orderedmap.VersionNotFoundError{Version: 1})
+
+	one := orderedmap.NewVersionedMap[string, int](1)
+	one.Store("a", 1)
+	one.Store("a", 2)
+	assert.False(t, one.HasVersion(1))
+	assert.True(t, one.HasVersion(2))
+}
+
+func TestVersionedMap_snapshot(t *testing.T) {
+	vm := orderedmap.NewVersionedMap[string, int](1)
+	vm.Store("a", 1)
+	s1 := vm.Snapshot()
+	s1b, err := vm.SnapshotAt(1)
+	assert.Nil(t, err)
+	assert.Equal(t, s1.Version(), uint64(1))
+
+	vm.Store("b", 2)
+	s2 := vm.Snapshot()
+	vm.Store("c", 3)
+	vm.Store("d", 4)
+
+	assert.False(t, vm.HasVersion(0))
+	assert.True(t, vm.HasVersion(1))
+	assert.True(t, vm.HasVersion(2))
+	assert.False(t, vm.HasVersion(3))
+	assert.Equal(t, rangeAtKeys(t, vm, 2), []string{"a", "b"})
+	assert.Equal(t, s2.String(), "Frozen[a:1 b:2]")
+	v, ok := s1.Load("a")
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+
+	s1.Release()
+	s1.Release()
+	assert.True(t, vm.HasVersion(1))
+	s1b.Release()
+	assert.False(t, vm.HasVersion(1))
+	assert.Equal(t, s1.Len(), 1)
+
+	s2.Release()
+	assert.False(t, vm.HasVersion(2))
+
+	s4 := vm.Snapshot()
+	s4.Release()
+	assert.True(t, vm.HasVersion(4))
+
+	_, err = vm.SnapshotAt(3)
+	assert.Equal(t, err, orderedmap.VersionNotFoundError{Version: 3})
+}
+
+func TestVersionedMap_retainZero(t *testing.T) {
+	vm := orderedmap.NewVersionedMap[int, int](0)
+	vm.Store(0, 0)
+	s1 := vm.Snapshot()
+	for i := 1; i < 1000; i++ {
+		vm.Store(i%50, i)
+	}
+	s2 := vm.Snapshot()
+	vm.Store(1, 1)
+
+	assert.Equal(t, vm.Version(), uint64(1001))
+	assert.False(t, vm.HasVersion(0))
+	assert.True(t, vm.HasVersion(1))
+	for i := 2; i < 1000; i++ {
+		assert.False(t, vm.HasVersion(uint64(i)))
+	}
+	assert.True(t, vm.HasVersion(1000))
+	assert.True(t, vm.HasVersion(1001))
+
+	s1.Release()
+	assert.False(t, vm.HasVersion(1))
+	s2.Release()
+	assert.False(t, vm.HasVersion(1000))
+	assert.True(t, vm.HasVersion(1001))
+
+	neg := orderedmap.NewVersionedMap[int, int](-1)
+	neg.Store(1, 1)
+	neg.Store(2, 2)
+	assert.False(t, neg.HasVersion(1))
+	assert.True(t, neg.HasVersion(2))
+}
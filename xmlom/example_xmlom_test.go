@@ -0,0 +1,32 @@
+package xmlom_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap/xmlom"
+)
+
+func ExampleUnmarshal() {
+	m := xmlom.New()
+	b := []byte(`<foo b="1" a="2"><bar>x</bar><bar>y</bar><baz>z</baz><bar>w</bar></foo>`)
+
+	e := xmlom.Unmarshal(b, &m)
+	fmt.Printf("m = %v\n", m)
+	fmt.Printf("e = %v\n", e)
+	// Output:
+	// m = Map[foo:Map[@b:1 @a:2 bar:[x y] baz:z bar#2:w]]
+	// e = <nil>
+}
+
+func ExampleMarshal() {
+	m := xmlom.New()
+	b := []byte(`<foo b="1" a="2"><qux>x</qux><baz>y</baz></foo>`)
+	xmlom.Unmarshal(b, &m)
+
+	b, e := xmlom.Marshal(&m)
+	fmt.Printf("xml = %s\n", b)
+	fmt.Printf("e = %v\n", e)
+	// Output:
+	// xml = <foo b="1" a="2"><qux>x</qux><baz>y</baz></foo>
+	// e = <nil>
+}
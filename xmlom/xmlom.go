@@ -0,0 +1,403 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+// Package xmlom provides XML serialization and deserialization of ordered
+// maps which preserve the order of attributes and child elements.
+//
+// An XML element is converted into an ordered map as follows:
+//
+//   - An attribute is stored with a key which is its name prefixed with
+//     AttrPrefix ("@" by default).
+//   - A child element is stored with a key which is its name. Consecutive
+//     child elements with a same name are grouped into a []any.
+//   - A child element which has neither attributes nor child elements is
+//     stored as a string of its text, and other child elements are stored as
+//     *orderedmap.Map[string, any].
+//   - Text content is stored with the key TextKey ("#text" by default) at the
+//     position where it appears. Text which consists only of white spaces is
+//     ignored.
+//   - When a name appears again after other entries, or text appears again
+//     after a child element, the key is suffixed with "#" and the occurrence
+//     number, for example "item#2" and "#text#2". Since "#" cannot be used in
+//     XML names, such keys never collide with names in a document.
+//
+// This keeps every text and every child element in document order, so an
+// XML element is written back in the same order as it was read.
+//
+// Namespace prefixes are kept in keys, for example "soap:Envelope" and
+// "@xmlns:soap".
+//
+// # Usage
+//
+// To deserialize an XML document into an ordered map is as follows:
+//
+//	m := xmlom.New()
+//	e := xmlom.Unmarshal(byteSeq, &m)
+//
+// The map has one entry of which key is the name of the root element.
+//
+// To serialize an ordered map into an XML document is as follows:
+//
+//	byteSeq, e := xmlom.Marshal(&m)
+//
+// Map also implements xml.Marshaler and xml.Unmarshaler, so it can be used as
+// a field of a struct which is serialized with encoding/xml.
+package xmlom
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sttk/orderedmap"
+)
+
+const (
+	// DefaultAttrPrefix is the prefix of keys for attributes used when
+	// Map.AttrPrefix is empty.
+	DefaultAttrPrefix = "@"
+
+	// DefaultTextKey is the key for text content used when Map.TextKey is
+	// empty.
+	DefaultTextKey = "#text"
+
+	xmlURL = "http://www.w3.org/XML/1998/namespace"
+)
+
+// Map is a struct which embeds orderedmap.Map and implements xml.Marshaler
+// and xml.Unmarshaler interfaces.
+// The entries of this map are the attributes, child elements and text
+// content of an XML element.
+type Map struct {
+	orderedmap.Map[string, any]
+
+	// AttrPrefix is the prefix of keys for attributes.
+	AttrPrefix string
+
+	// TextKey is the key for text content.
+	TextKey string
+}
+
+// New is a function which creates a new Map, which is empty.
+func New() Map {
+	return Map{Map: orderedmap.New[string, any]()}
+}
+
+func (m *Map) attrPrefix() string {
+	if len(m.AttrPrefix) == 0 {
+		return DefaultAttrPrefix
+	}
+	return m.AttrPrefix
+}
+
+func (m *Map) textKey() string {
+	if len(m.TextKey) == 0 {
+		return DefaultTextKey
+	}
+	return m.TextKey
+}
+
+// Unmarshal is a function which deserializes an XML document into a Map.
+// The name of the root element and its content are stored as an entry of the
+// Map.
+func Unmarshal(data []byte, m *Map) error {
+	if m.Len() == 0 {
+		m.Map = orderedmap.New[string, any]()
+	}
+
+	roots := newNodes(&m.Map)
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			scope := newScope(nil, start.Attr)
+			om := orderedmap.New[string, any]()
+			err = m.decodeElement(d, start, &om, scope)
+			if err != nil {
+				return err
+			}
+			roots.storeChild(scope.name(start.Name), m.simplify(&om))
+		}
+	}
+}
+
+// Marshal is a function which serializes a Map into an XML document.
+// Each entry of the Map is written as a root element.
+func Marshal(m *Map) ([]byte, error) {
+	var buf bytes.Buffer
+	e := xml.NewEncoder(&buf)
+	for ent := m.Front(); ent != nil; ent = ent.Next() {
+		err := m.encodeChild(e, baseName(ent.Key()), ent.Value())
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := e.Flush()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalXML is a method which sets the attributes, child elements and text
+// content of an XML element to this map.
+func (m *Map) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if m.Len() == 0 {
+		m.Map = orderedmap.New[string, any]()
+	}
+	return m.decodeElement(d, start, &m.Map, newScope(nil, start.Attr))
+}
+
+// MarshalXML is a method which writes an XML element which has the
+// attributes, child elements and text content in this map.
+func (m Map) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return m.encodeElement(e, start, &m.Map)
+}
+
+// scope is a map from namespace URLs to their prefixes.
+type scope map[string]string
+
+func newScope(parent scope, attrs []xml.Attr) scope {
+	var s scope
+	for _, a := range attrs {
+		var prefix string
+		switch {
+		case a.Name.Space == "xmlns":
+			prefix = a.Name.Local
+		case a.Name.Space == "" && a.Name.Local == "xmlns":
+			prefix = ""
+		default:
+			continue
+		}
+		if s == nil {
+			s = make(scope, len(parent)+1)
+			for url, p := range parent {
+				s[url] = p
+			}
+		}
+		s[a.Value] = prefix
+	}
+	if s == nil {
+		return parent
+	}
+	return s
+}
+
+func (s scope) name(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	if n.Space == "xmlns" {
+		return "xmlns:" + n.Local
+	}
+	prefix, ok := s[n.Space]
+	if !ok {
+		if n.Space == xmlURL {
+			return "xml:" + n.Local
+		}
+		prefix = n.Space
+	}
+	if prefix == "" {
+		return n.Local
+	}
+	return prefix + ":" + n.Local
+}
+
+func (m *Map) decodeElement(
+	d *xml.Decoder,
+	start xml.StartElement,
+	om *orderedmap.Map[string, any],
+	s scope,
+) error {
+	for _, a := range start.Attr {
+		om.Store(m.attrPrefix()+s.name(a.Name), a.Value)
+	}
+
+	n := newNodes(om)
+	var text strings.Builder
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n.storeText(m.textKey(), text.String())
+			text.Reset()
+			cs := newScope(s, t.Attr)
+			child := orderedmap.New[string, any]()
+			err = m.decodeElement(d, t, &child, cs)
+			if err != nil {
+				return err
+			}
+			n.storeChild(cs.name(t.Name), m.simplify(&child))
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			n.storeText(m.textKey(), text.String())
+			return nil
+		}
+	}
+}
+
+// simplify converts an element which has only text content to a string.
+func (m *Map) simplify(om *orderedmap.Map[string, any]) any {
+	if om.Len() == 0 {
+		return ""
+	}
+	if om.Len() == 1 && om.Front().Key() == m.textKey() {
+		return om.Front().Value()
+	}
+	return om
+}
+
+// nodes stores the texts and child elements of an XML element into a map in
+// document order.
+type nodes struct {
+	om       *orderedmap.Map[string, any]
+	lastName string
+	lastKey  string
+	counts   map[string]int
+}
+
+func newNodes(om *orderedmap.Map[string, any]) nodes {
+	return nodes{om: om, counts: make(map[string]int)}
+}
+
+func (n *nodes) storeChild(name string, value any) {
+	if n.lastName == name {
+		existing, _ := n.om.Load(n.lastKey)
+		if arr, ok := existing.([]any); ok {
+			n.om.Store(n.lastKey, append(arr, value))
+		} else {
+			n.om.Store(n.lastKey, []any{existing, value})
+		}
+		return
+	}
+	n.store(name, value)
+}
+
+func (n *nodes) storeText(textKey, text string) {
+	if len(strings.TrimSpace(text)) == 0 {
+		return
+	}
+	n.store(textKey, text)
+	n.lastName = ""
+}
+
+func (n *nodes) store(name string, value any) {
+	key := name
+	for {
+		c := n.counts[name] + 1
+		n.counts[name] = c
+		if c > 1 {
+			key = name + "#" + strconv.Itoa(c)
+		}
+		if _, ok := n.om.Load(key); !ok {
+			break
+		}
+	}
+	n.om.Store(key, value)
+	n.lastName = name
+	n.lastKey = key
+}
+
+// baseName removes the occurrence number suffix from a key.
+func baseName(key string) string {
+	i := strings.LastIndexByte(key, '#')
+	if i <= 0 || i == len(key)-1 {
+		return key
+	}
+	for _, c := range key[i+1:] {
+		if c < '0' || c > '9' {
+			return key
+		}
+	}
+	return key[:i]
+}
+
+func (m *Map) encodeElement(
+	e *xml.Encoder,
+	start xml.StartElement,
+	om *orderedmap.Map[string, any],
+) error {
+	prefix := m.attrPrefix()
+	textKey := m.textKey()
+
+	for ent := om.Front(); ent != nil; ent = ent.Next() {
+		if baseName(ent.Key()) != textKey && strings.HasPrefix(ent.Key(), prefix) {
+			start.Attr = append(start.Attr, xml.Attr{
+				Name:  xml.Name{Local: ent.Key()[len(prefix):]},
+				Value: fmt.Sprint(ent.Value()),
+			})
+		}
+	}
+
+	err := e.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+
+	for ent := om.Front(); ent != nil; ent = ent.Next() {
+		name := baseName(ent.Key())
+		if name == textKey {
+			err = e.EncodeToken(xml.CharData(fmt.Sprint(ent.Value())))
+		} else if !strings.HasPrefix(ent.Key(), prefix) {
+			err = m.encodeChild(e, name, ent.Value())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+func (m *Map) encodeChild(e *xml.Encoder, name string, value any) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	switch x := value.(type) {
+	case *orderedmap.Map[string, any]:
+		if x != nil {
+			return m.encodeElement(e, start, x)
+		}
+	case orderedmap.Map[string, any]:
+		return m.encodeElement(e, start, &x)
+	case []any:
+		for _, elem := range x {
+			err := m.encodeChild(e, name, elem)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := e.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+	if value != nil && !isNilMap(value) {
+		err = e.EncodeToken(xml.CharData(fmt.Sprint(value)))
+		if err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func isNilMap(value any) bool {
+	x, ok := value.(*orderedmap.Map[string, any])
+	return ok && x == nil
+}
@@ -0,0 +1,198 @@
+package xmlom_test
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+	"github.com/sttk/orderedmap/xmlom"
+)
+
+func TestUnmarshal_simple(t *testing.T) {
+	src := `<?xml version="1.0"?>
+<config z="1" a="2">
+  <name>foo</name>
+  <item id="b">x</item>
+  <item id="a">y</item>
+  <empty/>
+  <nested><k>v</k></nested>
+</config>`
+
+	m := xmlom.New()
+	err := xmlom.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(), "Map[config:Map[@z:1 @a:2 name:foo "+
+		"item:[Map[@id:b #text:x] Map[@id:a #text:y]] empty: nested:Map[k:v]]]")
+
+	v, _ := m.Load("config")
+	config := v.(*orderedmap.Map[string, any])
+	v, _ = config.Load("item")
+	items := v.([]any)
+	assert.Equal(t, len(items), 2)
+	assert.Equal(t, items[0].(*orderedmap.Map[string, any]).Front().Key(), "@id")
+}
+
+func TestUnmarshal_textPosition(t *testing.T) {
+	src := `<p><b>x</b> Hello <i>y</i> world </p>`
+
+	m := xmlom.New()
+	err := xmlom.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(), "Map[p:Map[b:x #text: Hello  i:y #text#2: world ]]")
+
+	m = xmlom.New()
+	err = xmlom.Unmarshal([]byte(`<p a="1"> keep spaces </p>`), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(), "Map[p:Map[@a:1 #text: keep spaces ]]")
+}
+
+func TestUnmarshal_namespaces(t *testing.T) {
+	src := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xml:lang="en">` +
+		`<soap:Body><m:GetPrice xmlns:m="https://example.com/prices"><m:Item>Apples</m:Item></m:GetPrice>` +
+		`<Other xmlns="urn:default"><Child/></Other></soap:Body></soap:Envelope>`
+
+	m := xmlom.New()
+	err := xmlom.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(), "Map[soap:Envelope:Map["+
+		"@xmlns:soap:http://schemas.xmlsoap.org/soap/envelope/ @xml:lang:en "+
+		"soap:Body:Map[m:GetPrice:Map[@xmlns:m:https://example.com/prices m:Item:Apples] "+
+		"Other:Map[@xmlns:urn:default Child:]]]]")
+
+	bs, err := xmlom.Marshal(&m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xml:lang="en">`+
+		`<soap:Body><m:GetPrice xmlns:m="https://example.com/prices"><m:Item>Apples</m:Item></m:GetPrice>`+
+		`<Other xmlns="urn:default"><Child></Child></Other></soap:Body></soap:Envelope>`)
+}
+
+func TestUnmarshal_customKeys(t *testing.T) {
+	src := `<a x="1">text<b/></a>`
+
+	m := xmlom.New()
+	m.AttrPrefix = "-"
+	m.TextKey = "_"
+	err := xmlom.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(), "Map[a:Map[-x:1 _:text b:]]")
+
+	bs, err := xmlom.Marshal(&m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), `<a x="1">text<b></b></a>`)
+}
+
+func TestUnmarshal_error(t *testing.T) {
+	m := xmlom.New()
+	err := xmlom.Unmarshal([]byte(`<a><b></a>`), &m)
+	assert.NotNil(t, err)
+
+	m = xmlom.New()
+	err = xmlom.Unmarshal([]byte(`<a>`), &m)
+	assert.NotNil(t, err)
+}
+
+func TestMarshal_goValues(t *testing.T) {
+	item := orderedmap.New[string, any]()
+	item.Store("@n", 2)
+	item.Store("#text", 3.5)
+
+	root := orderedmap.New[string, any]()
+	root.Store("@b", true)
+	root.Store("s", "a<b")
+	root.Store("i", []any{&item, "x", nil})
+	root.Store("v", item)
+	root.Store("nil", (*orderedmap.Map[string, any])(nil))
+
+	m := xmlom.New()
+	m.Store("root", &root)
+
+	bs, err := xmlom.Marshal(&m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), `<root b="true"><s>a&lt;b</s>`+
+		`<i n="2">3.5</i><i>x</i><i></i><v n="2">3.5</v><nil></nil></root>`)
+}
+
+func TestMarshal_error(t *testing.T) {
+	m := xmlom.New()
+	m.Store("", "x")
+
+	_, err := xmlom.Marshal(&m)
+	assert.NotNil(t, err)
+
+	child := orderedmap.New[string, any]()
+	child.Store("", "x")
+	m = xmlom.New()
+	m.Store("a", &child)
+	_, err = xmlom.Marshal(&m)
+	assert.NotNil(t, err)
+}
+
+func TestUnmarshal_interleavedSiblings(t *testing.T) {
+	src := `<r><x>1</x><y>2</y><x>3</x><x>4</x><y>5</y></r>`
+
+	m := xmlom.New()
+	err := xmlom.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(), "Map[r:Map[x:1 y:2 x#2:[3 4] y#2:5]]")
+
+	bs, err := xmlom.Marshal(&m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), src)
+}
+
+func TestUnmarshal_mixedText(t *testing.T) {
+	src := `<a>x &amp; y<b></b>tail<!-- c --> end<c>z</c></a>`
+
+	m := xmlom.New()
+	err := xmlom.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(), "Map[a:Map[#text:x & y b: #text#2:tail end c:z]]")
+
+	bs, err := xmlom.Marshal(&m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), `<a>x &amp; y<b></b>tail end<c>z</c></a>`)
+
+	m = xmlom.New()
+	m.TextKey = "_"
+	err = xmlom.Unmarshal([]byte(`<a>1<b></b>2<b></b>3</a>`), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(), "Map[a:Map[_:1 b: _#2:2 b#2: _#3:3]]")
+
+	bs, err = xmlom.Marshal(&m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), `<a>1<b></b>2<b></b>3</a>`)
+}
+
+func TestRoundTrip(t *testing.T) {
+	src := `<config version="2" id="x"><server name="b" port="80"></server>` +
+		`<server name="a" port="81"></server><note>keep &amp; order</note><empty></empty></config>`
+
+	m := xmlom.New()
+	err := xmlom.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+
+	bs, err := xmlom.Marshal(&m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), src)
+}
+
+type envelope struct {
+	XMLName xml.Name  `xml:"envelope"`
+	Header  string    `xml:"header"`
+	Body    xmlom.Map `xml:"body"`
+}
+
+func TestMap_asFieldOfStruct(t *testing.T) {
+	src := `<envelope><header>h</header><body z="1" a="2"><y>1</y><b>2</b>t</body></envelope>`
+
+	var env envelope
+	err := xml.Unmarshal([]byte(src), &env)
+	assert.Nil(t, err)
+	assert.Equal(t, env.Header, "h")
+	assert.Equal(t, env.Body.String(), "Map[@z:1 @a:2 y:1 b:2 #text:t]")
+
+	bs, err := xml.Marshal(env)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), src)
+}
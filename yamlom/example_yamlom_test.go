@@ -0,0 +1,33 @@
+package yamlom_test
+
+import (
+	"fmt"
+
+	"github.com/sttk/orderedmap/yamlom"
+	"gopkg.in/yaml.v3"
+)
+
+func ExampleMap_UnmarshalYAML() {
+	m := yamlom.New[string, any]()
+	b := []byte("foo: bar\nbaz:\n  qux: 1\n  quux: 2\n")
+
+	e := yaml.Unmarshal(b, &m)
+	fmt.Printf("m = %v\n", m)
+	fmt.Printf("e = %v\n", e)
+	// Output:
+	// m = Map[foo:bar baz:Map[qux:1 quux:2]]
+	// e = <nil>
+}
+
+func ExampleMap_MarshalYAML() {
+	m := yamlom.New[string, string]()
+	m.Store("foo", "bar")
+	m.Store("baz", "qux")
+
+	b, e := yaml.Marshal(m)
+	fmt.Printf("yaml = %q\n", string(b))
+	fmt.Printf("e = %v\n", e)
+	// Output:
+	// yaml = "foo: bar\nbaz: qux\n"
+	// e = <nil>
+}
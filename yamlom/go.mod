@@ -0,0 +1,16 @@
+module github.com/sttk/orderedmap/yamlom
+
+go 1.18
+
+require (
+	github.com/stretchr/testify v1.10.0
+	github.com/sttk/orderedmap v0.0.0-00010101000000-000000000000
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
+
+replace github.com/sttk/orderedmap => ../
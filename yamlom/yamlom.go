@@ -0,0 +1,314 @@
+// Copyright (C) 2023 Takayuki Sato. All Rights Reserved.
+// This program is free software under MIT License.
+// See the file LICENSE in this distribution for more details.
+
+// Package yamlom provides YAML serialization and deserialization of ordered
+// maps which preserve the order of mapping keys.
+//
+// This package is separated from orderedmap package so that orderedmap
+// package does not depend on any YAML library.
+//
+// # Usage
+//
+// To deserialize a YAML string into an ordered map is as follows:
+//
+//	om := yamlom.New[string, any]()
+//	e := yaml.Unmarshal(byteSeq, &om)
+//
+// Nested mappings are deserialized into *orderedmap.Map[string, any] when the
+// value type is any, and nested sequences are deserialized into []any.
+// Comments attached to keys are kept in a Map and are written again when
+// the Map is serialized.
+//
+// To serialize an ordered map into a YAML string is as follows:
+//
+//	byteSeq, e := yaml.Marshal(om)
+//
+// Or, functions for orderedmap.Map are also available:
+//
+//	e := yamlom.Unmarshal(byteSeq, &om)
+//	byteSeq, e := yamlom.Marshal(&om)
+package yamlom
+
+import (
+	"strconv"
+
+	"github.com/sttk/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// Map is a struct which embeds orderedmap.Map and implements yaml.Marshaler
+// and yaml.Unmarshaler interfaces.
+type Map[K comparable, V any] struct {
+	orderedmap.Map[K, V]
+	comments map[string]comments
+}
+
+type comments struct {
+	keyHead   string
+	keyLine   string
+	keyFoot   string
+	valueLine string
+}
+
+// NotMappingError is an error type which is returned when a YAML node to be
+// deserialized into an ordered map is not a mapping.
+type NotMappingError struct {
+	Line   int
+	Column int
+}
+
+func (err NotMappingError) Error() string {
+	return "yaml: line " + strconv.Itoa(err.Line) +
+		": cannot unmarshal a non-mapping node into an ordered map"
+}
+
+// New is a function which creates a new Map, which is empty.
+func New[K comparable, V any]() Map[K, V] {
+	return Map[K, V]{Map: orderedmap.New[K, V]()}
+}
+
+// MarshalYAML is a method which returns a YAML mapping node which has the
+// entries of this map in the order of key insertions.
+func (m Map[K, V]) MarshalYAML() (any, error) {
+	return encodeMapping(&m.Map, "", m.comments)
+}
+
+// UnmarshalYAML is a method which sets the content of this map from a YAML
+// mapping node.
+// The entries are stored in the order of keys in the node, and comments
+// attached to keys are kept in this map.
+func (m *Map[K, V]) UnmarshalYAML(node *yaml.Node) error {
+	if m.Len() == 0 {
+		m.Map = orderedmap.New[K, V]()
+	}
+	if m.comments == nil {
+		m.comments = make(map[string]comments)
+	}
+	return decodeNode(&m.Map, node, m.comments)
+}
+
+// Marshal is a function which serializes an ordered map into a YAML string.
+func Marshal[K comparable, V any](om *orderedmap.Map[K, V]) ([]byte, error) {
+	return yaml.Marshal(Map[K, V]{Map: *om})
+}
+
+// Unmarshal is a function which deserializes a YAML string into an ordered
+// map.
+// The map must be created with orderedmap.New.
+func Unmarshal[K comparable, V any](data []byte, om *orderedmap.Map[K, V]) error {
+	var doc yaml.Node
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+	return decodeNode(om, doc.Content[0], nil)
+}
+
+func decodeNode[K comparable, V any](
+	om *orderedmap.Map[K, V],
+	node *yaml.Node,
+	cm map[string]comments,
+) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return NotMappingError{Line: node.Line, Column: node.Column}
+	}
+	return decodeMapping(om, node, "", cm)
+}
+
+func decodeMapping[K comparable, V any](
+	om *orderedmap.Map[K, V],
+	node *yaml.Node,
+	path string,
+	cm map[string]comments,
+) error {
+	return decodeMappingContent(om, node, path, cm, false)
+}
+
+func decodeMappingContent[K comparable, V any](
+	om *orderedmap.Map[K, V],
+	node *yaml.Node,
+	path string,
+	cm map[string]comments,
+	merged bool,
+) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		kn, vn := node.Content[i], node.Content[i+1]
+
+		if kn.Tag == "!!merge" {
+			err := decodeMerge(om, vn, path, cm)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		var key K
+		err := kn.Decode(&key)
+		if err != nil {
+			return err
+		}
+		if merged {
+			if _, exists := om.Load(key); exists {
+				continue
+			}
+		}
+
+		childPath := path + "\x00k:" + kn.Value
+
+		var val V
+		if p, ok := any(&val).(*any); ok {
+			*p, err = decodeAny(vn, childPath, cm)
+		} else {
+			err = vn.Decode(&val)
+		}
+		if err != nil {
+			return err
+		}
+		om.Store(key, val)
+
+		if cm != nil && !merged {
+			c := comments{
+				keyHead:   kn.HeadComment,
+				keyLine:   kn.LineComment,
+				keyFoot:   kn.FootComment,
+				valueLine: vn.LineComment,
+			}
+			if c != (comments{}) {
+				cm[childPath] = c
+			}
+		}
+	}
+	return nil
+}
+
+func decodeMerge[K comparable, V any](
+	om *orderedmap.Map[K, V],
+	node *yaml.Node,
+	path string,
+	cm map[string]comments,
+) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		return decodeMappingContent(om, node, path, cm, true)
+	case yaml.SequenceNode:
+		for _, n := range node.Content {
+			err := decodeMerge(om, n, path, cm)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return NotMappingError{Line: node.Line, Column: node.Column}
+}
+
+func decodeAny(node *yaml.Node, path string, cm map[string]comments) (any, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return decodeAny(node.Alias, path, cm)
+	case yaml.MappingNode:
+		om := orderedmap.New[string, any]()
+		err := decodeMapping(&om, node, path, cm)
+		if err != nil {
+			return nil, err
+		}
+		return &om, nil
+	case yaml.SequenceNode:
+		arr := make([]any, 0, len(node.Content))
+		for i, n := range node.Content {
+			elem, err := decodeAny(n, path+"\x00i:"+strconv.Itoa(i), cm)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, elem)
+		}
+		return arr, nil
+	}
+
+	var v any
+	err := node.Decode(&v)
+	return v, err
+}
+
+func encodeMapping[K comparable, V any](
+	om *orderedmap.Map[K, V],
+	path string,
+	cm map[string]comments,
+) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+	for ent := om.Front(); ent != nil; ent = ent.Next() {
+		kn := &yaml.Node{}
+		err := kn.Encode(ent.Key())
+		if err != nil {
+			return nil, err
+		}
+
+		childPath := path + "\x00k:" + kn.Value
+
+		vn, err := encodeAny(ent.Value(), childPath, cm)
+		if err != nil {
+			return nil, err
+		}
+
+		if c, ok := cm[childPath]; ok {
+			kn.HeadComment = c.keyHead
+			kn.LineComment = c.keyLine
+			kn.FootComment = c.keyFoot
+			vn.LineComment = c.valueLine
+		}
+
+		node.Content = append(node.Content, kn, vn)
+	}
+
+	if len(node.Content) == 0 {
+		node.Style = yaml.FlowStyle
+	}
+	return node, nil
+}
+
+func encodeAny(v any, path string, cm map[string]comments) (*yaml.Node, error) {
+	switch x := v.(type) {
+	case *orderedmap.Map[string, any]:
+		if x != nil {
+			return encodeMapping(x, path, cm)
+		}
+	case orderedmap.Map[string, any]:
+		return encodeMapping(&x, path, cm)
+	case []any:
+		if x != nil {
+			node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			for i, elem := range x {
+				n, err := encodeAny(elem, path+"\x00i:"+strconv.Itoa(i), cm)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, n)
+			}
+			if len(node.Content) == 0 {
+				node.Style = yaml.FlowStyle
+			}
+			return node, nil
+		}
+	}
+
+	node := &yaml.Node{}
+	err := node.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
@@ -0,0 +1,228 @@
+package yamlom_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sttk/orderedmap"
+	"github.com/sttk/orderedmap/yamlom"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMap_MarshalYAML_empty(t *testing.T) {
+	m := yamlom.New[string, string]()
+
+	bs, err := yaml.Marshal(m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), "{}\n")
+}
+
+func TestMap_MarshalYAML_order(t *testing.T) {
+	m := yamlom.New[string, int]()
+	m.Store("z", 1)
+	m.Store("a", 2)
+	m.Store("m", 3)
+
+	bs, err := yaml.Marshal(m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), "z: 1\na: 2\nm: 3\n")
+
+	bs, err = yaml.Marshal(&m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), "z: 1\na: 2\nm: 3\n")
+}
+
+func TestMap_MarshalYAML_nested(t *testing.T) {
+	inner := orderedmap.New[string, any]()
+	inner.Store("y", "1")
+	inner.Store("b", true)
+
+	m := yamlom.New[int, any]()
+	m.Store(2, &inner)
+	m.Store(1, []any{inner, "x", []any{}})
+	m.Store(0, orderedmap.New[string, any]())
+	m.Store(-1, nil)
+
+	bs, err := yaml.Marshal(m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), strings.Join([]string{
+		`2:`,
+		`    "y": "1"`,
+		`    b: true`,
+		`1:`,
+		`    - "y": "1"`,
+		`      b: true`,
+		`    - x`,
+		`    - []`,
+		`0: {}`,
+		`-1: null`,
+		``,
+	}, "\n"))
+}
+
+func TestMap_UnmarshalYAML_order(t *testing.T) {
+	src := "z: 1\na: 2\nm: 3\n"
+
+	m := yamlom.New[string, int]()
+	err := yaml.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(), "Map[z:1 a:2 m:3]")
+}
+
+func TestMap_UnmarshalYAML_zeroValue(t *testing.T) {
+	src := "z: 1\na: 2\n"
+
+	var m yamlom.Map[string, int]
+	err := yaml.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(), "Map[z:1 a:2]")
+}
+
+func TestMap_UnmarshalYAML_nested(t *testing.T) {
+	src := `
+metadata:
+  name: foo
+  labels:
+    zone: b
+    app: a
+spec:
+  - name: c
+    image: x
+  - 1
+`
+	m := yamlom.New[string, any]()
+	err := yaml.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(),
+		"Map[metadata:Map[name:foo labels:Map[zone:b app:a]] spec:[Map[name:c image:x] 1]]")
+
+	v, _ := m.Load("metadata")
+	md := v.(*orderedmap.Map[string, any])
+	v, _ = md.Load("labels")
+	labels := v.(*orderedmap.Map[string, any])
+	assert.Equal(t, labels.Front().Key(), "zone")
+}
+
+func TestMap_UnmarshalYAML_typedValues(t *testing.T) {
+	src := "b: [1, 2]\na: [3]\n"
+
+	m := yamlom.New[string, []int]()
+	err := yaml.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(), "Map[b:[1 2] a:[3]]")
+}
+
+func TestMap_UnmarshalYAML_intKeys(t *testing.T) {
+	src := "3: c\n1: a\n"
+
+	m := yamlom.New[int, string]()
+	err := yaml.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(), "Map[3:c 1:a]")
+
+	m2 := yamlom.New[int, string]()
+	err = yaml.Unmarshal([]byte("x: c\n"), &m2)
+	assert.NotNil(t, err)
+}
+
+func TestMap_UnmarshalYAML_aliasAndMerge(t *testing.T) {
+	src := `
+base: &base
+  b: 1
+  a: 2
+other: *base
+derived:
+  c: 0
+  <<: *base
+  a: 3
+`
+	m := yamlom.New[string, any]()
+	err := yaml.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, m.String(),
+		"Map[base:Map[b:1 a:2] other:Map[b:1 a:2] derived:Map[c:0 b:1 a:3]]")
+}
+
+func TestMap_UnmarshalYAML_notMapping(t *testing.T) {
+	m := yamlom.New[string, any]()
+	err := yaml.Unmarshal([]byte("- a\n- b\n"), &m)
+	assert.Equal(t, err.Error(),
+		"yaml: line 1: cannot unmarshal a non-mapping node into an ordered map")
+
+	err = yaml.Unmarshal([]byte("a: 1\n<<: [1]\n"), &m)
+	assert.IsType(t, yamlom.NotMappingError{}, err)
+}
+
+func TestMap_roundTrip_withComments(t *testing.T) {
+	src := `# head of kind
+kind: Deployment # line of kind
+metadata:
+    # head of name
+    name: foo
+    labels:
+        zone: b # line of zone
+        app: a
+# head of spec
+spec:
+    - name: c # line of c
+      image: x
+`
+	m := yamlom.New[string, any]()
+	err := yaml.Unmarshal([]byte(src), &m)
+	assert.Nil(t, err)
+
+	bs, err := yaml.Marshal(m)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), src)
+}
+
+func TestMarshal(t *testing.T) {
+	om := orderedmap.New[string, string]()
+	om.Store("foo", "bar")
+	om.Store("baz", "qux")
+
+	bs, err := yamlom.Marshal(&om)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), "foo: bar\nbaz: qux\n")
+}
+
+func TestUnmarshal(t *testing.T) {
+	om := orderedmap.New[string, any]()
+	err := yamlom.Unmarshal([]byte("foo: {b: 1, a: 2}\nbaz: qux\n"), &om)
+	assert.Nil(t, err)
+	assert.Equal(t, om.String(), "Map[foo:Map[b:1 a:2] baz:qux]")
+
+	om = orderedmap.New[string, any]()
+	err = yamlom.Unmarshal([]byte(""), &om)
+	assert.Nil(t, err)
+	assert.Equal(t, om.Len(), 0)
+
+	err = yamlom.Unmarshal([]byte("null\n"), &om)
+	assert.Nil(t, err)
+	assert.Equal(t, om.Len(), 0)
+
+	err = yamlom.Unmarshal([]byte("foo"), &om)
+	assert.IsType(t, yamlom.NotMappingError{}, err)
+
+	err = yamlom.Unmarshal([]byte("foo: [}"), &om)
+	assert.NotNil(t, err)
+}
+
+func TestMap_asValueOfStruct(t *testing.T) {
+	type manifest struct {
+		Kind string                     `yaml:"kind"`
+		Data yamlom.Map[string, string] `yaml:"data"`
+	}
+
+	src := "kind: ConfigMap\ndata:\n    z: \"1\"\n    a: \"2\"\n"
+
+	var mf manifest
+	err := yaml.Unmarshal([]byte(src), &mf)
+	assert.Nil(t, err)
+	assert.Equal(t, mf.Data.String(), "Map[z:1 a:2]")
+
+	bs, err := yaml.Marshal(mf)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bs), src)
+}